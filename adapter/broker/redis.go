@@ -0,0 +1,142 @@
+package broker
+
+import (
+	"context"
+	"encoding/json"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+const channelPrefix = "comments:"
+
+// subscriberBuffer is how many comments a subscriber may lag behind before it
+// is dropped
+const subscriberBuffer = 32
+
+// RedisCommentBroker fans comments out over redis pub/sub. Each instance holds
+// a single pattern subscription and dispatches to its local subscribers.
+type RedisCommentBroker struct {
+	client *redis.Client
+	pubsub *redis.PubSub
+
+	mu          sync.Mutex
+	subscribers map[int]map[*subscriber]struct{} // movieID -> subscribers
+}
+
+type subscriber struct {
+	ch     chan model.CommentEventData
+	closed bool
+}
+
+var _ ports.ICommentBroker = (*RedisCommentBroker)(nil)
+
+func NewRedisCommentBroker(client *redis.Client) (*RedisCommentBroker, error) {
+
+	pubsub := client.PSubscribe(context.Background(), channelPrefix+"*")
+
+	// wait for the subscription so nothing published from now on is missed
+	if _, err := pubsub.Receive(context.Background()); err != nil {
+		_ = pubsub.Close()
+		return nil, err
+	}
+
+	b := &RedisCommentBroker{
+		client:      client,
+		pubsub:      pubsub,
+		subscribers: make(map[int]map[*subscriber]struct{}),
+	}
+
+	go b.dispatch(pubsub)
+
+	return b, nil
+}
+
+// Close ends the subscription, the redis client the broker was made with is
+// left to the caller
+func (b *RedisCommentBroker) Close() error {
+	return b.pubsub.Close()
+}
+
+func channel(movieID int) string {
+	return channelPrefix + strconv.Itoa(movieID)
+}
+
+func (b *RedisCommentBroker) Publish(ctx context.Context, comment model.CommentEventData) error {
+	payload, err := json.Marshal(comment)
+	if err != nil {
+		return err
+	}
+
+	return b.client.Publish(ctx, channel(comment.MovieID), payload).Err()
+}
+
+func (b *RedisCommentBroker) Subscribe(movieID int) (<-chan model.CommentEventData, func()) {
+	sub := &subscriber{ch: make(chan model.CommentEventData, subscriberBuffer)}
+
+	b.mu.Lock()
+	if b.subscribers[movieID] == nil {
+		b.subscribers[movieID] = make(map[*subscriber]struct{})
+	}
+	b.subscribers[movieID][sub] = struct{}{}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			b.remove(movieID, sub)
+			b.mu.Unlock()
+		})
+	}
+
+	return sub.ch, cancel
+}
+
+// remove drops sub and closes its channel, b.mu must be held
+func (b *RedisCommentBroker) remove(movieID int, sub *subscriber) {
+	delete(b.subscribers[movieID], sub)
+	if len(b.subscribers[movieID]) == 0 {
+		delete(b.subscribers, movieID)
+	}
+
+	if !sub.closed {
+		sub.closed = true
+		close(sub.ch)
+	}
+}
+
+func (b *RedisCommentBroker) dispatch(pubsub *redis.PubSub) {
+	for msg := range pubsub.Channel() {
+		movieID, err := strconv.Atoi(strings.TrimPrefix(msg.Channel, channelPrefix))
+		if err != nil {
+			log.Error().Err(err).Str("channel", msg.Channel).Msg("error parsing comment channel")
+			continue
+		}
+
+		var comment model.CommentEventData
+		if err := json.Unmarshal([]byte(msg.Payload), &comment); err != nil {
+			log.Error().Err(err).Str("channel", msg.Channel).Msg("error decoding published comment")
+			continue
+		}
+
+		b.mu.Lock()
+		for sub := range b.subscribers[movieID] {
+			select {
+			case sub.ch <- comment:
+			default:
+				// a slow subscriber must not hold up the others; it resumes from
+				// the last comment it got when it reconnects
+				log.Warn().Int("movie_id", movieID).Msgf("comment subscriber more than %d comments behind, dropping it", subscriberBuffer)
+				b.remove(movieID, sub)
+			}
+		}
+		b.mu.Unlock()
+	}
+}
@@ -0,0 +1,102 @@
+package broker_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/adapter/broker"
+	"github.com/iamnator/movie-api/model"
+)
+
+func newBroker(t *testing.T, m *miniredis.Miniredis) *broker.RedisCommentBroker {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	b, err := broker.NewRedisCommentBroker(client)
+	if err != nil {
+		t.Fatalf("error subscribing on miniredis: %s", err)
+	}
+	t.Cleanup(func() { _ = b.Close() })
+	return b
+}
+
+func receive(t *testing.T, comments <-chan model.CommentEventData) (model.CommentEventData, bool) {
+	t.Helper()
+
+	select {
+	case comment, ok := <-comments:
+		return comment, ok
+	case <-time.After(time.Second):
+		t.Fatalf("no comment received")
+		return model.CommentEventData{}, false
+	}
+}
+
+func Test_RedisCommentBroker_FansOutAcrossInstances(t *testing.T) {
+	m := miniredis.RunT(t)
+
+	// two instances sharing one redis
+	a, b := newBroker(t, m), newBroker(t, m)
+
+	movie1, cancel1 := b.Subscribe(1)
+	defer cancel1()
+	movie2, cancel2 := b.Subscribe(2)
+	defer cancel2()
+
+	comment := model.CommentEventData{ID: uuid.New(), MovieID: 1, Message: "hello there"}
+	if err := a.Publish(context.Background(), comment); err != nil {
+		t.Fatalf("error publishing: %s", err)
+	}
+
+	if got, _ := receive(t, movie1); got.ID != comment.ID {
+		t.Errorf("comment=%+v | expected=%+v", got, comment)
+	}
+
+	select {
+	case got := <-movie2:
+		t.Errorf("comment=%+v | expected none for another movie", got)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	cancel1()
+	if _, ok := <-movie1; ok {
+		t.Errorf("channel still open after cancel")
+	}
+}
+
+func Test_RedisCommentBroker_DropsSlowSubscriber(t *testing.T) {
+	m := miniredis.RunT(t)
+	b := newBroker(t, m)
+
+	slow, cancel := b.Subscribe(1)
+	defer cancel()
+
+	// never read until the buffer overflows
+	for i := 0; i < 64; i++ {
+		if err := b.Publish(context.Background(), model.CommentEventData{ID: uuid.New(), MovieID: 1}); err != nil {
+			t.Fatalf("error publishing: %s", err)
+		}
+	}
+
+	// let the dispatcher overflow the buffer before reading
+	time.Sleep(100 * time.Millisecond)
+
+	deadline := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-slow:
+			if !ok {
+				return
+			}
+		case <-deadline:
+			t.Fatalf("slow subscriber was not dropped")
+		}
+	}
+}
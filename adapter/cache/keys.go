@@ -45,24 +45,36 @@ func computeMovieKey[k int | string](id k) string {
 	return movieTag.computeStringKey(reflect.ValueOf(id).String())
 }
 
-// computeCharacterKey returns the key for a character
-// e.g. movie:1:character:2 -> movie:<movie_id>:character:<character_id>
-func computeCharacterKey[k int | string](characterID k) string {
-
-	typeOfCharacterID := reflect.TypeOf(characterID)
-	valueOfCharacterID := reflect.ValueOf(characterID)
+// computeCharacterKey returns the key for a character of a movie; a character
+// has a document for every movie it appears in
+// e.g. character:1:2 -> character:<movie_id>:<character_id>
+func computeCharacterKey(movieID, characterID int) string {
+	return characterTag.computeIntKey(movieID, characterID)
+}
 
-	if typeOfCharacterID.Kind() == reflect.Int {
-		return characterTag.computeIntKey(int(valueOfCharacterID.Int()))
-	} else {
-		return characterTag.computeStringKey(valueOfCharacterID.String())
-	}
+// computePlanetKey returns the key for a planet
+// e.g. planet:1 -> planet:<planet_id>
+func computePlanetKey(planetID int) string {
+	return planetTag.computeIntKey(planetID)
 }
 
 const (
 	movieTag     cacheTag = "movie"
 	characterTag cacheTag = "character"
 
+	// planetTag prefixes the planets, they are not indexed
+	planetTag cacheTag = "planet"
+
+	// responseTag prefixes the swapi responses, outside of both indexes so
+	// recreating them keeps the responses
+	responseTag cacheTag = "swapi:response"
+
+	// refreshedAtKey holds when a refresh last succeeded, outside of both indexes
+	refreshedAtKey = "cache:refreshed_at"
+
+	// fenceKey holds the highest fencing token a write carried
+	fenceKey = "cache:fence"
+
 	// DefaultTTLSec is the default TTL for cache entries
 	DefaultTTLSec = 0
 )
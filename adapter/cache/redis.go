@@ -3,6 +3,7 @@ package cache
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/rs/zerolog/log"
 	"strconv"
 	"strings"
@@ -16,133 +17,212 @@ import (
 )
 
 type RedisCache struct {
+	client         *redis.Client
+	pool           *goredis.Pool // of the redisearch clients, which do not speak go-redis
 	characterIndex *redisearch.Client
 	movieIndex     *redisearch.Client
 }
 
-func NewRedisCache(url string) (*RedisCache, error) {
-
-	opts, err := redis.ParseURL(url)
-	if err != nil {
-		return nil, err
-	}
+// NewRedisCache connects to redis and recreates the indexes, dropping the
+// documents they held so they follow the latest schema
+func NewRedisCache(client *redis.Client) (*RedisCache, error) {
+	return connect(client, true)
+}
 
-	redisClient := redis.NewClient(opts)
+// OpenRedisCache connects to redis keeping the cached documents, only creating
+// the indexes that are missing
+func OpenRedisCache(client *redis.Client) (*RedisCache, error) {
+	return connect(client, false)
+}
 
-	if _, err := redisClient.Ping(context.TODO()).Result(); err != nil {
-		return nil, err
-	}
+func connect(redisClient *redis.Client, recreate bool) (*RedisCache, error) {
 
+	opts := redisClient.Options()
 	pool := &goredis.Pool{Dial: func() (goredis.Conn, error) {
 		return goredis.Dial(opts.Network, opts.Addr, goredis.DialPassword(opts.Password))
 	}}
 
-	if err := createMovieSchema(context.Background(), redisClient); err != nil {
-		return nil, err
-	}
-
-	if err := createCharacterSchema(context.Background(), redisClient); err != nil {
-		return nil, err
+	for _, index := range []struct {
+		name   string
+		create func(ctx context.Context, client *redis.Client) error
+	}{
+		{MovieIndexName, createMovieSchema},
+		{CharacterIndexName, createCharacterSchema},
+	} {
+		if !recreate && indexExists(context.Background(), redisClient, index.name) {
+			continue
+		}
+		if err := index.create(context.Background(), redisClient); err != nil {
+			return nil, err
+		}
 	}
 
 	return &RedisCache{
+		client:         redisClient,
+		pool:           pool,
 		characterIndex: getRedisSearchClient(pool, CharacterIndexName),
 		movieIndex:     getRedisSearchClient(pool, MovieIndexName),
 	}, nil
 }
 
+// Close closes the connections of the redisearch clients, the redis client
+// the cache was opened with is left to the caller
+func (r RedisCache) Close() error {
+	return r.pool.Close()
+}
+
 func getRedisSearchClient(pool *goredis.Pool, index string) *redisearch.Client {
 	return redisearch.NewClientFromPool(pool, index)
 }
 
 var _ ports.ICache = (*RedisCache)(nil)
 
-func (r RedisCache) SetMovies(movies []model.MovieDetails) error {
-
-	// Create a document from movies
-
-	var docs redisearch.DocumentList
-	var doc redisearch.Document
+// staleFenceReply starts the error the scripts reply with to a stale token
+const staleFenceReply = "STALE_FENCE "
+
+// the writes run as scripts checking their fencing token against the highest
+// one accepted: a lower token is rejected, a higher one is recorded. A zero
+// token skips the check.
+const fenceCheck = `
+local fence = tonumber(ARGV[1])
+if fence > 0 then
+	local seen = tonumber(redis.call("GET", KEYS[1]) or "0")
+	if fence < seen then
+		return redis.error_reply("` + staleFenceReply + `token " .. fence .. " is older than " .. seen)
+	end
+	if fence > seen then
+		redis.call("SET", KEYS[1], tostring(fence))
+	end
+end
+`
+
+var (
+	// fencedHSetScript sets the fields of every hash in KEYS[2:], ARGV[2:]
+	// holding for each hash the number of arguments then its fields and values
+	fencedHSetScript = redis.NewScript(fenceCheck + `
+local i = 2
+for k = 2, #KEYS do
+	local n = tonumber(ARGV[i])
+	redis.call("HSET", KEYS[k], unpack(ARGV, i + 1, i + n))
+	i = i + n + 1
+end
+return #KEYS - 1`)
+
+	// fencedDelScript deletes KEYS[2:]
+	fencedDelScript = redis.NewScript(fenceCheck + `
+return redis.call("DEL", unpack(KEYS, 2))`)
+
+	// fencedSetScript sets KEYS[2] to ARGV[2]
+	fencedSetScript = redis.NewScript(fenceCheck + `
+return redis.call("SET", KEYS[2], ARGV[2])`)
+)
 
-	for _, movie := range movies {
+// runFenced runs a fenced write script on keys, mapping a rejected token to
+// ports.ErrStaleFence
+func (r RedisCache) runFenced(ctx context.Context, script *redis.Script, fence int64, keys []string, args ...interface{}) error {
+	err := script.Run(ctx, r.client, append([]string{fenceKey}, keys...), append([]interface{}{fence}, args...)...).Err()
+	if err == nil {
+		return nil
+	}
 
-		doc = redisearch.NewDocument(computeMovieKey(movie.ID), 1.0).
-			Set("id", movie.ID).
-			Set("name", movie.Name).
-			Set("release_date", movie.ReleaseDate.UTC().Format(time.RFC3339)).
-			Set("director", movie.Director).
-			Set("producer", movie.Producer).
-			Set("opening_crawl", movie.OpeningCrawl).
-			Set("created_at", movie.CreatedAt.UTC().Format(time.RFC3339)).
-			Set("updated_at", movie.UpdatedAt.UTC().Format(time.RFC3339))
+	// some servers prefix the error reply with ERR
+	if i := strings.Index(err.Error(), staleFenceReply); i >= 0 {
+		return fmt.Errorf("%w: %s", ports.ErrStaleFence, err.Error()[i+len(staleFenceReply):])
+	}
+	return err
+}
 
-		docs = append(docs, doc)
+// setHashes writes the fields of every document; the indexes follow the
+// hashes under their prefix. Fields not given are kept, such as the comment
+// count of a movie.
+func (r RedisCache) setHashes(ctx context.Context, fence int64, docs map[string][]interface{}) error {
+	if len(docs) == 0 {
+		return nil
 	}
 
-	// Add the document to the index
-	if err := r.movieIndex.IndexOptions(redisearch.IndexingOptions{
-		Language:         redisearch.DefaultIndexingOptions.Language,
-		NoSave:           redisearch.DefaultIndexingOptions.NoSave,
-		Replace:          true,
-		Partial:          true,
-		ReplaceCondition: redisearch.DefaultIndexingOptions.ReplaceCondition, // replace only if the document exists
-	}, docs...); err != nil {
-		return err
+	keys := make([]string, 0, len(docs))
+	var args []interface{}
+	for key, fields := range docs {
+		keys = append(keys, key)
+		args = append(args, len(fields))
+		args = append(args, fields...)
 	}
 
-	return nil
+	return r.runFenced(ctx, fencedHSetScript, fence, keys, args...)
 }
 
-func (r RedisCache) SetMovieByID(movieID int, movie model.MovieDetails) error {
-
-	// Create a document from movies
-	doc := redisearch.NewDocument(computeMovieKey(movieID), 1.0)
+func movieFields(movieID int, movie model.MovieDetails) []interface{} {
+	return []interface{}{
+		"id", movieID,
+		"name", movie.Name,
+		"release_date", movie.ReleaseDate.UTC().Format(time.RFC3339),
+		"director", movie.Director,
+		"producer", movie.Producer,
+		"opening_crawl", movie.OpeningCrawl,
+		"created_at", movie.CreatedAt.UTC().Format(time.RFC3339),
+		"updated_at", movie.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
 
-	doc.
-		Set("id", movieID).
-		Set("name", movie.Name).
-		Set("release_date", movie.ReleaseDate.UTC().Format(time.RFC3339)).
-		Set("director", movie.Director).
-		Set("producer", movie.Producer).
-		Set("opening_crawl", movie.OpeningCrawl).
-		Set("created_at", movie.CreatedAt.UTC().Format(time.RFC3339)).
-		Set("updated_at", movie.UpdatedAt.UTC().Format(time.RFC3339))
+func planetFields(planet model.Planet) []interface{} {
+	return []interface{}{
+		"id", planet.ID,
+		"name", planet.Name,
+		"climate", planet.Climate,
+		"terrain", planet.Terrain,
+		"population", planet.Population,
+		"updated_at", planet.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
 
-	// Add the document to the index
-	if err := r.movieIndex.IndexOptions(redisearch.DefaultIndexingOptions, doc); err != nil {
-		return err
+func characterFields(movieID int, character model.Character) []interface{} {
+	heightKnown := 0
+	if character.HeightKnown {
+		heightKnown = 1
 	}
 
-	return nil
+	return []interface{}{
+		"id", character.ID,
+		"name", character.Name,
+		"movie_id", movieID,
+		"gender", character.Gender,
+		"height_cm", character.HeightCm,
+		"height_known", heightKnown,
+		"updated_at", character.UpdatedAt.UTC().Format(time.RFC3339),
+	}
 }
 
-func (r RedisCache) SetCharactersByMovieID(movieID int, characters []model.Character) error {
+func (r RedisCache) SetMovies(ctx context.Context, fence int64, movies []model.MovieDetails) error {
+	docs := make(map[string][]interface{}, len(movies))
+	for _, movie := range movies {
+		docs[computeMovieKey(movie.ID)] = movieFields(movie.ID, movie)
+	}
+
+	return r.setHashes(ctx, fence, docs)
+}
 
-	// Create a document from movies
-	var docs redisearch.DocumentList
-	var doc redisearch.Document
+func (r RedisCache) SetMovieByID(ctx context.Context, fence int64, movieID int, movie model.MovieDetails) error {
+	return r.setHashes(ctx, fence, map[string][]interface{}{
+		computeMovieKey(movieID): movieFields(movieID, movie),
+	})
+}
 
+func (r RedisCache) SetCharactersByMovieID(ctx context.Context, fence int64, movieID int, characters []model.Character) error {
+	docs := make(map[string][]interface{}, len(characters))
 	for _, character := range characters {
-		doc = redisearch.NewDocument(computeCharacterKey(character.ID), 1.0).
-			Set("id", character.ID).
-			Set("name", character.Name).
-			Set("movie_id", character.MovieID).
-			Set("gender", character.Gender).
-			Set("height_cm", character.HeightCm)
-
-		docs = append(docs, doc)
+		docs[computeCharacterKey(movieID, character.ID)] = characterFields(movieID, character)
 	}
 
-	opts := redisearch.DefaultIndexingOptions
-	opts.Replace = true
-	opts.Partial = true
+	return r.setHashes(ctx, fence, docs)
+}
 
-	//Add the document to the index
-	if err := r.characterIndex.IndexOptions(opts, docs...); err != nil {
-		return err
+func (r RedisCache) SetPlanets(ctx context.Context, fence int64, planets []model.Planet) error {
+	docs := make(map[string][]interface{}, len(planets))
+	for _, planet := range planets {
+		docs[computePlanetKey(planet.ID)] = planetFields(planet)
 	}
 
-	return nil
+	return r.setHashes(ctx, fence, docs)
 }
 
 ///
@@ -151,7 +231,7 @@ func (r RedisCache) SetCharactersByMovieID(movieID int, characters []model.Chara
 //
 //
 
-func (r RedisCache) GetMovies(page, pageSize int) ([]model.MovieDetails, int64, error) {
+func (r RedisCache) GetMovies(ctx context.Context, page, pageSize int) ([]model.MovieDetails, int64, error) {
 
 	if page < 1 {
 		page = 1
@@ -188,20 +268,21 @@ func (r RedisCache) GetMovies(page, pageSize int) ([]model.MovieDetails, int64,
 		movie.OpeningCrawl = doc.Properties["opening_crawl"].(string)
 		movie.CreatedAt, _ = time.Parse(time.RFC3339, doc.Properties["created_at"].(string))
 		movie.UpdatedAt, _ = time.Parse(time.RFC3339, doc.Properties["updated_at"].(string))
+		movie.CommentCount = parseCommentCount(doc.Properties)
 
 		movies = append(movies, movie)
 	}
 
-	log.Info().Msgf("movies: %v", len(movies))
+	log.Ctx(ctx).Info().Msgf("movies: %v", len(movies))
 
 	return movies, int64(count), nil
 }
 
-func (r RedisCache) GetMovieByID(id int) (*model.MovieDetails, error) {
+func (r RedisCache) GetMovieByID(ctx context.Context, id int) (*model.MovieDetails, error) {
 	var movie model.MovieDetails
 
 	mvId := computeMovieKey(id)
-	log.Info().Msgf("movie id: %v", mvId)
+	log.Ctx(ctx).Info().Msgf("movie id: %v", mvId)
 
 	docs, err := r.movieIndex.Get(mvId)
 	if err != nil {
@@ -227,11 +308,12 @@ func (r RedisCache) GetMovieByID(id int) (*model.MovieDetails, error) {
 	movie.OpeningCrawl = docs.Properties["opening_crawl"].(string)
 	movie.CreatedAt, _ = time.Parse(time.RFC3339, docs.Properties["created_at"].(string))
 	movie.UpdatedAt, _ = time.Parse(time.RFC3339, docs.Properties["updated_at"].(string))
+	movie.CommentCount = parseCommentCount(docs.Properties)
 
 	return &movie, nil
 }
 
-func (r RedisCache) GetCharactersByMovieID(movieID int, page, pageSize int, filter ports.GetCharacterFiler) ([]model.Character, int64, error) {
+func (r RedisCache) GetCharactersByMovieID(ctx context.Context, movieID int, page, pageSize int, filter ports.GetCharacterFiler) ([]model.Character, int64, error) {
 
 	query := redisearch.NewQuery(`@movie_id:{` + strconv.Itoa(movieID) + `}`)
 
@@ -274,6 +356,15 @@ func (r RedisCache) GetCharactersByMovieID(movieID int, page, pageSize int, filt
 			Gender:   doc.Properties["gender"].(string),
 			HeightCm: height,
 		}
+		if known, ok := doc.Properties["height_known"].(string); ok {
+			character.HeightKnown = known == "1"
+		} else {
+			// cached before unknown heights were flagged, they were cached as 0
+			character.HeightKnown = height > 0
+		}
+		if updatedAt, ok := doc.Properties["updated_at"].(string); ok {
+			character.UpdatedAt, _ = time.Parse(time.RFC3339, updatedAt)
+		}
 
 		id = doc.Properties["id"].(string)
 		if strings.Contains(id, ":") {
@@ -288,3 +379,208 @@ func (r RedisCache) GetCharactersByMovieID(movieID int, page, pageSize int, filt
 
 	return characters, int64(count), nil
 }
+
+// setCommentCountScript only touches movies that are cached, a bare hash
+// would otherwise be indexed as an empty movie
+var setCommentCountScript = redis.NewScript(`
+if redis.call("EXISTS", KEYS[1]) == 1 then
+	return redis.call("HSET", KEYS[1], "comment_count", ARGV[1])
+end
+return 0`)
+
+// parseCommentCount reads the comment count of a movie document; movies cached
+// before their comments were counted have none
+func parseCommentCount(properties map[string]interface{}) int64 {
+	v, ok := properties["comment_count"].(string)
+	if !ok {
+		return 0
+	}
+	count, _ := strconv.ParseInt(v, 10, 64)
+	return count
+}
+
+func (r RedisCache) SetMovieCommentCounts(ctx context.Context, counts map[int]int64) error {
+	if len(counts) == 0 {
+		return nil
+	}
+
+	pipe := r.client.Pipeline()
+
+	for movieID, count := range counts {
+		key := computeMovieKey(movieID)
+
+		setCommentCountScript.Eval(ctx, pipe, []string{key}, count)
+	}
+
+	_, err := pipe.Exec(ctx)
+	return err
+}
+
+// versionsPageSize is how many documents are read per search when listing versions
+const versionsPageSize = 1000
+
+// searchAll pages through every document of the index, returning only fields
+func searchAll(index *redisearch.Client, fields ...string) ([]redisearch.Document, error) {
+	var all []redisearch.Document
+
+	for offset := 0; ; offset += versionsPageSize {
+		query := redisearch.NewQuery("*").
+			SetReturnFields(fields...).
+			Limit(offset, versionsPageSize)
+
+		docs, total, err := index.Search(query)
+		if err != nil {
+			return nil, err
+		}
+
+		all = append(all, docs...)
+
+		if len(docs) == 0 || offset+len(docs) >= total {
+			return all, nil
+		}
+	}
+}
+
+func (r RedisCache) GetMovieVersions(ctx context.Context) (map[int]time.Time, error) {
+
+	docs, err := searchAll(r.movieIndex, "id", "updated_at")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[int]time.Time, len(docs))
+	for _, doc := range docs {
+		id, _ := strconv.Atoi(doc.Properties["id"].(string))
+		updatedAt, _ := time.Parse(time.RFC3339, doc.Properties["updated_at"].(string))
+		versions[id] = updatedAt
+	}
+
+	return versions, nil
+}
+
+func (r RedisCache) GetCharacterVersions(ctx context.Context) (map[ports.CharacterKey]time.Time, error) {
+
+	docs, err := searchAll(r.characterIndex, "id", "movie_id", "updated_at")
+	if err != nil {
+		return nil, err
+	}
+
+	versions := make(map[ports.CharacterKey]time.Time, len(docs))
+	for _, doc := range docs {
+		var key ports.CharacterKey
+		key.CharacterID, _ = strconv.Atoi(doc.Properties["id"].(string))
+		key.MovieID, _ = strconv.Atoi(doc.Properties["movie_id"].(string))
+
+		// documents cached before updated_at existed count as the oldest version
+		var updatedAt time.Time
+		if v, ok := doc.Properties["updated_at"].(string); ok {
+			updatedAt, _ = time.Parse(time.RFC3339, v)
+		}
+		versions[key] = updatedAt
+	}
+
+	return versions, nil
+}
+
+func (r RedisCache) DeleteMovies(ctx context.Context, fence int64, ids ...int) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(ids))
+	for _, id := range ids {
+		keys = append(keys, computeMovieKey(id))
+	}
+
+	// deleting the hash removes the document from the index
+	return r.runFenced(ctx, fencedDelScript, fence, keys)
+}
+
+func (r RedisCache) DeleteCharacters(ctx context.Context, fence int64, characters ...ports.CharacterKey) error {
+	if len(characters) == 0 {
+		return nil
+	}
+
+	keys := make([]string, 0, len(characters))
+	for _, character := range characters {
+		keys = append(keys, computeCharacterKey(character.MovieID, character.CharacterID))
+	}
+
+	return r.runFenced(ctx, fencedDelScript, fence, keys)
+}
+
+func (r RedisCache) DocumentCounts(ctx context.Context) (map[string]int64, error) {
+	counts := make(map[string]int64, 2)
+
+	for _, index := range []string{MovieIndexName, CharacterIndexName} {
+		count, err := r.documentCount(ctx, index)
+		if err != nil {
+			return nil, err
+		}
+		counts[index] = count
+	}
+
+	return counts, nil
+}
+
+func (r RedisCache) documentCount(ctx context.Context, index string) (int64, error) {
+	info, err := r.IndexInfo(ctx, index)
+	if err != nil {
+		return 0, err
+	}
+
+	numDocs, ok := info["num_docs"]
+	if !ok {
+		return 0, errors.New("num_docs missing from index info")
+	}
+
+	return strconv.ParseInt(numDocs, 10, 64)
+}
+
+// IndexInfo returns the scalar fields FT.INFO reports about the index, such
+// as num_docs or indexing, by name; nested fields are left out
+func (r RedisCache) IndexInfo(ctx context.Context, index string) (map[string]string, error) {
+	info, err := r.client.Do(ctx, "FT.INFO", index).Slice()
+	if err != nil {
+		return nil, err
+	}
+
+	// a flat list of field names and values
+	fields := make(map[string]string, len(info)/2)
+	for i := 0; i+1 < len(info); i += 2 {
+		name, ok := info[i].(string)
+		if !ok {
+			continue
+		}
+
+		switch v := info[i+1].(type) {
+		case string:
+			fields[name] = v
+		case int64:
+			fields[name] = strconv.FormatInt(v, 10)
+		case float64:
+			fields[name] = strconv.FormatFloat(v, 'f', -1, 64)
+		}
+	}
+
+	return fields, nil
+}
+
+func (r RedisCache) Ping(ctx context.Context) error {
+	return r.client.Ping(ctx).Err()
+}
+
+func (r RedisCache) SetRefreshedAt(ctx context.Context, fence int64, at time.Time) error {
+	return r.runFenced(ctx, fencedSetScript, fence, []string{refreshedAtKey}, at.UTC().Format(time.RFC3339))
+}
+
+func (r RedisCache) GetRefreshedAt(ctx context.Context) (time.Time, error) {
+	v, err := r.client.Get(ctx, refreshedAtKey).Result()
+	if errors.Is(err, redis.Nil) {
+		return time.Time{}, nil
+	}
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Parse(time.RFC3339, v)
+}
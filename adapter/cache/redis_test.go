@@ -0,0 +1,73 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+// miniredis knows no redisearch, the writes only need the hashes
+func newTestCache(t *testing.T) (RedisCache, *miniredis.Miniredis) {
+	m := miniredis.RunT(t)
+	return RedisCache{client: redis.NewClient(&redis.Options{Addr: m.Addr()})}, m
+}
+
+func Test_RedisCache_RejectsStaleFence(t *testing.T) {
+	r, m := newTestCache(t)
+	ctx := context.Background()
+
+	// the refresh holding token 2 took over from the one holding token 1
+	if err := r.SetMovies(ctx, 2, []model.MovieDetails{{ID: 1, Name: "A New Hope"}}); err != nil {
+		t.Fatalf("error writing with the current token: %s", err)
+	}
+	m.HSet(computeMovieKey(1), "comment_count", "3")
+
+	if err := r.SetMovies(ctx, 1, []model.MovieDetails{{ID: 1, Name: "stale"}}); !errors.Is(err, ports.ErrStaleFence) {
+		t.Errorf("stale SetMovies err=%v | expected=%v", err, ports.ErrStaleFence)
+	}
+	if err := r.SetCharactersByMovieID(ctx, 1, 1, []model.Character{{ID: 1, Name: "stale"}}); !errors.Is(err, ports.ErrStaleFence) {
+		t.Errorf("stale SetCharactersByMovieID err=%v | expected=%v", err, ports.ErrStaleFence)
+	}
+	if err := r.DeleteMovies(ctx, 1, 1); !errors.Is(err, ports.ErrStaleFence) {
+		t.Errorf("stale DeleteMovies err=%v | expected=%v", err, ports.ErrStaleFence)
+	}
+	if err := r.SetRefreshedAt(ctx, 1, time.Now()); !errors.Is(err, ports.ErrStaleFence) {
+		t.Errorf("stale SetRefreshedAt err=%v | expected=%v", err, ports.ErrStaleFence)
+	}
+
+	if name := m.HGet(computeMovieKey(1), "name"); name != "A New Hope" {
+		t.Errorf("name=%s | expected the current write to be kept", name)
+	}
+	if m.Exists(computeCharacterKey(1, 1)) || m.Exists(refreshedAtKey) {
+		t.Errorf("stale writes reached redis")
+	}
+
+	// a newer token moves the fence up, the fields it does not write are kept
+	if err := r.SetMovies(ctx, 3, []model.MovieDetails{{ID: 1, Name: "Episode IV"}}); err != nil {
+		t.Fatalf("error writing with a newer token: %s", err)
+	}
+	if name, count := m.HGet(computeMovieKey(1), "name"), m.HGet(computeMovieKey(1), "comment_count"); name != "Episode IV" || count != "3" {
+		t.Errorf("name=%s comment_count=%s | expected=Episode IV 3", name, count)
+	}
+	if fence, _ := m.Get(fenceKey); fence != "3" {
+		t.Errorf("fence=%s | expected=3", fence)
+	}
+	if err := r.SetMovies(ctx, 2, []model.MovieDetails{{ID: 1}}); !errors.Is(err, ports.ErrStaleFence) {
+		t.Errorf("token 2 after 3 err=%v | expected=%v", err, ports.ErrStaleFence)
+	}
+
+	// writers holding no lock write unfenced
+	if err := r.DeleteMovies(ctx, 0, 1); err != nil {
+		t.Errorf("unfenced DeleteMovies err=%v | expected=nil", err)
+	}
+	if m.Exists(computeMovieKey(1)) {
+		t.Errorf("movie was not deleted")
+	}
+}
@@ -0,0 +1,43 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	swapi "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+)
+
+// ResponseStore keeps the responses of the swapi client in redis, shared by
+// every instance. Each is kept for ttl after it was last stored, revalidating
+// a response stores it again.
+type ResponseStore struct {
+	client *redis.Client
+	ttl    time.Duration
+}
+
+var _ swapi.ResponseStore = ResponseStore{}
+
+func NewResponseStore(client *redis.Client, ttl time.Duration) *ResponseStore {
+	return &ResponseStore{
+		client: client,
+		ttl:    ttl,
+	}
+}
+
+func (s ResponseStore) Get(ctx context.Context, key string) ([]byte, bool, error) {
+	value, err := s.client.Get(ctx, responseTag.computeStringKey(key)).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return value, true, nil
+}
+
+func (s ResponseStore) Set(ctx context.Context, key string, value []byte) error {
+	return s.client.Set(ctx, responseTag.computeStringKey(key), value, s.ttl).Err()
+}
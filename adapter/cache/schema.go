@@ -10,6 +10,11 @@ const (
 	CharacterIndexName = "idx:characters"
 )
 
+// indexExists reports whether redisearch knows the index
+func indexExists(ctx context.Context, client *redis.Client, index string) bool {
+	return client.Do(ctx, "FT.INFO", index).Err() == nil
+}
+
 func createMovieSchema(ctx context.Context, client *redis.Client) error {
 
 	//drop the index if it exists
@@ -25,6 +30,7 @@ func createMovieSchema(ctx context.Context, client *redis.Client) error {
 		"release_date", "TEXT", "WEIGHT", "5.0", "SORTABLE",
 		"created_at", "TEXT",
 		"updated_at", "TEXT",
+		"comment_count", "NUMERIC",
 	).
 		Err()
 
@@ -46,6 +52,7 @@ func createCharacterSchema(ctx context.Context, client *redis.Client) error {
 		"movie_id", "TAG", "SORTABLE",
 		"gender", "TAG", "SORTABLE",
 		"height_cm", "NUMERIC", "SORTABLE",
+		"updated_at", "TEXT",
 	).
 		Err()
 	if err != nil {
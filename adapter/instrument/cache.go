@@ -0,0 +1,133 @@
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+// cache records the latency of every call to the cache
+type cache struct {
+	next ports.ICache
+}
+
+var _ ports.ICache = cache{}
+
+// Cache records the latency of every call to next
+func Cache(next ports.ICache) ports.ICache {
+	return cache{next: next}
+}
+
+func (c cache) SetMovies(ctx context.Context, fence int64, movies []model.MovieDetails) error {
+	ctx, call := startCall(ctx, cachePort, "SetMovies")
+	err := c.next.SetMovies(ctx, fence, movies)
+	call.end(err)
+	return err
+}
+
+func (c cache) SetMovieByID(ctx context.Context, fence int64, id int, movie model.MovieDetails) error {
+	ctx, call := startCall(ctx, cachePort, "SetMovieByID")
+	err := c.next.SetMovieByID(ctx, fence, id, movie)
+	call.end(err)
+	return err
+}
+
+func (c cache) SetCharactersByMovieID(ctx context.Context, fence int64, id int, characters []model.Character) error {
+	ctx, call := startCall(ctx, cachePort, "SetCharactersByMovieID")
+	err := c.next.SetCharactersByMovieID(ctx, fence, id, characters)
+	call.end(err)
+	return err
+}
+
+func (c cache) SetPlanets(ctx context.Context, fence int64, planets []model.Planet) error {
+	ctx, call := startCall(ctx, cachePort, "SetPlanets")
+	err := c.next.SetPlanets(ctx, fence, planets)
+	call.end(err)
+	return err
+}
+
+func (c cache) GetMovies(ctx context.Context, page int, pageSize int) ([]model.MovieDetails, int64, error) {
+	ctx, call := startCall(ctx, cachePort, "GetMovies")
+	res, count, err := c.next.GetMovies(ctx, page, pageSize)
+	call.end(err)
+	return res, count, err
+}
+
+func (c cache) GetMovieByID(ctx context.Context, id int) (*model.MovieDetails, error) {
+	ctx, call := startCall(ctx, cachePort, "GetMovieByID")
+	res, err := c.next.GetMovieByID(ctx, id)
+	call.end(err)
+	return res, err
+}
+
+func (c cache) GetCharactersByMovieID(ctx context.Context, id int, page int, pageSize int, filter ports.GetCharacterFiler) ([]model.Character, int64, error) {
+	ctx, call := startCall(ctx, cachePort, "GetCharactersByMovieID")
+	res, count, err := c.next.GetCharactersByMovieID(ctx, id, page, pageSize, filter)
+	call.end(err)
+	return res, count, err
+}
+
+func (c cache) GetMovieVersions(ctx context.Context) (map[int]time.Time, error) {
+	ctx, call := startCall(ctx, cachePort, "GetMovieVersions")
+	res, err := c.next.GetMovieVersions(ctx)
+	call.end(err)
+	return res, err
+}
+
+func (c cache) GetCharacterVersions(ctx context.Context) (map[ports.CharacterKey]time.Time, error) {
+	ctx, call := startCall(ctx, cachePort, "GetCharacterVersions")
+	res, err := c.next.GetCharacterVersions(ctx)
+	call.end(err)
+	return res, err
+}
+
+func (c cache) SetMovieCommentCounts(ctx context.Context, counts map[int]int64) error {
+	ctx, call := startCall(ctx, cachePort, "SetMovieCommentCounts")
+	err := c.next.SetMovieCommentCounts(ctx, counts)
+	call.end(err)
+	return err
+}
+
+func (c cache) DeleteMovies(ctx context.Context, fence int64, ids ...int) error {
+	ctx, call := startCall(ctx, cachePort, "DeleteMovies")
+	err := c.next.DeleteMovies(ctx, fence, ids...)
+	call.end(err)
+	return err
+}
+
+func (c cache) DeleteCharacters(ctx context.Context, fence int64, keys ...ports.CharacterKey) error {
+	ctx, call := startCall(ctx, cachePort, "DeleteCharacters")
+	err := c.next.DeleteCharacters(ctx, fence, keys...)
+	call.end(err)
+	return err
+}
+
+func (c cache) DocumentCounts(ctx context.Context) (map[string]int64, error) {
+	ctx, call := startCall(ctx, cachePort, "DocumentCounts")
+	res, err := c.next.DocumentCounts(ctx)
+	call.end(err)
+	return res, err
+}
+
+func (c cache) Ping(ctx context.Context) error {
+	ctx, call := startCall(ctx, cachePort, "Ping")
+	err := c.next.Ping(ctx)
+	call.end(err)
+	return err
+}
+
+func (c cache) SetRefreshedAt(ctx context.Context, fence int64, at time.Time) error {
+	ctx, call := startCall(ctx, cachePort, "SetRefreshedAt")
+	err := c.next.SetRefreshedAt(ctx, fence, at)
+	call.end(err)
+	return err
+}
+
+func (c cache) GetRefreshedAt(ctx context.Context) (time.Time, error) {
+	ctx, call := startCall(ctx, cachePort, "GetRefreshedAt")
+	res, err := c.next.GetRefreshedAt(ctx)
+	call.end(err)
+	return res, err
+}
@@ -0,0 +1,99 @@
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+type commentRepository struct {
+	next ports.ICommentRepository
+}
+
+var _ ports.ICommentRepository = commentRepository{}
+
+// CommentRepository records the latency of every call to next
+func CommentRepository(next ports.ICommentRepository) ports.ICommentRepository {
+	return commentRepository{next: next}
+}
+
+func (r commentRepository) AddComment(ctx context.Context, comment *model.Comment) error {
+	ctx, call := startCall(ctx, commentRepositoryPort, "AddComment")
+	err := r.next.AddComment(ctx, comment)
+	call.end(err)
+	return err
+}
+
+func (r commentRepository) GetComment(ctx context.Context, commentID uuid.UUID) (*model.Comment, error) {
+	ctx, call := startCall(ctx, commentRepositoryPort, "GetComment")
+	res, err := r.next.GetComment(ctx, commentID)
+	call.end(err)
+	return res, err
+}
+
+func (r commentRepository) GetCommentsByID(ctx context.Context, commentID ...uuid.UUID) ([]model.Comment, error) {
+	ctx, call := startCall(ctx, commentRepositoryPort, "GetCommentsByID")
+	res, err := r.next.GetCommentsByID(ctx, commentID...)
+	call.end(err)
+	return res, err
+}
+
+func (r commentRepository) GetCommentsByIPAddr(ctx context.Context, ipAddr string, page int, pageSize int) ([]model.Comment, int64, error) {
+	ctx, call := startCall(ctx, commentRepositoryPort, "GetCommentsByIPAddr")
+	res, count, err := r.next.GetCommentsByIPAddr(ctx, ipAddr, page, pageSize)
+	call.end(err)
+	return res, count, err
+}
+
+func (r commentRepository) GetCommentsByMovieID(ctx context.Context, movieID int, page int, pageSize int) ([]model.Comment, int64, error) {
+	ctx, call := startCall(ctx, commentRepositoryPort, "GetCommentsByMovieID")
+	res, count, err := r.next.GetCommentsByMovieID(ctx, movieID, page, pageSize)
+	call.end(err)
+	return res, count, err
+}
+
+func (r commentRepository) GetCommentCountByMovieID(ctx context.Context, movieID int) (int64, error) {
+	ctx, call := startCall(ctx, commentRepositoryPort, "GetCommentCountByMovieID")
+	res, err := r.next.GetCommentCountByMovieID(ctx, movieID)
+	call.end(err)
+	return res, err
+}
+
+func (r commentRepository) GetCommentsAfter(ctx context.Context, movieID int, afterID uuid.UUID, limit int) ([]model.Comment, error) {
+	ctx, call := startCall(ctx, commentRepositoryPort, "GetCommentsAfter")
+	res, err := r.next.GetCommentsAfter(ctx, movieID, afterID, limit)
+	call.end(err)
+	return res, err
+}
+
+func (r commentRepository) GetCommentCounts(ctx context.Context) (map[int]int64, error) {
+	ctx, call := startCall(ctx, commentRepositoryPort, "GetCommentCounts")
+	res, err := r.next.GetCommentCounts(ctx)
+	call.end(err)
+	return res, err
+}
+
+func (r commentRepository) AnonymiseIPAddrs(ctx context.Context, createdBefore time.Time) (int64, error) {
+	ctx, call := startCall(ctx, commentRepositoryPort, "AnonymiseIPAddrs")
+	res, err := r.next.AnonymiseIPAddrs(ctx, createdBefore)
+	call.end(err)
+	return res, err
+}
+
+func (r commentRepository) Ping(ctx context.Context) error {
+	ctx, call := startCall(ctx, commentRepositoryPort, "Ping")
+	err := r.next.Ping(ctx)
+	call.end(err)
+	return err
+}
+
+func (r commentRepository) SchemaVersion(ctx context.Context) (model.SchemaVersion, error) {
+	ctx, call := startCall(ctx, commentRepositoryPort, "SchemaVersion")
+	res, err := r.next.SchemaVersion(ctx)
+	call.end(err)
+	return res, err
+}
@@ -0,0 +1,56 @@
+// Package instrument decorates the service and the ports it talks to. Port
+// calls are timed, and every call carrying a context is traced.
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/iamnator/movie-api/pkg/metrics"
+)
+
+// port label of each decorated port
+const (
+	cachePort             = "cache"
+	commentRepositoryPort = "comment_repository"
+	webhookRepositoryPort = "webhook_repository"
+	outboxRepositoryPort  = "outbox_repository"
+)
+
+var tracer = otel.Tracer("github.com/iamnator/movie-api/adapter/instrument")
+
+func observe(port, method string, start time.Time, err error) {
+	metrics.ObservePortCall(port, method, err, time.Since(start))
+}
+
+// call is a port call in flight
+type call struct {
+	port, method string
+	start        time.Time
+	span         trace.Span
+}
+
+// startCall opens the span of a port call, ctx is returned with the span so
+// the port's own spans nest under it
+func startCall(ctx context.Context, port, method string) (context.Context, call) {
+	ctx, span := tracer.Start(ctx, port+"."+method, trace.WithSpanKind(trace.SpanKindClient))
+	return ctx, call{port: port, method: method, start: time.Now(), span: span}
+}
+
+func (c call) end(err error) {
+	observe(c.port, c.method, c.start, err)
+	endSpan(c.span, err)
+}
+
+// endSpan ends span, marking it failed when err is set
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,50 @@
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+type outboxRepository struct {
+	next ports.IOutboxRepository
+}
+
+var _ ports.IOutboxRepository = outboxRepository{}
+
+// OutboxRepository records the latency of every call to next
+func OutboxRepository(next ports.IOutboxRepository) ports.IOutboxRepository {
+	return outboxRepository{next: next}
+}
+
+func (r outboxRepository) ClaimOutbox(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	ctx, call := startCall(ctx, outboxRepositoryPort, "ClaimOutbox")
+	res, err := r.next.ClaimOutbox(ctx, now, limit, lease)
+	call.end(err)
+	return res, err
+}
+
+func (r outboxRepository) MarkOutboxPublished(ctx context.Context, id uuid.UUID, publishedAt time.Time) error {
+	ctx, call := startCall(ctx, outboxRepositoryPort, "MarkOutboxPublished")
+	err := r.next.MarkOutboxPublished(ctx, id, publishedAt)
+	call.end(err)
+	return err
+}
+
+func (r outboxRepository) MarkOutboxFailed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time) error {
+	ctx, call := startCall(ctx, outboxRepositoryPort, "MarkOutboxFailed")
+	err := r.next.MarkOutboxFailed(ctx, id, reason, retryAt)
+	call.end(err)
+	return err
+}
+
+func (r outboxRepository) DeletePublishedOutbox(ctx context.Context, before time.Time) (int64, error) {
+	ctx, call := startCall(ctx, outboxRepositoryPort, "DeletePublishedOutbox")
+	n, err := r.next.DeletePublishedOutbox(ctx, before)
+	call.end(err)
+	return n, err
+}
@@ -0,0 +1,146 @@
+package instrument
+
+import (
+	"context"
+
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service"
+)
+
+// services traces every call to the service
+type services struct {
+	next service.IServices
+}
+
+var _ service.IServices = services{}
+
+// Services traces every call to next, nesting the spans of the ports it calls
+func Services(next service.IServices) service.IServices {
+	return services{next: next}
+}
+
+func (s services) GetMovies(ctx context.Context, page int, pageSize int) ([]model.Movie, int64, error) {
+	ctx, span := tracer.Start(ctx, "service.GetMovies")
+	res, count, err := s.next.GetMovies(ctx, page, pageSize)
+	endSpan(span, err)
+	return res, count, err
+}
+
+func (s services) GetMovieByID(ctx context.Context, movieID int) (*model.Movie, error) {
+	ctx, span := tracer.Start(ctx, "service.GetMovieByID")
+	res, err := s.next.GetMovieByID(ctx, movieID)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s services) ValidateMovieID(ctx context.Context, movieID int) error {
+	ctx, span := tracer.Start(ctx, "service.ValidateMovieID")
+	err := s.next.ValidateMovieID(ctx, movieID)
+	endSpan(span, err)
+	return err
+}
+
+func (s services) SaveComment(ctx context.Context, movieID int, comment model.Comment) error {
+	ctx, span := tracer.Start(ctx, "service.SaveComment")
+	err := s.next.SaveComment(ctx, movieID, comment)
+	endSpan(span, err)
+	return err
+}
+
+func (s services) GetComment(ctx context.Context, movieID int, page int, pageSize int) ([]model.Comment, int64, error) {
+	ctx, span := tracer.Start(ctx, "service.GetComment")
+	res, count, err := s.next.GetComment(ctx, movieID, page, pageSize)
+	endSpan(span, err)
+	return res, count, err
+}
+
+func (s services) GetCharactersByMovieID(ctx context.Context, arg model.GetCharactersByMovieIDArgs) (*model.CharacterList, int64, error) {
+	ctx, span := tracer.Start(ctx, "service.GetCharactersByMovieID")
+	res, count, err := s.next.GetCharactersByMovieID(ctx, arg)
+	endSpan(span, err)
+	return res, count, err
+}
+
+func (s services) TriggerRefresh(ctx context.Context, filmID int) (*model.RefreshJob, error) {
+	ctx, span := tracer.Start(ctx, "service.TriggerRefresh")
+	res, err := s.next.TriggerRefresh(ctx, filmID)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s services) RunRefresh(ctx context.Context, filmID int) (*model.RefreshJob, error) {
+	ctx, span := tracer.Start(ctx, "service.RunRefresh")
+	res, err := s.next.RunRefresh(ctx, filmID)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s services) GetRefreshJob(ctx context.Context, jobID string) (*model.RefreshJob, error) {
+	ctx, span := tracer.Start(ctx, "service.GetRefreshJob")
+	res, err := s.next.GetRefreshJob(ctx, jobID)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s services) GetSchedule(ctx context.Context) []model.ScheduledJob {
+	ctx, span := tracer.Start(ctx, "service.GetSchedule")
+	defer span.End()
+	return s.next.GetSchedule(ctx)
+}
+
+func (s services) CreateWebhook(ctx context.Context, req model.AddWebhookRequest) (*model.WebhookSubscription, error) {
+	ctx, span := tracer.Start(ctx, "service.CreateWebhook")
+	res, err := s.next.CreateWebhook(ctx, req)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s services) GetWebhooks(ctx context.Context) ([]model.WebhookSubscription, error) {
+	ctx, span := tracer.Start(ctx, "service.GetWebhooks")
+	res, err := s.next.GetWebhooks(ctx)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s services) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	ctx, span := tracer.Start(ctx, "service.DeleteWebhook")
+	err := s.next.DeleteWebhook(ctx, id)
+	endSpan(span, err)
+	return err
+}
+
+func (s services) GetWebhookDeliveries(ctx context.Context, state model.WebhookDeliveryState, page int, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	ctx, span := tracer.Start(ctx, "service.GetWebhookDeliveries")
+	res, count, err := s.next.GetWebhookDeliveries(ctx, state, page, pageSize)
+	endSpan(span, err)
+	return res, count, err
+}
+
+func (s services) ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	ctx, span := tracer.Start(ctx, "service.ReplayWebhookDelivery")
+	res, err := s.next.ReplayWebhookDelivery(ctx, id)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s services) StreamComments(ctx context.Context, movieID int, lastEventID string) (<-chan model.CommentEventData, error) {
+	ctx, span := tracer.Start(ctx, "service.StreamComments")
+	res, err := s.next.StreamComments(ctx, movieID, lastEventID)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s services) WatchMovie(ctx context.Context, movieID int) (<-chan int64, error) {
+	ctx, span := tracer.Start(ctx, "service.WatchMovie")
+	res, err := s.next.WatchMovie(ctx, movieID)
+	endSpan(span, err)
+	return res, err
+}
+
+func (s services) Readiness(ctx context.Context) model.HealthReport {
+	ctx, span := tracer.Start(ctx, "service.Readiness")
+	defer span.End()
+	return s.next.Readiness(ctx)
+}
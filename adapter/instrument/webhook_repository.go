@@ -0,0 +1,85 @@
+package instrument
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+type webhookRepository struct {
+	next ports.IWebhookRepository
+}
+
+var _ ports.IWebhookRepository = webhookRepository{}
+
+// WebhookRepository records the latency of every call to next
+func WebhookRepository(next ports.IWebhookRepository) ports.IWebhookRepository {
+	return webhookRepository{next: next}
+}
+
+func (r webhookRepository) AddSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	ctx, call := startCall(ctx, webhookRepositoryPort, "AddSubscription")
+	err := r.next.AddSubscription(ctx, subscription)
+	call.end(err)
+	return err
+}
+
+func (r webhookRepository) GetSubscription(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error) {
+	ctx, call := startCall(ctx, webhookRepositoryPort, "GetSubscription")
+	res, err := r.next.GetSubscription(ctx, id)
+	call.end(err)
+	return res, err
+}
+
+func (r webhookRepository) GetSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	ctx, call := startCall(ctx, webhookRepositoryPort, "GetSubscriptions")
+	res, err := r.next.GetSubscriptions(ctx)
+	call.end(err)
+	return res, err
+}
+
+func (r webhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	ctx, call := startCall(ctx, webhookRepositoryPort, "DeleteSubscription")
+	err := r.next.DeleteSubscription(ctx, id)
+	call.end(err)
+	return err
+}
+
+func (r webhookRepository) AddDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	ctx, call := startCall(ctx, webhookRepositoryPort, "AddDeliveries")
+	err := r.next.AddDeliveries(ctx, deliveries)
+	call.end(err)
+	return err
+}
+
+func (r webhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	ctx, call := startCall(ctx, webhookRepositoryPort, "GetDelivery")
+	res, err := r.next.GetDelivery(ctx, id)
+	call.end(err)
+	return res, err
+}
+
+func (r webhookRepository) GetDeliveries(ctx context.Context, state model.WebhookDeliveryState, page int, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	ctx, call := startCall(ctx, webhookRepositoryPort, "GetDeliveries")
+	res, count, err := r.next.GetDeliveries(ctx, state, page, pageSize)
+	call.end(err)
+	return res, count, err
+}
+
+func (r webhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error) {
+	ctx, call := startCall(ctx, webhookRepositoryPort, "ClaimDueDeliveries")
+	res, err := r.next.ClaimDueDeliveries(ctx, now, limit, lease)
+	call.end(err)
+	return res, err
+}
+
+func (r webhookRepository) UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	ctx, call := startCall(ctx, webhookRepositoryPort, "UpdateDelivery")
+	err := r.next.UpdateDelivery(ctx, delivery)
+	call.end(err)
+	return err
+}
@@ -0,0 +1,57 @@
+package lock
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+const (
+	jobKeyPrefix = "job:"
+
+	// jobTTL is how long a job is reported after it was last updated
+	jobTTL = 24 * time.Hour
+)
+
+// RedisJobStore keeps the refresh jobs in the redis holding the refresh lock,
+// as JSON expiring a day after their last update
+type RedisJobStore struct {
+	client *redis.Client
+}
+
+var _ ports.IJobStore = (*RedisJobStore)(nil)
+
+func NewRedisJobStore(client *redis.Client) *RedisJobStore {
+	return &RedisJobStore{
+		client: client,
+	}
+}
+
+func (r RedisJobStore) SaveJob(ctx context.Context, job model.RefreshJob) error {
+	value, err := json.Marshal(job)
+	if err != nil {
+		return err
+	}
+
+	return r.client.Set(ctx, jobKeyPrefix+job.ID, value, jobTTL).Err()
+}
+
+func (r RedisJobStore) GetJob(ctx context.Context, id string) (model.RefreshJob, error) {
+	var job model.RefreshJob
+
+	value, err := r.client.Get(ctx, jobKeyPrefix+id).Bytes()
+	if errors.Is(err, redis.Nil) {
+		return job, ports.ErrNotFound
+	}
+	if err != nil {
+		return job, err
+	}
+
+	return job, json.Unmarshal(value, &job)
+}
@@ -0,0 +1,63 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"github.com/iamnator/movie-api/adapter/lock"
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+func newJobStore(t *testing.T, m *miniredis.Miniredis) *lock.RedisJobStore {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return lock.NewRedisJobStore(client)
+}
+
+func Test_RedisJobStore_Shared(t *testing.T) {
+	m := miniredis.RunT(t)
+	ctx := context.Background()
+
+	// the instance running the job and the one asked about it
+	a, b := newJobStore(t, m), newJobStore(t, m)
+
+	job := model.RefreshJob{
+		ID:           "6f1f1c0e-6d6b-4c8e-a7d5-1f4f7c1b2d3e",
+		Trigger:      model.RefreshTriggerManual,
+		State:        model.RefreshJobRunning,
+		FencingToken: 3,
+		StartedAt:    time.Now().UTC().Truncate(time.Second),
+	}
+	if err := a.SaveJob(ctx, job); err != nil {
+		t.Fatalf("error saving job: %s", err)
+	}
+
+	job.State = model.RefreshJobSucceeded
+	job.FilmsFetched = 6
+	if err := a.SaveJob(ctx, job); err != nil {
+		t.Fatalf("error updating job: %s", err)
+	}
+
+	got, err := b.GetJob(ctx, job.ID)
+	if err != nil {
+		t.Fatalf("error getting job: %s", err)
+	}
+	if got.State != model.RefreshJobSucceeded || got.FilmsFetched != 6 || got.FencingToken != 3 || !got.StartedAt.Equal(job.StartedAt) {
+		t.Errorf("job=%+v | expected=%+v", got, job)
+	}
+
+	m.FastForward(25 * time.Hour)
+
+	if _, err := b.GetJob(ctx, job.ID); !errors.Is(err, ports.ErrNotFound) {
+		t.Errorf("expired job err=%v | expected=%v", err, ports.ErrNotFound)
+	}
+}
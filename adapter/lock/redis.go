@@ -0,0 +1,150 @@
+package lock
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+const keyPrefix = "lock:"
+
+var (
+	// renewScript extends the lease only if it is still ours
+	renewScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("PEXPIRE", KEYS[1], ARGV[2])
+end
+return 0`)
+
+	// releaseScript deletes the lease only if it is still ours
+	releaseScript = redis.NewScript(`
+if redis.call("GET", KEYS[1]) == ARGV[1] then
+	return redis.call("DEL", KEYS[1])
+end
+return 0`)
+)
+
+// RedisLocker hands out leases backed by SET NX PX, so every instance sharing
+// the redis agrees on who holds a lock.
+type RedisLocker struct {
+	client *redis.Client
+}
+
+var _ ports.ILocker = (*RedisLocker)(nil)
+
+func NewRedisLocker(client *redis.Client) *RedisLocker {
+	return &RedisLocker{
+		client: client,
+	}
+}
+
+func (r RedisLocker) Acquire(ctx context.Context, name string, ttl time.Duration) (ports.ILease, error) {
+	key := keyPrefix + name
+
+	// the fencing counter outlives the lease so tokens keep increasing
+	token, err := r.client.Incr(ctx, key+":fence").Result()
+	if err != nil {
+		return nil, err
+	}
+
+	ok, err := r.client.SetNX(ctx, key, token, ttl).Result()
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ports.ErrLockNotAcquired
+	}
+
+	l := &lease{
+		client: r.client,
+		key:    key,
+		token:  token,
+		ttl:    ttl,
+		lost:   make(chan struct{}),
+		stop:   make(chan struct{}),
+		done:   make(chan struct{}),
+	}
+
+	go l.keepAlive()
+
+	return l, nil
+}
+
+type lease struct {
+	client *redis.Client
+	key    string
+	token  int64
+	ttl    time.Duration
+
+	lost     chan struct{}
+	lostOnce sync.Once
+
+	stop     chan struct{}
+	stopOnce sync.Once
+	done     chan struct{}
+}
+
+func (l *lease) Token() int64 {
+	return l.token
+}
+
+func (l *lease) Lost() <-chan struct{} {
+	return l.lost
+}
+
+// keepAlive renews the lease three times per ttl; if the lease is gone or
+// cannot be renewed before it expires, it is reported as lost.
+func (l *lease) keepAlive() {
+	defer close(l.done)
+
+	ticker := time.NewTicker(l.ttl / 3)
+	defer ticker.Stop()
+
+	lastRenewed := time.Now()
+
+	for {
+		select {
+		case <-l.stop:
+			return
+		case <-ticker.C:
+			ctx, cancel := context.WithTimeout(context.Background(), l.ttl/3)
+			renewed, err := renewScript.Run(ctx, l.client, []string{l.key}, l.token, l.ttl.Milliseconds()).Int()
+			cancel()
+
+			switch {
+			case err == nil && renewed == 1:
+				lastRenewed = time.Now()
+			case err == nil:
+				log.Warn().Str("lock", l.key).Int64("token", l.token).Msg("lock lease lost to another holder")
+				l.markLost()
+				return
+			case time.Since(lastRenewed) >= l.ttl:
+				log.Error().Err(err).Str("lock", l.key).Int64("token", l.token).Msg("lock lease expired before it could be renewed")
+				l.markLost()
+				return
+			default:
+				log.Warn().Err(err).Str("lock", l.key).Msg("error renewing lock lease, retrying ...")
+			}
+		}
+	}
+}
+
+func (l *lease) markLost() {
+	l.lostOnce.Do(func() {
+		close(l.lost)
+	})
+}
+
+func (l *lease) Release(ctx context.Context) error {
+	l.stopOnce.Do(func() {
+		close(l.stop)
+	})
+	<-l.done
+
+	return releaseScript.Run(ctx, l.client, []string{l.key}, l.token).Err()
+}
@@ -0,0 +1,128 @@
+package lock_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"github.com/iamnator/movie-api/adapter/lock"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+func newLocker(t *testing.T, m *miniredis.Miniredis) *lock.RedisLocker {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	return lock.NewRedisLocker(client)
+}
+
+func Test_RedisLocker_Exclusive(t *testing.T) {
+	m := miniredis.RunT(t)
+	ctx := context.Background()
+
+	// two instances sharing one redis
+	a, b := newLocker(t, m), newLocker(t, m)
+
+	leaseA, err := a.Acquire(ctx, "refresh", time.Second)
+	if err != nil {
+		t.Fatalf("error acquiring lock: %s", err)
+	}
+
+	if _, err := b.Acquire(ctx, "refresh", time.Second); !errors.Is(err, ports.ErrLockNotAcquired) {
+		t.Fatalf("error=%v | expected=%v", err, ports.ErrLockNotAcquired)
+	}
+
+	if err := leaseA.Release(ctx); err != nil {
+		t.Fatalf("error releasing lock: %s", err)
+	}
+
+	leaseB, err := b.Acquire(ctx, "refresh", time.Second)
+	if err != nil {
+		t.Fatalf("error acquiring released lock: %s", err)
+	}
+	defer leaseB.Release(ctx)
+
+	if leaseB.Token() <= leaseA.Token() {
+		t.Errorf("fencing token did not increase: %d -> %d", leaseA.Token(), leaseB.Token())
+	}
+}
+
+func Test_RedisLocker_Expired(t *testing.T) {
+	m := miniredis.RunT(t)
+	ctx := context.Background()
+
+	locker := newLocker(t, m)
+
+	lease, err := locker.Acquire(ctx, "refresh", time.Minute)
+	if err != nil {
+		t.Fatalf("error acquiring lock: %s", err)
+	}
+	defer lease.Release(ctx)
+
+	// the holder crashed and the lease timed out
+	m.FastForward(2 * time.Minute)
+
+	other, err := locker.Acquire(ctx, "refresh", time.Minute)
+	if err != nil {
+		t.Fatalf("error acquiring expired lock: %s", err)
+	}
+	defer other.Release(ctx)
+
+	// releasing a stale lease must not delete the new holder's lease
+	if err := lease.Release(ctx); err != nil {
+		t.Fatalf("error releasing stale lease: %s", err)
+	}
+	if !m.Exists("lock:refresh") {
+		t.Errorf("stale release deleted the current lease")
+	}
+}
+
+func Test_RedisLocker_Lost(t *testing.T) {
+	m := miniredis.RunT(t)
+	ctx := context.Background()
+
+	lease, err := newLocker(t, m).Acquire(ctx, "refresh", 150*time.Millisecond)
+	if err != nil {
+		t.Fatalf("error acquiring lock: %s", err)
+	}
+	defer lease.Release(ctx)
+
+	m.Del("lock:refresh")
+
+	select {
+	case <-lease.Lost():
+	case <-time.After(time.Second):
+		t.Fatal("expected the lease to be reported lost")
+	}
+}
+
+func Test_RedisLocker_Renewed(t *testing.T) {
+	m := miniredis.RunT(t)
+	ctx := context.Background()
+
+	lease, err := newLocker(t, m).Acquire(ctx, "refresh", 150*time.Millisecond)
+	if err != nil {
+		t.Fatalf("error acquiring lock: %s", err)
+	}
+	defer lease.Release(ctx)
+
+	// pretend most of the ttl has passed, the next renewal resets it
+	m.SetTTL("lock:refresh", time.Millisecond)
+	time.Sleep(100 * time.Millisecond)
+
+	if ttl := m.TTL("lock:refresh"); ttl < 100*time.Millisecond {
+		t.Errorf("ttl=%v | expected the lease to be renewed", ttl)
+	}
+
+	select {
+	case <-lease.Lost():
+		t.Error("renewed lease reported lost")
+	default:
+	}
+}
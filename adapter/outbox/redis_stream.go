@@ -0,0 +1,70 @@
+package outbox
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+const (
+	streamPrefix    = "outbox:"
+	publishedPrefix = "outbox:published:"
+
+	// publishedTTL is how long a published idempotency key is remembered, a
+	// message redelivered within it is not added to the stream twice
+	publishedTTL = 24 * time.Hour
+
+	// maxStreamLen roughly caps every stream, trimming the oldest entries
+	maxStreamLen = 100000
+)
+
+// publishScript adds the message to its stream unless its idempotency key was
+// already published, both atomically
+var publishScript = redis.NewScript(`
+if redis.call("SET", KEYS[2], ARGV[1], "NX", "PX", ARGV[2]) then
+	return redis.call("XADD", KEYS[1], "MAXLEN", "~", ARGV[3], "*",
+		"id", ARGV[4], "idempotency_key", ARGV[1], "topic", ARGV[5], "payload", ARGV[6], "created_at", ARGV[7])
+end
+return false`)
+
+// RedisStreamSink publishes outbox messages to a redis stream per topic,
+// outbox:<topic>
+type RedisStreamSink struct {
+	client *redis.Client
+}
+
+var _ ports.IOutboxSink = (*RedisStreamSink)(nil)
+
+func NewRedisStreamSink(client *redis.Client) *RedisStreamSink {
+	return &RedisStreamSink{
+		client: client,
+	}
+}
+
+// Stream returns the name of the stream messages of topic are published to
+func Stream(topic string) string {
+	return streamPrefix + topic
+}
+
+func (r RedisStreamSink) Publish(ctx context.Context, message model.OutboxMessage) error {
+	err := publishScript.Run(ctx, r.client,
+		[]string{Stream(message.Topic), publishedPrefix + message.IdempotencyKey},
+		message.IdempotencyKey,
+		publishedTTL.Milliseconds(),
+		maxStreamLen,
+		message.ID.String(),
+		message.Topic,
+		string(message.Payload),
+		message.CreatedAt.UTC().Format(time.RFC3339Nano),
+	).Err()
+
+	// redis.Nil means the message was already published
+	if err == redis.Nil {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,64 @@
+package outbox_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/adapter/outbox"
+	"github.com/iamnator/movie-api/model"
+)
+
+func Test_RedisStreamSink_PublishesOnce(t *testing.T) {
+	m := miniredis.RunT(t)
+	ctx := context.Background()
+
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	defer client.Close()
+
+	sink := outbox.NewRedisStreamSink(client)
+
+	message := model.OutboxMessage{
+		ID:             uuid.New(),
+		IdempotencyKey: "comment.created:1",
+		Topic:          model.TopicCommentCreated,
+		Payload:        `{"movie_id":1}`,
+		CreatedAt:      time.Now(),
+	}
+
+	// the relay may publish a message again if it crashed before marking it
+	for i := 0; i < 2; i++ {
+		if err := sink.Publish(ctx, message); err != nil {
+			t.Fatalf("error publishing: %s", err)
+		}
+	}
+
+	entries, err := m.Stream(outbox.Stream(model.TopicCommentCreated))
+	if err != nil {
+		t.Fatalf("error reading stream: %s", err)
+	}
+	if len(entries) != 1 {
+		t.Fatalf("entries=%d | expected=1", len(entries))
+	}
+
+	fields := make(map[string]string)
+	for i := 0; i+1 < len(entries[0].Values); i += 2 {
+		fields[entries[0].Values[i]] = entries[0].Values[i+1]
+	}
+	if fields["idempotency_key"] != message.IdempotencyKey || fields["payload"] != string(message.Payload) {
+		t.Errorf("fields=%v | expected message %+v", fields, message)
+	}
+
+	// another message of the same topic goes to the same stream
+	message.ID, message.IdempotencyKey = uuid.New(), "comment.created:2"
+	if err := sink.Publish(ctx, message); err != nil {
+		t.Fatalf("error publishing: %s", err)
+	}
+	if entries, _ := m.Stream(outbox.Stream(model.TopicCommentCreated)); len(entries) != 2 {
+		t.Errorf("entries=%d | expected=2", len(entries))
+	}
+}
@@ -0,0 +1,45 @@
+package outbox
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"os"
+	"sync"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+// WriterSink writes outbox messages to w as JSON lines, for local runs and
+// tests
+type WriterSink struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+var _ ports.IOutboxSink = (*WriterSink)(nil)
+
+// NewWriterSink returns a sink writing to w, os.Stdout if nil
+func NewWriterSink(w io.Writer) *WriterSink {
+	if w == nil {
+		w = os.Stdout
+	}
+
+	return &WriterSink{
+		w: w,
+	}
+}
+
+func (s *WriterSink) Publish(ctx context.Context, message model.OutboxMessage) error {
+	b, err := json.Marshal(message)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	_, err = s.w.Write(append(b, '\n'))
+	return err
+}
@@ -0,0 +1,63 @@
+package presence
+
+import (
+	"context"
+	"strconv"
+	"time"
+
+	"github.com/go-redis/redis/v8"
+
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+const keyPrefix = "presence:"
+
+// RedisPresence keeps the viewers of a movie in a sorted set scored by when
+// they expire, so viewers of a crashed instance drop out on their own.
+type RedisPresence struct {
+	client *redis.Client
+}
+
+var _ ports.IPresence = (*RedisPresence)(nil)
+
+func NewRedisPresence(client *redis.Client) *RedisPresence {
+	return &RedisPresence{
+		client: client,
+	}
+}
+
+func key(movieID int) string {
+	return keyPrefix + strconv.Itoa(movieID)
+}
+
+// joinScript adds the viewer and makes the set outlive it, but never shortens
+// the life of the set, other viewers may have joined for longer
+var joinScript = redis.NewScript(`
+redis.call("ZADD", KEYS[1], ARGV[1], ARGV[2])
+if redis.call("PTTL", KEYS[1]) < tonumber(ARGV[3]) then
+	redis.call("PEXPIRE", KEYS[1], ARGV[3])
+end
+return 1`)
+
+func (r RedisPresence) Join(ctx context.Context, movieID int, session string, ttl time.Duration) error {
+	expiresAt := time.Now().Add(ttl)
+
+	return joinScript.Run(ctx, r.client, []string{key(movieID)}, expiresAt.UnixMilli(), session, ttl.Milliseconds()).Err()
+}
+
+func (r RedisPresence) Leave(ctx context.Context, movieID int, session string) error {
+	return r.client.ZRem(ctx, key(movieID), session).Err()
+}
+
+func (r RedisPresence) Count(ctx context.Context, movieID int) (int64, error) {
+	now := strconv.FormatInt(time.Now().UnixMilli(), 10)
+
+	pipe := r.client.TxPipeline()
+	pipe.ZRemRangeByScore(ctx, key(movieID), "-inf", "("+now)
+	count := pipe.ZCard(ctx, key(movieID))
+	if _, err := pipe.Exec(ctx); err != nil {
+		return 0, err
+	}
+
+	return count.Val(), nil
+}
@@ -0,0 +1,60 @@
+package presence_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+
+	"github.com/iamnator/movie-api/adapter/presence"
+)
+
+func Test_RedisPresence(t *testing.T) {
+	m := miniredis.RunT(t)
+	ctx := context.Background()
+
+	// two instances sharing one redis
+	clientA := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	defer clientA.Close()
+	clientB := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	defer clientB.Close()
+
+	a, b := presence.NewRedisPresence(clientA), presence.NewRedisPresence(clientB)
+
+	for _, join := range []struct {
+		p       *presence.RedisPresence
+		movieID int
+		session string
+		ttl     time.Duration
+	}{
+		{a, 1, "alice", time.Minute},
+		{b, 1, "bob", time.Minute},
+		{b, 1, "bob", time.Minute}, // refreshing does not count twice
+		{a, 2, "carol", time.Minute},
+	} {
+		if err := join.p.Join(ctx, join.movieID, join.session, join.ttl); err != nil {
+			t.Fatalf("error joining: %s", err)
+		}
+	}
+
+	// a viewer whose instance stopped refreshing it
+	if _, err := m.ZAdd("presence:1", float64(time.Now().Add(-time.Second).UnixMilli()), "gone"); err != nil {
+		t.Fatalf("error adding stale viewer: %s", err)
+	}
+
+	if count, err := a.Count(ctx, 1); err != nil || count != 2 {
+		t.Errorf("count=%d err=%v | expected=2", count, err)
+	}
+
+	if err := a.Leave(ctx, 1, "alice"); err != nil {
+		t.Fatalf("error leaving: %s", err)
+	}
+	if count, err := b.Count(ctx, 1); err != nil || count != 1 {
+		t.Errorf("count=%d err=%v | expected=1", count, err)
+	}
+	if count, err := b.Count(ctx, 2); err != nil || count != 1 {
+		t.Errorf("count=%d err=%v | expected=1", count, err)
+	}
+}
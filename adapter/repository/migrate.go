@@ -0,0 +1,186 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	"github.com/golang-migrate/migrate/v4/source"
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"github.com/iamnator/movie-api/model"
+)
+
+// Migration is a schema change of the migrations source
+type Migration struct {
+	Version int64  `json:"version"`
+	Name    string `json:"name"`
+	Applied bool   `json:"applied"`
+}
+
+// Migrator applies the migrations of a directory with golang-migrate, which
+// records the version in schema_migrations and holds an advisory lock while
+// migrating, so two instances migrating at once apply every migration once.
+type Migrator struct {
+	m          *migrate.Migrate
+	migrations []Migration // oldest first
+}
+
+func NewMigrator(url string, migrations fs.FS) (*Migrator, error) {
+	src, err := iofs.New(migrations, ".")
+	if err != nil {
+		return nil, err
+	}
+
+	listed, err := listMigrations(src)
+	if err != nil {
+		return nil, err
+	}
+
+	m, err := migrate.NewWithSourceInstance("iofs", src, url)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Migrator{
+		m:          m,
+		migrations: listed,
+	}, nil
+}
+
+// listMigrations returns every migration of src, ordered by version
+func listMigrations(src source.Driver) ([]Migration, error) {
+	var migrations []Migration
+
+	for version, err := src.First(); ; version, err = src.Next(version) {
+		if errors.Is(err, os.ErrNotExist) {
+			return migrations, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		up, name, err := src.ReadUp(version)
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %d: %w", version, err)
+		}
+		_ = up.Close()
+
+		migrations = append(migrations, Migration{Version: int64(version), Name: name})
+	}
+}
+
+// Close closes the connection to the database
+func (m Migrator) Close() error {
+	srcErr, dbErr := m.m.Close()
+	if srcErr != nil {
+		return srcErr
+	}
+	return dbErr
+}
+
+// Status returns the schema version and every known migration, marking those
+// applied
+func (m Migrator) Status(ctx context.Context) (model.SchemaVersion, []Migration, error) {
+	version, err := m.version()
+	if err != nil {
+		return version, nil, err
+	}
+
+	migrations := make([]Migration, len(m.migrations))
+	for i, migration := range m.migrations {
+		migration.Applied = migration.Version <= version.Current
+		migrations[i] = migration
+	}
+
+	return version, migrations, nil
+}
+
+// Up applies every pending migration and returns those it applied
+func (m Migrator) Up(ctx context.Context) ([]Migration, error) {
+	return m.run(ctx, m.m.Up)
+}
+
+// Down reverts the last steps applied migrations and returns those it
+// reverted, newest first
+func (m Migrator) Down(ctx context.Context, steps int) ([]Migration, error) {
+	return m.run(ctx, func() error {
+		return m.m.Steps(-steps)
+	})
+}
+
+// run runs migrate, stopping it between two migrations once ctx is done, and
+// returns the migrations it applied or reverted
+func (m Migrator) run(ctx context.Context, fn func() error) ([]Migration, error) {
+	before, err := m.version()
+	if err != nil {
+		return nil, err
+	}
+
+	done := make(chan struct{})
+	defer close(done)
+	go func() {
+		select {
+		case <-ctx.Done():
+			m.m.GracefulStop <- true
+		case <-done:
+		}
+	}()
+
+	err = fn()
+	if errors.Is(err, migrate.ErrNoChange) {
+		err = nil
+	}
+
+	after, versionErr := m.version()
+	if versionErr != nil {
+		return nil, versionErr
+	}
+
+	return m.changed(before.Current, after.Current), err
+}
+
+// changed returns the migrations between the versions from and to, in the
+// order they were applied or reverted
+func (m Migrator) changed(from, to int64) []Migration {
+	var changed []Migration
+	for _, migration := range m.migrations {
+		if (migration.Version > from && migration.Version <= to) || (migration.Version > to && migration.Version <= from) {
+			changed = append(changed, migration)
+		}
+	}
+
+	if to < from {
+		for i, j := 0, len(changed)-1; i < j; i, j = i+1, j-1 {
+			changed[i], changed[j] = changed[j], changed[i]
+		}
+	}
+
+	return changed
+}
+
+func (m Migrator) version() (model.SchemaVersion, error) {
+	version := model.SchemaVersion{Required: m.latest()}
+
+	current, dirty, err := m.m.Version()
+	if errors.Is(err, migrate.ErrNilVersion) {
+		return version, nil
+	}
+	if err != nil {
+		return version, err
+	}
+
+	version.Current, version.Dirty = int64(current), dirty
+	return version, nil
+}
+
+func (m Migrator) latest() int64 {
+	if len(m.migrations) == 0 {
+		return 0
+	}
+	return m.migrations[len(m.migrations)-1].Version
+}
@@ -0,0 +1,58 @@
+package repository
+
+import (
+	"testing"
+
+	"github.com/golang-migrate/migrate/v4/source/iofs"
+
+	"github.com/iamnator/movie-api/database"
+)
+
+func Test_Migrator_changed(t *testing.T) {
+	m := Migrator{migrations: []Migration{{Version: 1}, {Version: 2}, {Version: 5}}}
+
+	for _, tt := range []struct {
+		from, to int64
+		expected []int64
+	}{
+		{0, 5, []int64{1, 2, 5}},
+		{1, 2, []int64{2}},
+		{5, 1, []int64{5, 2}}, // reverted newest first
+		{2, 2, nil},
+	} {
+		var got []int64
+		for _, migration := range m.changed(tt.from, tt.to) {
+			got = append(got, migration.Version)
+		}
+
+		if len(got) != len(tt.expected) {
+			t.Errorf("changed(%d, %d)=%v | expected=%v", tt.from, tt.to, got, tt.expected)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.expected[i] {
+				t.Errorf("changed(%d, %d)=%v | expected=%v", tt.from, tt.to, got, tt.expected)
+				break
+			}
+		}
+	}
+}
+
+func Test_RequiredSchemaVersion_IsLatestMigration(t *testing.T) {
+	src, err := iofs.New(database.Migrations(), ".")
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	migrations, err := listMigrations(src)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(migrations) == 0 || migrations[0].Name != "create_comment_table" {
+		t.Fatalf("migrations=%+v | expected=000001_create_comment_table first", migrations)
+	}
+	if latest := migrations[len(migrations)-1].Version; latest != RequiredSchemaVersion {
+		t.Errorf("latest migration=%d | expected=%d, bump RequiredSchemaVersion", latest, RequiredSchemaVersion)
+	}
+}
@@ -0,0 +1,75 @@
+package repository
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+type PgxOutboxRepository struct {
+	db *gorm.DB
+}
+
+var _ ports.IOutboxRepository = (*PgxOutboxRepository)(nil)
+
+func NewPgxOutboxRepository(db *gorm.DB) *PgxOutboxRepository {
+	return &PgxOutboxRepository{
+		db: db,
+	}
+}
+
+func (p PgxOutboxRepository) ClaimOutbox(ctx context.Context, now time.Time, limit int, lease time.Duration) (messages []model.OutboxMessage, err error) {
+
+	// SKIP LOCKED lets every relay claim a different batch
+	if err := p.db.WithContext(ctx).Raw(`
+UPDATE outbox SET locked_until = ?
+WHERE id IN (
+    SELECT id FROM outbox
+    WHERE published_at IS NULL AND (locked_until IS NULL OR locked_until <= ?)
+    ORDER BY created_at
+    LIMIT ?
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *`, now.Add(lease), now, limit).
+		Scan(&messages).Error; err != nil {
+		return nil, err
+	}
+
+	// RETURNING does not keep the order of the sub query
+	sort.SliceStable(messages, func(i, j int) bool {
+		return messages[i].CreatedAt.Before(messages[j].CreatedAt)
+	})
+
+	return messages, nil
+}
+
+func (p PgxOutboxRepository) MarkOutboxPublished(ctx context.Context, id uuid.UUID, publishedAt time.Time) error {
+	return p.db.WithContext(ctx).Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"published_at": publishedAt,
+			"locked_until": nil,
+			"attempts":     gorm.Expr("attempts + 1"),
+		}).Error
+}
+
+func (p PgxOutboxRepository) DeletePublishedOutbox(ctx context.Context, before time.Time) (int64, error) {
+	res := p.db.WithContext(ctx).Where("published_at < ?", before).Delete(&model.OutboxMessage{})
+	return res.RowsAffected, res.Error
+}
+
+func (p PgxOutboxRepository) MarkOutboxFailed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time) error {
+	return p.db.WithContext(ctx).Model(&model.OutboxMessage{}).
+		Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"last_error":   reason,
+			"locked_until": retryAt,
+			"attempts":     gorm.Expr("attempts + 1"),
+		}).Error
+}
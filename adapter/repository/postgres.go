@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"context"
+	"encoding/json"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/iamnator/movie-api/service/ports"
 	"gorm.io/driver/postgres"
@@ -16,7 +20,15 @@ type PgxCommentRepository struct {
 
 var _ ports.ICommentRepository = (*PgxCommentRepository)(nil)
 
-func NewPgxCommentRepository(url string) (*PgxCommentRepository, error) {
+func NewPgxCommentRepository(db *gorm.DB) *PgxCommentRepository {
+	return &PgxCommentRepository{
+		db: db,
+	}
+}
+
+// Open connects to postgres and pings it. The repositories share the pool of
+// the db returned, closing it is left to the caller.
+func Open(url string) (*gorm.DB, error) {
 
 	db, err := gorm.Open(postgres.Open(url), &gorm.Config{})
 	if err != nil {
@@ -33,38 +45,70 @@ func NewPgxCommentRepository(url string) (*PgxCommentRepository, error) {
 		return nil, err
 	}
 
-	return &PgxCommentRepository{
-		db: db,
-	}, nil
+	return db, nil
 }
 
-func (p PgxCommentRepository) AddComment(comment model.Comment) error {
+// AddComment saves the comment and records its comment.created event in the
+// outbox within the same transaction, so the event is never lost nor
+// published for a comment that was not saved.
+func (p PgxCommentRepository) AddComment(ctx context.Context, comment *model.Comment) error {
+
+	return p.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+
+		//on constraint violation, return updated comment
+		if err := tx.Model(&model.Comment{}).
+			Clauses(
+				clause.OnConflict{
+					Columns:   []clause.Column{{Name: "ipv4_addr"}, {Name: "swapi_movie_id"}, {Name: "message"}},
+					DoUpdates: clause.Assignments(map[string]interface{}{"updated_at": gorm.Expr("NOW()")}),
+				},
+				clause.Returning{}).
+			Create(comment).Error; err != nil {
+			return err
+		}
+
+		message, err := commentCreatedMessage(*comment)
+		if err != nil {
+			return err
+		}
 
-	//on constraint violation, return updated comment
+		// a repeated comment returns the original row, so its event is recorded once
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "idempotency_key"}},
+			DoNothing: true,
+		}).Create(&message).Error
+	})
+}
+
+func commentCreatedMessage(comment model.Comment) (model.OutboxMessage, error) {
+	payload, err := json.Marshal(comment.EventData())
+	if err != nil {
+		return model.OutboxMessage{}, err
+	}
 
-	return p.db.Model(&model.Comment{}).
-		Clauses(
-			clause.OnConflict{
-				Columns:   []clause.Column{{Name: "ipv4_addr"}, {Name: "swapi_movie_id"}, {Name: "message"}},
-				DoUpdates: clause.Assignments(map[string]interface{}{"updated_at": gorm.Expr("NOW()")}),
-			}).
-		Create(&comment).Error
+	return model.OutboxMessage{
+		ID:             uuid.New(),
+		IdempotencyKey: model.TopicCommentCreated + ":" + comment.ID.String(),
+		Topic:          model.TopicCommentCreated,
+		Payload:        model.RawJSON(payload),
+		CreatedAt:      comment.CreatedAt,
+	}, nil
 }
 
-func (p PgxCommentRepository) GetComment(commentID uuid.UUID) (comment *model.Comment, err error) {
-	return comment, p.db.Model(&model.Comment{}).Where("id = ?", commentID.String()).First(&comment).Error
+func (p PgxCommentRepository) GetComment(ctx context.Context, commentID uuid.UUID) (comment *model.Comment, err error) {
+	return comment, p.db.WithContext(ctx).Model(&model.Comment{}).Where("id = ?", commentID.String()).First(&comment).Error
 }
 
-func (p PgxCommentRepository) GetCommentsByID(commentID ...uuid.UUID) (comments []model.Comment, err error) {
-	return comments, p.db.Model(&model.Comment{}).Where("id IN ?", commentID).Order("created_at DESC").Find(&comments).Error
+func (p PgxCommentRepository) GetCommentsByID(ctx context.Context, commentID ...uuid.UUID) (comments []model.Comment, err error) {
+	return comments, p.db.WithContext(ctx).Model(&model.Comment{}).Where("id IN ?", commentID).Order("created_at DESC").Find(&comments).Error
 }
 
-func (p PgxCommentRepository) GetCommentsByIPAddr(ipAddr string, page, pageSize int) (comments []model.Comment, count int64, err error) {
+func (p PgxCommentRepository) GetCommentsByIPAddr(ctx context.Context, ipAddr string, page, pageSize int) (comments []model.Comment, count int64, err error) {
 	if page <= 0 {
 		page = 1
 	}
 
-	return comments, count, p.db.Model(&model.Comment{}).
+	return comments, count, p.db.WithContext(ctx).Model(&model.Comment{}).
 		Where("ip_addr = ?", ipAddr).
 		Count(&count).
 		Order("created_at DESC").
@@ -72,11 +116,11 @@ func (p PgxCommentRepository) GetCommentsByIPAddr(ipAddr string, page, pageSize
 		Find(&comments).Error
 }
 
-func (p PgxCommentRepository) GetCommentsByMovieID(movieID int, page, pageSize int) (comments []model.Comment, count int64, err error) {
+func (p PgxCommentRepository) GetCommentsByMovieID(ctx context.Context, movieID int, page, pageSize int) (comments []model.Comment, count int64, err error) {
 	if page <= 0 {
 		page = 1
 	}
-	return comments, count, p.db.Model(&model.Comment{}).
+	return comments, count, p.db.WithContext(ctx).Model(&model.Comment{}).
 		Where("swapi_movie_id = ?", movieID).
 		Count(&count).
 		Offset((page - 1) * pageSize).
@@ -85,6 +129,132 @@ func (p PgxCommentRepository) GetCommentsByMovieID(movieID int, page, pageSize i
 		Find(&comments).Error
 }
 
-func (p PgxCommentRepository) GetCommentCountByMovieID(movieID int) (count int64, err error) {
-	return count, p.db.Model(&model.Comment{}).Where("swapi_movie_id = ?", movieID).Count(&count).Error
+func (p PgxCommentRepository) GetCommentCountByMovieID(ctx context.Context, movieID int) (count int64, err error) {
+	return count, p.db.WithContext(ctx).Model(&model.Comment{}).Where("swapi_movie_id = ?", movieID).Count(&count).Error
+}
+
+func (p PgxCommentRepository) GetCommentsAfter(ctx context.Context, movieID int, afterID uuid.UUID, limit int) (comments []model.Comment, err error) {
+	var after model.Comment
+	if err := p.db.WithContext(ctx).Model(&model.Comment{}).Where("id = ?", afterID).First(&after).Error; err != nil {
+		return nil, notFound(err)
+	}
+
+	// comments created in the same instant are ordered by id
+	return comments, p.db.WithContext(ctx).Model(&model.Comment{}).
+		Where("swapi_movie_id = ?", movieID).
+		Where("(created_at, id) > (?, ?)", after.CreatedAt, after.ID).
+		Order("created_at, id").
+		Limit(limit).
+		Find(&comments).Error
+}
+
+// EachComment calls fn with every comment, or those of movieID when it is not
+// 0, oldest first. Comments are read in batches so the table is never held in
+// memory.
+func (p PgxCommentRepository) EachComment(ctx context.Context, movieID int, fn func(model.Comment) error) error {
+	var last *model.Comment
+	for {
+		query := p.db.WithContext(ctx).Model(&model.Comment{})
+		if movieID > 0 {
+			query = query.Where("swapi_movie_id = ?", movieID)
+		}
+		// resume after the last comment of the previous batch
+		if last != nil {
+			query = query.Where("(created_at, id) > (?, ?)", last.CreatedAt, last.ID)
+		}
+
+		var batch []model.Comment
+		if err := query.Order("created_at, id").Limit(exportBatchSize).Find(&batch).Error; err != nil {
+			return err
+		}
+
+		for _, comment := range batch {
+			if err := fn(comment); err != nil {
+				return err
+			}
+		}
+
+		if len(batch) < exportBatchSize {
+			return nil
+		}
+		last = &batch[len(batch)-1]
+	}
+}
+
+// exportBatchSize is how many comments EachComment reads at once
+const exportBatchSize = 500
+
+func (p PgxCommentRepository) GetCommentCounts(ctx context.Context) (map[int]int64, error) {
+	var rows []struct {
+		SwapiMovieID int
+		Count        int64
+	}
+
+	if err := p.db.WithContext(ctx).Model(&model.Comment{}).
+		Select("swapi_movie_id, count(*) AS count").
+		Group("swapi_movie_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	counts := make(map[int]int64, len(rows))
+	for _, row := range rows {
+		counts[row.SwapiMovieID] = row.Count
+	}
+
+	return counts, nil
+}
+
+// anonymisedIPPrefix marks ip addresses that were already anonymised
+const anonymisedIPPrefix = "anon:"
+
+func (p PgxCommentRepository) AnonymiseIPAddrs(ctx context.Context, createdBefore time.Time) (int64, error) {
+
+	// hashing with the comment id keeps rows unique under the
+	// (swapi_movie_id, message, ipv4_addr) index; the result fits varchar(20)
+	res := p.db.WithContext(ctx).Model(&model.Comment{}).
+		Where("created_at < ?", createdBefore).
+		Where("ipv4_addr NOT LIKE ?", anonymisedIPPrefix+"%").
+		Update("ipv4_addr", gorm.Expr("? || substr(md5(ipv4_addr || id::text), 1, 15)", anonymisedIPPrefix))
+
+	return res.RowsAffected, res.Error
+}
+
+func (p PgxCommentRepository) Ping(ctx context.Context) error {
+	sqlDB, err := p.db.DB()
+	if err != nil {
+		return err
+	}
+	return sqlDB.PingContext(ctx)
+}
+
+// RequiredSchemaVersion is the latest migration in database/migrations the
+// repositories rely on
+const RequiredSchemaVersion = 5
+
+// SchemaVersion reads the version golang-migrate recorded in schema_migrations
+func (p PgxCommentRepository) SchemaVersion(ctx context.Context) (model.SchemaVersion, error) {
+	version, err := readSchemaVersion(p.db.WithContext(ctx))
+	version.Required = RequiredSchemaVersion
+	return version, err
+}
+
+func readSchemaVersion(db *gorm.DB) (model.SchemaVersion, error) {
+	var version model.SchemaVersion
+
+	var rows []struct {
+		Version int64
+		Dirty   bool
+	}
+	if err := db.Raw("SELECT version, dirty FROM schema_migrations LIMIT 1").Scan(&rows).Error; err != nil {
+		return version, err
+	}
+
+	// no row means no migration ran yet
+	if len(rows) > 0 {
+		version.Current = rows[0].Version
+		version.Dirty = rows[0].Dirty
+	}
+
+	return version, nil
 }
@@ -0,0 +1,114 @@
+package repository
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+type PgxWebhookRepository struct {
+	db *gorm.DB
+}
+
+var _ ports.IWebhookRepository = (*PgxWebhookRepository)(nil)
+
+func NewPgxWebhookRepository(db *gorm.DB) *PgxWebhookRepository {
+	return &PgxWebhookRepository{
+		db: db,
+	}
+}
+
+func notFound(err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ports.ErrNotFound
+	}
+	return err
+}
+
+func (p PgxWebhookRepository) AddSubscription(ctx context.Context, subscription *model.WebhookSubscription) error {
+	return p.db.WithContext(ctx).Create(subscription).Error
+}
+
+func (p PgxWebhookRepository) GetSubscription(ctx context.Context, id uuid.UUID) (subscription *model.WebhookSubscription, err error) {
+	return subscription, notFound(p.db.WithContext(ctx).Model(&model.WebhookSubscription{}).Where("id = ?", id).First(&subscription).Error)
+}
+
+func (p PgxWebhookRepository) GetSubscriptions(ctx context.Context) (subscriptions []model.WebhookSubscription, err error) {
+	return subscriptions, p.db.WithContext(ctx).Model(&model.WebhookSubscription{}).Order("created_at DESC").Find(&subscriptions).Error
+}
+
+func (p PgxWebhookRepository) DeleteSubscription(ctx context.Context, id uuid.UUID) error {
+	res := p.db.WithContext(ctx).Where("id = ?", id).Delete(&model.WebhookSubscription{})
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ports.ErrNotFound
+	}
+	return nil
+}
+
+func (p PgxWebhookRepository) AddDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error {
+	if len(deliveries) == 0 {
+		return nil
+	}
+	return p.db.WithContext(ctx).Create(&deliveries).Error
+}
+
+func (p PgxWebhookRepository) GetDelivery(ctx context.Context, id uuid.UUID) (delivery *model.WebhookDelivery, err error) {
+	return delivery, notFound(p.db.WithContext(ctx).Model(&model.WebhookDelivery{}).Where("id = ?", id).First(&delivery).Error)
+}
+
+func (p PgxWebhookRepository) GetDeliveries(ctx context.Context, state model.WebhookDeliveryState, page, pageSize int) (deliveries []model.WebhookDelivery, count int64, err error) {
+	if page <= 0 {
+		page = 1
+	}
+
+	query := p.db.WithContext(ctx).Model(&model.WebhookDelivery{})
+	if state != "" {
+		query = query.Where("state = ?", state)
+	}
+
+	return deliveries, count, query.
+		Count(&count).
+		Offset((page - 1) * pageSize).
+		Limit(pageSize).
+		Order("created_at DESC").
+		Find(&deliveries).Error
+}
+
+func (p PgxWebhookRepository) ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) (deliveries []model.WebhookDelivery, err error) {
+
+	// SKIP LOCKED lets every instance claim a different batch
+	return deliveries, p.db.WithContext(ctx).Raw(`
+UPDATE webhook_delivery SET next_attempt_at = ?
+WHERE id IN (
+    SELECT id FROM webhook_delivery
+    WHERE state = ? AND next_attempt_at <= ?
+    ORDER BY next_attempt_at
+    LIMIT ?
+    FOR UPDATE SKIP LOCKED
+)
+RETURNING *`, now.Add(lease), model.WebhookDeliveryPending, now, limit).
+		Scan(&deliveries).Error
+}
+
+func (p PgxWebhookRepository) UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error {
+	res := p.db.WithContext(ctx).Model(&model.WebhookDelivery{}).
+		Where("id = ?", delivery.ID).
+		Select("state", "attempts", "last_error", "next_attempt_at", "delivered_at").
+		Updates(&delivery)
+	if res.Error != nil {
+		return res.Error
+	}
+	if res.RowsAffected == 0 {
+		return ports.ErrNotFound
+	}
+	return nil
+}
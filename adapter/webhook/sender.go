@@ -0,0 +1,139 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/netguard"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+// headers sent with every delivery
+const (
+	HeaderSignature = "X-Webhook-Signature" // sha256=<hex HMAC-SHA256 of "<timestamp>.<body>" keyed with the secret>
+	HeaderTimestamp = "X-Webhook-Timestamp" // unix seconds the delivery was signed at
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderEventID   = "X-Webhook-Event-ID"
+	HeaderDelivery  = "X-Webhook-Delivery"
+)
+
+const (
+	// maxErrorBody is how much of a failed response is kept in the error
+	maxErrorBody = 512
+
+	// DefaultTimeout bounds a delivery when no client is given
+	DefaultTimeout = 10 * time.Second
+
+	// SignatureTolerance is how far from their clock receivers should accept
+	// the timestamp of a delivery. The timestamp is signed with the body, so
+	// rejecting older ones stops a captured delivery from being replayed
+	// later; every attempt is signed again when it is sent.
+	SignatureTolerance = 5 * time.Minute
+)
+
+type HTTPSender struct {
+	client *http.Client
+}
+
+var _ ports.IWebhookSender = (*HTTPSender)(nil)
+
+// NewHTTPSender sends deliveries with client, or when nil with NewClient()
+func NewHTTPSender(client *http.Client) *HTTPSender {
+	if client == nil {
+		client = NewClient()
+	}
+
+	return &HTTPSender{
+		client: client,
+	}
+}
+
+// NewClient returns the client deliveries are sent with: requests time out
+// after DefaultTimeout, bypass any proxy and only dial public addresses, also
+// when redirected, so subscribers cannot reach the internal network
+func NewClient() *http.Client {
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: netguard.Control,
+	}
+
+	return &http.Client{
+		Timeout: DefaultTimeout,
+		Transport: &http.Transport{
+			DialContext:           dialer.DialContext,
+			TLSHandshakeTimeout:   5 * time.Second,
+			ResponseHeaderTimeout: DefaultTimeout,
+			MaxIdleConnsPerHost:   4,
+			IdleConnTimeout:       90 * time.Second,
+		},
+	}
+}
+
+// Sign returns the value of the signature header of body sent at timestamp,
+// in unix seconds
+func Sign(secret string, timestamp int64, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp, 10) + "."))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify reports whether signature is the signature of body sent at
+// timestamp, the value of the timestamp header, and whether that timestamp is
+// within SignatureTolerance of now, for receivers
+func Verify(secret string, body []byte, timestamp, signature string, now time.Time) bool {
+	ts, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	if age := now.Sub(time.Unix(ts, 0)); age > SignatureTolerance || age < -SignatureTolerance {
+		return false
+	}
+
+	return hmac.Equal([]byte(Sign(secret, ts, body)), []byte(signature))
+}
+
+func (h HTTPSender) Send(ctx context.Context, url, secret string, delivery model.WebhookDelivery) error {
+	body := []byte(delivery.Payload)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now().Unix()
+
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderSignature, Sign(secret, timestamp, body))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderEvent, string(delivery.EventType))
+	req.Header.Set(HeaderEventID, delivery.EventID.String())
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+
+	resp, err := h.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("webhook responded with %d: %s", resp.StatusCode, bytes.TrimSpace(msg))
+	}
+
+	// drain so the connection can be reused
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package webhook
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/netguard"
+)
+
+func TestHTTPSender_Send(t *testing.T) {
+	delivery := model.WebhookDelivery{
+		ID:        uuid.New(),
+		EventID:   uuid.New(),
+		EventType: model.EventCommentCreated,
+		Payload:   `{"type":"comment.created"}`,
+	}
+
+	tests := []struct {
+		name    string
+		status  int
+		wantErr bool
+	}{
+		{name: "delivered", status: http.StatusNoContent},
+		{name: "rejected", status: http.StatusBadRequest, wantErr: true},
+		{name: "server error", status: http.StatusBadGateway, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				body, _ := io.ReadAll(r.Body)
+
+				if !Verify("secret", body, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), time.Now()) {
+					t.Errorf("signature=%s timestamp=%s | body=%s", r.Header.Get(HeaderSignature), r.Header.Get(HeaderTimestamp), body)
+				}
+				if got := r.Header.Get(HeaderEvent); got != string(delivery.EventType) {
+					t.Errorf("event=%s | expected=%s", got, delivery.EventType)
+				}
+				if got := r.Header.Get(HeaderDelivery); got != delivery.ID.String() {
+					t.Errorf("delivery=%s | expected=%s", got, delivery.ID)
+				}
+
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			err := NewHTTPSender(server.Client()).Send(context.Background(), server.URL, "secret", delivery)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("err=%v | wantErr=%v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestHTTPSender_Send_RefusesPrivateAddresses(t *testing.T) {
+	var reached bool
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		reached = true
+	}))
+	defer server.Close()
+
+	err := NewHTTPSender(nil).Send(context.Background(), server.URL, "secret", model.WebhookDelivery{ID: uuid.New()})
+	if !errors.Is(err, netguard.ErrForbiddenTarget) {
+		t.Errorf("err=%v | expected=%v", err, netguard.ErrForbiddenTarget)
+	}
+	if reached {
+		t.Errorf("loopback server was reached")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	body := []byte(`{"id":1}`)
+	now := time.Unix(1_700_000_000, 0)
+	signature := Sign("secret", now.Unix(), body)
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+
+	if !Verify("secret", body, timestamp, signature, now.Add(SignatureTolerance)) {
+		t.Errorf("signature does not verify")
+	}
+	if Verify("other", body, timestamp, signature, now) {
+		t.Errorf("signature verifies with the wrong secret")
+	}
+	if Verify("secret", []byte(`{"id":2}`), timestamp, signature, now) {
+		t.Errorf("signature verifies a tampered body")
+	}
+	if Verify("secret", body, strconv.FormatInt(now.Unix()+60, 10), signature, now) {
+		t.Errorf("signature verifies with a moved timestamp")
+	}
+	if Verify("secret", body, timestamp, signature, now.Add(SignatureTolerance+time.Second)) {
+		t.Errorf("signature verifies a delivery replayed after the tolerance")
+	}
+}
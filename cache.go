@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"sort"
+	"text/tabwriter"
+	"time"
+
+	"github.com/iamnator/movie-api/adapter/cache"
+	"github.com/iamnator/movie-api/config"
+)
+
+// cacheCommand inspects the cache without changing it, or recreates its
+// indexes after a schema change
+func cacheCommand(ctx context.Context, cfg config.Config, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("cache", flag.ContinueOnError)
+	name, err := subcommand(fs, args, "inspect", "reindex")
+	if err != nil {
+		return err
+	}
+
+	redisClient, err := openRedis(ctx, cfg.Redis.URL)
+	if err != nil {
+		return err
+	}
+	defer closeRedis(redisClient)
+
+	if name == "reindex" {
+		// the documents are dropped with the indexes, the next refresh caches them again
+		redisCache, err := cache.NewRedisCache(redisClient)
+		if err != nil {
+			return err
+		}
+		defer redisCache.Close()
+
+		fmt.Fprintln(stdout, "indexes recreated, run refresh to cache the movies again")
+		return nil
+	}
+
+	redisCache, err := cache.OpenRedisCache(redisClient)
+	if err != nil {
+		return err
+	}
+	defer redisCache.Close()
+
+	refreshedAt, err := redisCache.GetRefreshedAt(ctx)
+	if err != nil {
+		return err
+	}
+	if refreshedAt.IsZero() {
+		fmt.Fprint(stdout, "never refreshed\n\n")
+	} else {
+		fmt.Fprintf(stdout, "refreshed at %s (%s ago)\n\n", refreshedAt.Format(time.RFC3339), time.Since(refreshedAt).Round(time.Second))
+	}
+
+	for _, index := range []string{cache.MovieIndexName, cache.CharacterIndexName} {
+		info, err := redisCache.IndexInfo(ctx, index)
+		if err != nil {
+			return fmt.Errorf("error reading index %s: %w", index, err)
+		}
+
+		fmt.Fprintf(stdout, "%s: %s documents\n", index, info["num_docs"])
+
+		names := make([]string, 0, len(info))
+		for name := range info {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+		for _, name := range names {
+			fmt.Fprintf(tw, "  %s\t%s\n", name, info[name])
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(stdout)
+	}
+
+	return nil
+}
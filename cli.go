@@ -0,0 +1,160 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"syscall"
+	"text/tabwriter"
+
+	"github.com/iamnator/movie-api/config"
+	"github.com/iamnator/movie-api/pkg/tracing"
+)
+
+// command is a subcommand of the binary
+type command struct {
+	name    string
+	args    string // synopsis of the arguments
+	summary string
+
+	// oneOff commands are given a context cancelled on interrupt; serve keeps
+	// the default signal handling
+	oneOff bool
+
+	requires []string // settings the command cannot run without
+
+	run func(ctx context.Context, cfg config.Config, args []string, stdout io.Writer) error
+}
+
+// defaultCommand runs when the binary is started without arguments, as it is
+// in the Dockerfile and on heroku
+const defaultCommand = "serve"
+
+func commands() []command {
+	return []command{
+		{name: "serve", summary: "run the api (default)",
+			requires: []string{config.ServerPort, config.RedisURL, config.PostgresURL}, run: serve},
+		{name: "migrate", args: "up | down [-steps n] | status", summary: "apply, revert or list the database migrations", oneOff: true,
+			requires: []string{config.PostgresURL}, run: migrate},
+		{name: "refresh", args: "[-film id]", summary: "refresh the cache from swapi once, of every film or one, and exit", oneOff: true,
+			requires: []string{config.RedisURL, config.PostgresURL}, run: refresh},
+		{name: "export", args: "movies | comments [-movie id] [-o file]", summary: "dump the cached movies or the comments as json lines", oneOff: true,
+			run: export}, // checks the setting of what it exports
+		{name: "seed", args: "[-file path]", summary: "load movies, characters and comments fixtures into the cache and the database", oneOff: true,
+			requires: []string{config.RedisURL, config.PostgresURL}, run: seed},
+		{name: "cache", args: "inspect | reindex", summary: "print the cache indexes and their document counts, or recreate them emptied", oneOff: true,
+			requires: []string{config.RedisURL}, run: cacheCommand},
+	}
+}
+
+// errUsage is returned for invalid arguments
+var errUsage = errors.New("invalid arguments")
+
+// run dispatches args to their command, with the config read from getenv
+func run(ctx context.Context, args []string, getenv func(string) string, stdout io.Writer) error {
+	name := defaultCommand
+	if len(args) > 0 {
+		name, args = args[0], args[1:]
+	}
+
+	switch name {
+	case "help", "-h", "-help", "--help":
+		usage(stdout)
+		return nil
+	}
+
+	var cmd *command
+	for _, c := range commands() {
+		if c.name == name {
+			c := c
+			cmd = &c
+			break
+		}
+	}
+	if cmd == nil {
+		usage(os.Stderr)
+		return fmt.Errorf("unknown command %q", name)
+	}
+
+	cfg, err := config.Load(getenv)
+	if err != nil {
+		return err
+	}
+	if err := cfg.Require(cmd.requires...); err != nil {
+		return err
+	}
+
+	if cmd.oneOff {
+		var stop context.CancelFunc
+		ctx, stop = signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+		defer stop()
+	}
+
+	shutdownTracing, err := tracing.Init(ctx, tracing.ServiceName, cfg.Tracing.Exporter)
+	if err != nil {
+		return err
+	}
+
+	err = cmd.run(ctx, cfg, args, stdout)
+
+	// flush the spans of the last requests
+	if er := shutdownTracing(context.Background()); er != nil {
+		fmt.Fprintln(os.Stderr, "error flushing traces:", er)
+	}
+
+	return err
+}
+
+func usage(w io.Writer) {
+	fmt.Fprint(w, "Usage: movie-api [command] [arguments]\n\nCommands:\n")
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	for _, c := range commands() {
+		fmt.Fprintf(tw, "  %s %s\t%s\n", c.name, c.args, c.summary)
+	}
+	_ = tw.Flush()
+
+	fmt.Fprintf(w, "\nThe config is read from the file named by %s and the environment, see config/example.yaml.\n", config.FileEnv)
+}
+
+// parseArgs parses the flags of fs wherever they appear in args, so they may
+// follow the positional arguments, and returns the positional arguments
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, errUsage
+		}
+
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// subcommand returns the single positional argument of args, which must be
+// one of the names
+func subcommand(fs *flag.FlagSet, args []string, names ...string) (string, error) {
+	positional, err := parseArgs(fs, args)
+	if err != nil {
+		return "", err
+	}
+
+	if len(positional) == 1 {
+		for _, name := range names {
+			if positional[0] == name {
+				return name, nil
+			}
+		}
+	}
+
+	fmt.Fprintf(fs.Output(), "usage: %s %v\n", fs.Name(), names)
+	return "", errUsage
+}
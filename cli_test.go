@@ -0,0 +1,105 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/iamnator/movie-api/database"
+)
+
+func Test_run_Help(t *testing.T) {
+	var out bytes.Buffer
+	if err := run(context.Background(), []string{"help"}, func(string) string { return "" }, &out); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, c := range commands() {
+		if !strings.Contains(out.String(), c.name+" ") {
+			t.Errorf("usage=%s | expected=command %s listed", out.String(), c.name)
+		}
+	}
+}
+
+func Test_run_UnknownCommand(t *testing.T) {
+	err := run(context.Background(), []string{"deploy"}, func(string) string { return "" }, io.Discard)
+	if err == nil || !strings.Contains(err.Error(), "deploy") {
+		t.Errorf("error=%v | expected=unknown command deploy", err)
+	}
+}
+
+func Test_parseArgs_FlagsAnywhere(t *testing.T) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	movieID := fs.Int("movie", 0, "")
+	out := fs.String("o", "", "")
+
+	positional, err := parseArgs(fs, []string{"-o", "comments.json", "comments", "--movie", "2"})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(positional, []string{"comments"}) {
+		t.Errorf("positional=%v | expected=%v", positional, []string{"comments"})
+	}
+	if *movieID != 2 || *out != "comments.json" {
+		t.Errorf("movie=%d o=%s | expected=2 comments.json", *movieID, *out)
+	}
+}
+
+func Test_subcommand(t *testing.T) {
+	tests := []struct {
+		args    []string
+		want    string
+		wantErr bool
+	}{
+		{args: []string{"down", "-steps", "2"}, want: "down"},
+		{args: []string{"status"}, want: "status"},
+		{args: []string{}, wantErr: true},
+		{args: []string{"sideways"}, wantErr: true},
+		{args: []string{"up", "down"}, wantErr: true},
+		{args: []string{"up", "-force"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+		fs.SetOutput(io.Discard)
+		fs.Int("steps", 1, "")
+
+		got, err := subcommand(fs, tt.args, "up", "down", "status")
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("args=%v: got=%q error=%v | expected=%q error=%v", tt.args, got, err, tt.want, tt.wantErr)
+		}
+		if err != nil && !errors.Is(err, errUsage) {
+			t.Errorf("args=%v: error=%v | expected=%v", tt.args, err, errUsage)
+		}
+	}
+}
+
+func Test_parseFixtures_Bundled(t *testing.T) {
+	f, err := parseFixtures(database.Fixtures())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(f.Movies) == 0 || len(f.Characters) == 0 || len(f.Comments) == 0 {
+		t.Errorf("fixtures=%+v | expected=movies, characters and comments", f)
+	}
+	if byMovie := f.charactersByMovie(); len(byMovie) != len(f.Movies) {
+		t.Errorf("movies with characters=%d | expected=%d", len(byMovie), len(f.Movies))
+	}
+}
+
+func Test_parseFixtures_UnknownMovie(t *testing.T) {
+	_, err := parseFixtures([]byte(`{
+		"movies": [{"id": 1, "title": "A New Hope"}],
+		"comments": [{"movie_id": 7, "message": "which movie?", "ipv4_addr": "10.0.0.1"}]
+	}`))
+	if err == nil || !strings.Contains(err.Error(), "movie 7") {
+		t.Errorf("error=%v | expected=unknown movie 7", err)
+	}
+}
@@ -0,0 +1,266 @@
+// Package config holds the typed configuration of the api. It is read from an
+// optional YAML or JSON file, then overridden by environment variables, on top
+// of the defaults, and validated before anything starts.
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// FileEnv names the environment variable pointing at the config file
+const FileEnv = "CONFIG_FILE"
+
+type (
+	Config struct {
+		Server   Server   `yaml:"server" json:"server"`
+		Redis    Redis    `yaml:"redis" json:"redis"`
+		Postgres Postgres `yaml:"postgres" json:"postgres"`
+		Swapi    Swapi    `yaml:"swapi" json:"swapi"`
+		Refresh  Refresh  `yaml:"refresh" json:"refresh"`
+		Comments Comments `yaml:"comments" json:"comments"`
+		Outbox   Outbox   `yaml:"outbox" json:"outbox"`
+		Tracing  Tracing  `yaml:"tracing" json:"tracing"`
+	}
+
+	Server struct {
+		Port              string   `yaml:"port" json:"port"`                               // PORT
+		Host              string   `yaml:"host" json:"host"`                               // HOST_MACHINE, documented host of the api
+		ReadHeaderTimeout Duration `yaml:"read_header_timeout" json:"read_header_timeout"` // SERVER_READ_HEADER_TIMEOUT
+		DefaultPageSize   int      `yaml:"default_page_size" json:"default_page_size"`     // DEFAULT_PAGE_SIZE, when the request sets none
+		Streams           Streams  `yaml:"streams" json:"streams"`
+		// ADMIN_TOKEN, secret, bearer token of the /admin routes; they are all
+		// forbidden while it is unset
+		AdminToken string `yaml:"admin_token" json:"admin_token"`
+	}
+
+	// Streams bounds the comment streams and live websockets
+	Streams struct {
+		MaxPerClient int      `yaml:"max_per_client" json:"max_per_client"` // STREAMS_MAX_PER_CLIENT, per client ip
+		MaxTotal     int      `yaml:"max_total" json:"max_total"`           // STREAMS_MAX_TOTAL, per instance
+		Heartbeat    Duration `yaml:"heartbeat" json:"heartbeat"`           // STREAMS_HEARTBEAT, keeps idle streams from being cut by proxies
+		// CommentsPerMinute and CommentBurst pace the comments posted on a
+		// live websocket, per connection
+		CommentsPerMinute int `yaml:"comments_per_minute" json:"comments_per_minute"` // STREAMS_COMMENTS_PER_MINUTE
+		CommentBurst      int `yaml:"comment_burst" json:"comment_burst"`             // STREAMS_COMMENT_BURST
+	}
+
+	Redis struct {
+		URL string `yaml:"url" json:"url"` // REDISCLOUD_URL, secret
+	}
+
+	Postgres struct {
+		URL string `yaml:"url" json:"url"` // DATABASE_URL, secret
+	}
+
+	Swapi struct {
+		// SWAPI_SOURCE, "live" fetches from BaseURL, "fixtures" serves a snapshot
+		// so the api runs offline on deterministic data
+		Source      string `yaml:"source" json:"source"`
+		FixturesDir string `yaml:"fixtures_dir" json:"fixtures_dir"` // SWAPI_FIXTURES_DIR, snapshot read instead of the bundled one
+
+		BaseURL string `yaml:"base_url" json:"base_url"` // SWAPI_BASE_URL
+		// SWAPI_BACKEND, the api served at BaseURL: "swapi.dev", for swapi.dev
+		// and its mirrors, or "swapi.tech"
+		Backend string `yaml:"backend" json:"backend"`
+		// SWAPI_FALLBACKS, tried in order when BaseURL fails, as a comma
+		// separated list of backend=base_url, e.g. swapi.tech=https://www.swapi.tech
+		Fallbacks []SwapiBackend `yaml:"fallbacks" json:"fallbacks"`
+
+		Timeout       Duration   `yaml:"timeout" json:"timeout"`               // SWAPI_TIMEOUT, per request
+		RetryAttempts int        `yaml:"retry_attempts" json:"retry_attempts"` // SWAPI_RETRY_ATTEMPTS, 1 disables retries
+		Concurrency   int        `yaml:"concurrency" json:"concurrency"`       // SWAPI_CONCURRENCY, of the requests fetching characters
+		Cache         SwapiCache `yaml:"cache" json:"cache"`
+		RateLimit     RateLimit  `yaml:"rate_limit" json:"rate_limit"`
+		Breaker       Breaker    `yaml:"breaker" json:"breaker"`
+	}
+
+	// SwapiBackend is an api serving swapi's data, in the shape of Backend
+	SwapiBackend struct {
+		Backend string `yaml:"backend" json:"backend"` // "swapi.dev" or "swapi.tech"
+		BaseURL string `yaml:"base_url" json:"base_url"`
+	}
+
+	// RateLimit paces the requests to swapi with a token bucket, shared by the
+	// workers fetching characters and their retries
+	RateLimit struct {
+		PerSecond float64 `yaml:"per_second" json:"per_second"` // SWAPI_RATE_LIMIT, 0 disables the limit
+		Burst     int     `yaml:"burst" json:"burst"`           // SWAPI_RATE_BURST
+	}
+
+	// Breaker stops calling swapi after consecutive failures, until it cooled
+	// down and a probe succeeds
+	Breaker struct {
+		Threshold int      `yaml:"threshold" json:"threshold"` // SWAPI_BREAKER_THRESHOLD, consecutive failures opening it, 0 disables it
+		Cooldown  Duration `yaml:"cooldown" json:"cooldown"`   // SWAPI_BREAKER_COOLDOWN
+	}
+
+	// SwapiCache keeps the responses of swapi, revalidated once stale so
+	// unchanged resources are not downloaded again
+	SwapiCache struct {
+		Store string   `yaml:"store" json:"store"` // SWAPI_CACHE_STORE, off | disk | redis
+		Dir   string   `yaml:"dir" json:"dir"`     // SWAPI_CACHE_DIR, of the disk store
+		TTL   Duration `yaml:"ttl" json:"ttl"`     // SWAPI_CACHE_TTL, of the responses kept in redis
+	}
+
+	Refresh struct {
+		Timeout Duration `yaml:"timeout" json:"timeout"` // REFRESH_TIMEOUT, bounds a whole refresh
+		// cron expression of each periodic job by name, "off" disables a job on
+		// this instance; SCHEDULE_<JOB> overrides one
+		Schedules map[string]string `yaml:"schedules" json:"schedules"`
+	}
+
+	Comments struct {
+		IPRetention Duration `yaml:"ip_retention" json:"ip_retention"` // COMMENTS_IP_RETENTION, before ip addresses are anonymised
+	}
+
+	Outbox struct {
+		Sink      string   `yaml:"sink" json:"sink"`           // OUTBOX_SINK, "redis" or "stdout"
+		Retention Duration `yaml:"retention" json:"retention"` // OUTBOX_RETENTION, before published messages are deleted
+	}
+
+	Tracing struct {
+		Exporter string `yaml:"exporter" json:"exporter"` // OTEL_TRACES_EXPORTER, "none", "otlp" or "stdout"
+	}
+)
+
+// Default returns the configuration used for anything the file and the
+// environment leave unset
+func Default() Config {
+	return Config{
+		Server: Server{
+			ReadHeaderTimeout: Duration(10 * time.Second),
+			DefaultPageSize:   10,
+			Streams: Streams{
+				MaxPerClient:      5,
+				MaxTotal:          1000,
+				Heartbeat:         Duration(15 * time.Second),
+				CommentsPerMinute: 10,
+				CommentBurst:      3,
+			},
+		},
+		Swapi: Swapi{
+			Source:        "live",
+			BaseURL:       "https://swapi.dev",
+			Backend:       "swapi.dev",
+			Timeout:       Duration(120 * time.Second),
+			RetryAttempts: 3,
+			Concurrency:   5,
+			Cache: SwapiCache{
+				Store: "disk",
+				Dir:   filepath.Join(os.TempDir(), "movie-api", "swapi"),
+				TTL:   Duration(7 * 24 * time.Hour),
+			},
+			RateLimit: RateLimit{
+				PerSecond: 5,
+				Burst:     10,
+			},
+			Breaker: Breaker{
+				Threshold: 5,
+				Cooldown:  Duration(30 * time.Second),
+			},
+		},
+		Refresh: Refresh{
+			Timeout:   Duration(10 * time.Minute),
+			Schedules: map[string]string{},
+		},
+		Comments: Comments{
+			IPRetention: Duration(30 * 24 * time.Hour),
+		},
+		Outbox: Outbox{
+			Sink:      "redis",
+			Retention: Duration(7 * 24 * time.Hour),
+		},
+		Tracing: Tracing{
+			Exporter: "none",
+		},
+	}
+}
+
+// Load reads the file named by CONFIG_FILE, if any, then the environment
+func Load(getenv func(string) string) (Config, error) {
+	cfg := Default()
+
+	if path := getenv(FileEnv); path != "" {
+		if err := cfg.readFile(path); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := cfg.applyEnv(getenv); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+// readFile decodes the file over cfg by its extension; unknown keys are an
+// error so a typo does not silently fall back to a default
+func (c *Config) readFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("error reading config file: %w", err)
+	}
+
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		dec := yaml.NewDecoder(bytes.NewReader(data))
+		dec.KnownFields(true)
+		err = dec.Decode(c)
+	case ".json":
+		dec := json.NewDecoder(bytes.NewReader(data))
+		dec.DisallowUnknownFields()
+		err = dec.Decode(c)
+	default:
+		return fmt.Errorf("unsupported config file extension %q, use .yaml, .yml or .json", ext)
+	}
+	if err != nil {
+		return fmt.Errorf("error decoding config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// Redacted returns a copy of the config safe to print, with the credentials
+// of connection urls and the admin token masked
+func (c Config) Redacted() Config {
+	if c.Server.AdminToken != "" {
+		c.Server.AdminToken = "xxxxx"
+	}
+	c.Redis.URL = redactURL(c.Redis.URL)
+	c.Postgres.URL = redactURL(c.Postgres.URL)
+	return c
+}
+
+// String prints the redacted config as YAML
+func (c Config) String() string {
+	out, err := yaml.Marshal(c.Redacted())
+	if err != nil {
+		return err.Error()
+	}
+	return string(out)
+}
+
+func redactURL(raw string) string {
+	if raw == "" {
+		return ""
+	}
+	u, err := url.Parse(raw)
+	if err != nil {
+		// the url may still carry a password, print none of it
+		return "xxxxx"
+	}
+	return u.Redacted()
+}
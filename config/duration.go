@@ -0,0 +1,24 @@
+package config
+
+import "time"
+
+// Duration is a time.Duration read and written as text, e.g. "15s" or
+// "720h", in YAML, JSON and the environment alike
+type Duration time.Duration
+
+func (d Duration) Std() time.Duration {
+	return time.Duration(d)
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(time.Duration(d).String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	v, err := time.ParseDuration(string(text))
+	if err != nil {
+		return err
+	}
+	*d = Duration(v)
+	return nil
+}
@@ -0,0 +1,121 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/iamnator/movie-api/model"
+)
+
+// scheduleEnvPrefix and the upper cased job name override a job's schedule,
+// e.g. SCHEDULE_COMMENT_COUNTS
+const scheduleEnvPrefix = "SCHEDULE_"
+
+// scheduledJobs are the jobs whose schedule can be set from the environment
+var scheduledJobs = []string{
+	model.JobFilms,
+	model.JobCharacters,
+	model.JobPlanets,
+	model.JobCommentCounts,
+	model.JobIPAnonymisation,
+	model.JobOutboxPruning,
+}
+
+// applyEnv overrides the config with every environment variable set
+func (c *Config) applyEnv(getenv func(string) string) error {
+	var problems []string
+
+	str := func(dst *string, key string) {
+		if v := getenv(key); v != "" {
+			*dst = v
+		}
+	}
+	num := func(dst *int, key string) {
+		if v := getenv(key); v != "" {
+			n, err := strconv.Atoi(v)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not a number", key, v))
+				return
+			}
+			*dst = n
+		}
+	}
+	float := func(dst *float64, key string) {
+		if v := getenv(key); v != "" {
+			f, err := strconv.ParseFloat(v, 64)
+			if err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %q is not a number", key, v))
+				return
+			}
+			*dst = f
+		}
+	}
+	dur := func(dst *Duration, key string) {
+		if v := getenv(key); v != "" {
+			if err := dst.UnmarshalText([]byte(v)); err != nil {
+				problems = append(problems, fmt.Sprintf("%s: %s", key, err))
+			}
+		}
+	}
+
+	str(&c.Server.Port, "PORT")
+	str(&c.Server.Host, "HOST_MACHINE")
+	dur(&c.Server.ReadHeaderTimeout, "SERVER_READ_HEADER_TIMEOUT")
+	num(&c.Server.DefaultPageSize, "DEFAULT_PAGE_SIZE")
+	num(&c.Server.Streams.MaxPerClient, "STREAMS_MAX_PER_CLIENT")
+	num(&c.Server.Streams.MaxTotal, "STREAMS_MAX_TOTAL")
+	dur(&c.Server.Streams.Heartbeat, "STREAMS_HEARTBEAT")
+	num(&c.Server.Streams.CommentsPerMinute, "STREAMS_COMMENTS_PER_MINUTE")
+	num(&c.Server.Streams.CommentBurst, "STREAMS_COMMENT_BURST")
+	str(&c.Server.AdminToken, "ADMIN_TOKEN")
+
+	str(&c.Redis.URL, "REDISCLOUD_URL")
+	str(&c.Postgres.URL, "DATABASE_URL")
+
+	str(&c.Swapi.Source, "SWAPI_SOURCE")
+	str(&c.Swapi.FixturesDir, "SWAPI_FIXTURES_DIR")
+	str(&c.Swapi.BaseURL, "SWAPI_BASE_URL")
+	str(&c.Swapi.Backend, "SWAPI_BACKEND")
+	if v := getenv("SWAPI_FALLBACKS"); v != "" {
+		c.Swapi.Fallbacks = nil
+		for _, fallback := range strings.Split(v, ",") {
+			backend, baseURL, ok := strings.Cut(strings.TrimSpace(fallback), "=")
+			if !ok {
+				problems = append(problems, fmt.Sprintf("SWAPI_FALLBACKS: %q is not backend=base_url", fallback))
+				continue
+			}
+			c.Swapi.Fallbacks = append(c.Swapi.Fallbacks, SwapiBackend{Backend: backend, BaseURL: baseURL})
+		}
+	}
+	dur(&c.Swapi.Timeout, "SWAPI_TIMEOUT")
+	num(&c.Swapi.RetryAttempts, "SWAPI_RETRY_ATTEMPTS")
+	num(&c.Swapi.Concurrency, "SWAPI_CONCURRENCY")
+	str(&c.Swapi.Cache.Store, "SWAPI_CACHE_STORE")
+	str(&c.Swapi.Cache.Dir, "SWAPI_CACHE_DIR")
+	dur(&c.Swapi.Cache.TTL, "SWAPI_CACHE_TTL")
+	float(&c.Swapi.RateLimit.PerSecond, "SWAPI_RATE_LIMIT")
+	num(&c.Swapi.RateLimit.Burst, "SWAPI_RATE_BURST")
+	num(&c.Swapi.Breaker.Threshold, "SWAPI_BREAKER_THRESHOLD")
+	dur(&c.Swapi.Breaker.Cooldown, "SWAPI_BREAKER_COOLDOWN")
+
+	dur(&c.Refresh.Timeout, "REFRESH_TIMEOUT")
+	for _, job := range scheduledJobs {
+		if v := getenv(scheduleEnvPrefix + strings.ToUpper(job)); v != "" {
+			if c.Refresh.Schedules == nil {
+				c.Refresh.Schedules = make(map[string]string)
+			}
+			c.Refresh.Schedules[job] = v
+		}
+	}
+
+	dur(&c.Comments.IPRetention, "COMMENTS_IP_RETENTION")
+	str(&c.Outbox.Sink, "OUTBOX_SINK")
+	dur(&c.Outbox.Retention, "OUTBOX_RETENTION")
+	str(&c.Tracing.Exporter, "OTEL_TRACES_EXPORTER")
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package config
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+
+	"github.com/iamnator/movie-api/pkg/scheduler"
+)
+
+// ValidationError lists every problem of a config at once, so they can all
+// be fixed before the next boot
+type ValidationError struct {
+	Problems []string
+}
+
+func (e *ValidationError) Error() string {
+	return "invalid config: " + strings.Join(e.Problems, "; ")
+}
+
+// settings only some commands need, by key, with the variable setting them
+const (
+	ServerPort  = "server.port (PORT)"
+	RedisURL    = "redis.url (REDISCLOUD_URL)"
+	PostgresURL = "postgres.url (DATABASE_URL)"
+)
+
+// Require checks the settings a command cannot run without are set
+func (c Config) Require(settings ...string) error {
+	var problems []string
+	for _, setting := range settings {
+		var set bool
+		switch setting {
+		case ServerPort:
+			set = c.Server.Port != ""
+		case RedisURL:
+			set = c.Redis.URL != ""
+		case PostgresURL:
+			set = c.Postgres.URL != ""
+		}
+		if !set {
+			problems = append(problems, setting+" is required")
+		}
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+// Validate checks the config is consistent; settings left unset are checked
+// by Require
+func (c Config) Validate() error {
+	var problems []string
+	check := func(ok bool, format string, args ...interface{}) {
+		if !ok {
+			problems = append(problems, fmt.Sprintf(format, args...))
+		}
+	}
+
+	check(c.Server.ReadHeaderTimeout > 0, "server.read_header_timeout must be positive")
+	check(c.Server.DefaultPageSize > 0, "server.default_page_size must be positive")
+	check(c.Server.Streams.MaxPerClient > 0, "server.streams.max_per_client must be positive")
+	check(c.Server.Streams.MaxTotal >= c.Server.Streams.MaxPerClient, "server.streams.max_total must be at least server.streams.max_per_client")
+	check(c.Server.Streams.Heartbeat > 0, "server.streams.heartbeat must be positive")
+	check(c.Server.Streams.CommentsPerMinute > 0, "server.streams.comments_per_minute must be positive")
+	check(c.Server.Streams.CommentBurst > 0, "server.streams.comment_burst must be positive")
+
+	check(c.Swapi.Source == "live" || c.Swapi.Source == "fixtures", "swapi.source %q must be live or fixtures", c.Swapi.Source)
+	check(c.Swapi.FixturesDir == "" || c.Swapi.Source == "fixtures", "swapi.fixtures_dir is only read with swapi.source fixtures")
+	check(httpURL(c.Swapi.BaseURL), "swapi.base_url %q must be an http(s) url", c.Swapi.BaseURL)
+	check(knownSwapiBackend(c.Swapi.Backend), "swapi.backend %q must be swapi.dev or swapi.tech", c.Swapi.Backend)
+	for i, fallback := range c.Swapi.Fallbacks {
+		check(knownSwapiBackend(fallback.Backend), "swapi.fallbacks[%d].backend %q must be swapi.dev or swapi.tech", i, fallback.Backend)
+		check(httpURL(fallback.BaseURL), "swapi.fallbacks[%d].base_url %q must be an http(s) url", i, fallback.BaseURL)
+	}
+	check(c.Swapi.Timeout > 0, "swapi.timeout must be positive")
+	check(c.Swapi.RetryAttempts > 0, "swapi.retry_attempts must be at least 1")
+	check(c.Swapi.Concurrency > 0, "swapi.concurrency must be at least 1")
+	check(c.Swapi.RateLimit.PerSecond >= 0, "swapi.rate_limit.per_second must not be negative")
+	check(c.Swapi.RateLimit.PerSecond == 0 || c.Swapi.RateLimit.Burst > 0, "swapi.rate_limit.burst must be positive")
+	check(c.Swapi.Breaker.Threshold >= 0, "swapi.breaker.threshold must not be negative")
+	check(c.Swapi.Breaker.Threshold == 0 || c.Swapi.Breaker.Cooldown > 0, "swapi.breaker.cooldown must be positive")
+	switch c.Swapi.Cache.Store {
+	case "off":
+	case "disk":
+		check(c.Swapi.Cache.Dir != "", "swapi.cache.dir is required with the disk store")
+	case "redis":
+		check(c.Swapi.Cache.TTL > 0, "swapi.cache.ttl must be positive")
+	default:
+		problems = append(problems, fmt.Sprintf("swapi.cache.store %q must be off, disk or redis", c.Swapi.Cache.Store))
+	}
+
+	check(c.Refresh.Timeout > 0, "refresh.timeout must be positive")
+	for job, spec := range c.Refresh.Schedules {
+		check(knownJob(job), "refresh.schedules: unknown job %q", job)
+		if spec != scheduler.Disabled {
+			_, err := cron.ParseStandard(spec)
+			check(err == nil, "refresh.schedules.%s: %v", job, err)
+		}
+	}
+
+	check(c.Comments.IPRetention >= Duration(24*time.Hour), "comments.ip_retention must be at least a day")
+
+	check(c.Outbox.Sink == "redis" || c.Outbox.Sink == "stdout", "outbox.sink %q must be redis or stdout", c.Outbox.Sink)
+	check(c.Outbox.Retention >= Duration(time.Hour), "outbox.retention must be at least an hour")
+
+	switch c.Tracing.Exporter {
+	case "", "none", "otlp", "stdout":
+	default:
+		problems = append(problems, fmt.Sprintf("tracing.exporter %q must be none, otlp or stdout", c.Tracing.Exporter))
+	}
+
+	if len(problems) > 0 {
+		return &ValidationError{Problems: problems}
+	}
+	return nil
+}
+
+func knownJob(name string) bool {
+	for _, job := range scheduledJobs {
+		if job == name {
+			return true
+		}
+	}
+	return false
+}
+
+// knownSwapiBackend reports whether the swapi client reads the api's shape
+func knownSwapiBackend(backend string) bool {
+	return backend == "swapi.dev" || backend == "swapi.tech"
+}
+
+func httpURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
@@ -0,0 +1,45 @@
+package main
+
+import (
+	"context"
+	"log"
+
+	"github.com/go-redis/redis/v8"
+	"gorm.io/gorm"
+)
+
+// openRedis connects to redis and pings it. A command opens a single client,
+// its adapters share the pool.
+func openRedis(ctx context.Context, url string) (*redis.Client, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, err
+	}
+
+	client := redis.NewClient(opts)
+
+	if err := client.Ping(ctx).Err(); err != nil {
+		_ = client.Close()
+		return nil, err
+	}
+
+	return client, nil
+}
+
+// closeRedis closes the client once the command is done with it
+func closeRedis(client *redis.Client) {
+	if err := client.Close(); err != nil {
+		log.Printf("error closing redis client: %s", err)
+	}
+}
+
+// closePostgres closes the pool of a db opened with repository.Open
+func closePostgres(db *gorm.DB) {
+	sqlDB, err := db.DB()
+	if err == nil {
+		err = sqlDB.Close()
+	}
+	if err != nil {
+		log.Printf("error closing postgres: %s", err)
+	}
+}
@@ -0,0 +1,30 @@
+// Package database bundles the sql migrations and seed data with the binary,
+// so the cli can apply them without the source tree.
+package database
+
+import (
+	"embed"
+	"io/fs"
+)
+
+//go:embed migrations/*.sql
+var migrations embed.FS
+
+//go:embed seed/fixtures.json
+var fixtures []byte
+
+// Migrations returns the golang-migrate style migrations, named
+// <version>_<title>.<up|down>.sql
+func Migrations() fs.FS {
+	sub, err := fs.Sub(migrations, "migrations")
+	if err != nil {
+		// the directory is embedded above, it cannot be missing
+		panic(err)
+	}
+	return sub
+}
+
+// Fixtures returns the seed data loaded by the seed command by default
+func Fixtures() []byte {
+	return fixtures
+}
@@ -0,0 +1,43 @@
+create table if not exists webhook_subscription
+(
+    id         uuid      default gen_random_uuid() not null
+        constraint webhook_subscription_pk
+            primary key,
+    url        text                                not null,
+    events     text[]    default '{}'              not null,
+    secret     text                                not null,
+    created_at timestamp default current_timestamp not null,
+    deleted_at timestamp
+);
+
+comment on column webhook_subscription.events is 'event types to deliver; empty means every event';
+
+comment on column webhook_subscription.secret is 'key of the HMAC-SHA256 signature sent with every delivery';
+
+create table if not exists webhook_delivery
+(
+    id              uuid      default gen_random_uuid() not null
+        constraint webhook_delivery_pk
+            primary key,
+    subscription_id uuid                                not null
+        constraint webhook_delivery_subscription_fk
+            references webhook_subscription (id),
+    event_id        uuid                                not null,
+    event_type      varchar(50)                         not null,
+    payload         jsonb                               not null,
+    state           varchar(20)                         not null,
+    attempts        int       default 0                 not null,
+    last_error      text,
+    next_attempt_at timestamp default current_timestamp not null,
+    created_at      timestamp default current_timestamp not null,
+    delivered_at    timestamp
+);
+
+comment on column webhook_delivery.state is 'pending, delivered or dead once retries are exhausted';
+
+create index if not exists webhook_delivery_pending_index
+    on webhook_delivery (next_attempt_at)
+    where state = 'pending';
+
+create index if not exists webhook_delivery_state_index
+    on webhook_delivery (state, created_at);
@@ -0,0 +1,25 @@
+create table if not exists outbox
+(
+    id              uuid      default gen_random_uuid() not null
+        constraint outbox_pk
+            primary key,
+    idempotency_key text                                not null,
+    topic           varchar(100)                        not null,
+    payload         jsonb                               not null,
+    attempts        int       default 0                 not null,
+    last_error      text,
+    locked_until    timestamp,
+    created_at      timestamp default current_timestamp not null,
+    published_at    timestamp
+);
+
+comment on column outbox.idempotency_key is 'stable key of the event, consumers deduplicate redeliveries on it';
+
+comment on column outbox.locked_until is 'the relay that claimed the message owns it until then';
+
+create unique index if not exists outbox_idempotency_key_uindex
+    on outbox (idempotency_key);
+
+create index if not exists outbox_pending_index
+    on outbox (created_at)
+    where published_at is null;
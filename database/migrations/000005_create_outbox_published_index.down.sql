@@ -0,0 +1 @@
+drop index if exists outbox_published_index;
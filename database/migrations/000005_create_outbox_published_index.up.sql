@@ -0,0 +1,3 @@
+create index if not exists outbox_published_index
+    on outbox (published_at)
+    where published_at is not null;
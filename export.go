@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"io"
+	"os"
+
+	"github.com/iamnator/movie-api/adapter/cache"
+	"github.com/iamnator/movie-api/adapter/repository"
+	"github.com/iamnator/movie-api/config"
+	"github.com/iamnator/movie-api/model"
+)
+
+// exportPageSize is how many movies are read from the cache at once
+const exportPageSize = 100
+
+// export writes the cached movies, or the comments, one json document per line
+func export(ctx context.Context, cfg config.Config, args []string, stdout io.Writer) (err error) {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	movieID := fs.Int("movie", 0, "swapi id of the only movie whose comments are exported")
+	out := fs.String("o", "", "file written instead of stdout")
+	what, err := subcommand(fs, args, "movies", "comments")
+	if err != nil {
+		return err
+	}
+
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			return err
+		}
+		defer func() {
+			if er := f.Close(); err == nil {
+				err = er
+			}
+		}()
+		w = f
+	}
+	enc := json.NewEncoder(w)
+
+	switch what {
+	case "movies":
+		return exportMovies(ctx, cfg, enc)
+	default:
+		return exportComments(ctx, cfg, *movieID, enc)
+	}
+}
+
+func exportMovies(ctx context.Context, cfg config.Config, enc *json.Encoder) error {
+	if err := cfg.Require(config.RedisURL); err != nil {
+		return err
+	}
+
+	redisClient, err := openRedis(ctx, cfg.Redis.URL)
+	if err != nil {
+		return err
+	}
+	defer closeRedis(redisClient)
+
+	redisCache, err := cache.OpenRedisCache(redisClient)
+	if err != nil {
+		return err
+	}
+	defer redisCache.Close()
+
+	for page, exported := 1, int64(0); ; page++ {
+		movies, count, err := redisCache.GetMovies(ctx, page, exportPageSize)
+		if err != nil {
+			return err
+		}
+
+		for _, movie := range movies {
+			if err := enc.Encode(movie); err != nil {
+				return err
+			}
+		}
+
+		exported += int64(len(movies))
+		if len(movies) == 0 || exported >= count {
+			return nil
+		}
+	}
+}
+
+func exportComments(ctx context.Context, cfg config.Config, movieID int, enc *json.Encoder) error {
+	if err := cfg.Require(config.PostgresURL); err != nil {
+		return err
+	}
+
+	db, err := repository.Open(cfg.Postgres.URL)
+	if err != nil {
+		return err
+	}
+	defer closePostgres(db)
+
+	return repository.NewPgxCommentRepository(db).EachComment(ctx, movieID, func(comment model.Comment) error {
+		return enc.Encode(comment)
+	})
+}
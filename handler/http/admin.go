@@ -0,0 +1,86 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+
+	"github.com/iamnator/movie-api/service"
+)
+
+// triggerRefreshHandler handles the request to refresh the cache from swapi
+//
+//	@Summary		Refresh the movie cache
+//	@Description	Starts an asynchronous refresh of movies and characters from swapi. Triggers received while a refresh of the same scope is running join it, a refresh of another scope running answers 409 to retry later.
+//	@Tags			Admin
+//	@Param			film_id				query		int	false	"Only refresh this film"
+//	@Success		202					{object}	model.GenericResponse{data=model.RefreshJob}
+//	@Failure		400,401,403,409,502	{object}	model.GenericResponse{error=string}
+//	@Security		AdminToken
+//	@Router			/admin/refresh [post]
+func (h handlers) triggerRefreshHandler(w http.ResponseWriter, r *http.Request) {
+	var filmID int
+	if v := r.URL.Query().Get("film_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil || id < 1 {
+			respondWithError(w, http.StatusBadRequest, "Invalid film id", err)
+			return
+		}
+		filmID = id
+	}
+
+	job, err := h.service.TriggerRefresh(r.Context(), filmID)
+	if errors.Is(err, service.ErrRefreshRunning) {
+		respondWithError(w, http.StatusConflict, "A refresh of another scope is running, retry once it is done", err)
+		return
+	}
+	if err != nil {
+		respondWithError(w, http.StatusInternalServerError, "Error starting refresh", err)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusAccepted, "Refresh started", 0, job)
+}
+
+// getRefreshJobHandler handles the request to get the status of a refresh job
+//
+//	@Summary		Get a refresh job
+//	@Description	Get the state and progress of a refresh job, whichever instance runs it. Jobs are reported for a day after their last update.
+//	@Tags			Admin
+//	@Param			job_id			path		string	true	"Job ID"
+//	@Success		200				{object}	model.GenericResponse{data=model.RefreshJob}
+//	@Failure		401,403,404,502	{object}	model.GenericResponse{error=string}
+//	@Security		AdminToken
+//	@Router			/admin/jobs/{job_id} [get]
+func (h handlers) getRefreshJobHandler(w http.ResponseWriter, r *http.Request) {
+	jobID := mux.Vars(r)["job_id"]
+
+	job, err := h.service.GetRefreshJob(r.Context(), jobID)
+	if err != nil {
+		if errors.Is(err, service.ErrRefreshJobNotFound) {
+			respondWithError(w, http.StatusNotFound, "Job not found", err)
+			return
+		}
+		respondWithError(w, http.StatusInternalServerError, "Error getting job", err)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Success", 1, job)
+}
+
+// getScheduleHandler handles the request to list the periodic jobs
+//
+//	@Summary		Get the job schedule
+//	@Description	Lists the periodic jobs of this instance with their cron expression and next and last run times
+//	@Tags			Admin
+//	@Success		200		{object}	model.GenericResponse{data=[]model.ScheduledJob}
+//	@Failure		401,403	{object}	model.GenericResponse{error=string}
+//	@Security		AdminToken
+//	@Router			/admin/schedule [get]
+func (h handlers) getScheduleHandler(w http.ResponseWriter, r *http.Request) {
+	jobs := h.service.GetSchedule(r.Context())
+
+	respondWithSuccess(w, http.StatusOK, "Success", int64(len(jobs)), jobs)
+}
@@ -5,28 +5,44 @@ import (
 	"errors"
 	"github.com/go-openapi/runtime/middleware"
 	"github.com/iamnator/movie-api/service"
-	"github.com/rs/zerolog/log"
 	"net/http"
 	"strconv"
 	"time"
 
 	"github.com/gorilla/mux"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gorilla/mux/otelmux"
+
+	"github.com/iamnator/movie-api/config"
 	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/metrics"
+	"github.com/iamnator/movie-api/pkg/tracing"
 )
 
 type handlers struct {
-	service service.IServices
+	service         service.IServices
+	streams         *streamLimiter // open comment streams
+	heartbeat       time.Duration  // keeps idle streams from being cut by proxies
+	defaultPageSize int
+
+	// commentsPerMinute and commentBurst pace the comments posted on a live websocket
+	commentsPerMinute, commentBurst int
 }
 
-func NewHandlers(srv service.IServices) handlers {
+func NewHandlers(srv service.IServices, cfg config.Server) handlers {
 	return handlers{
-		service: srv,
+		service:         srv,
+		streams:         newStreamLimiter(cfg.Streams.MaxPerClient, cfg.Streams.MaxTotal),
+		heartbeat:       cfg.Streams.Heartbeat.Std(),
+		defaultPageSize: cfg.DefaultPageSize,
+
+		commentsPerMinute: cfg.Streams.CommentsPerMinute,
+		commentBurst:      cfg.Streams.CommentBurst,
 	}
 }
 
-func Run(port string, r *mux.Router, srv service.IServices) error {
+func Run(cfg config.Server, r *mux.Router, srv service.IServices) error {
 
-	handler := NewHandlers(srv)
+	handler := NewHandlers(srv, cfg)
 
 	// documentation for developers
 	opts := middleware.SwaggerUIOpts{SpecURL: "./docs/swagger.json"}
@@ -39,23 +55,21 @@ func Run(port string, r *mux.Router, srv service.IServices) error {
 		http.ServeFile(w, r, "docs/swagger.yaml")
 	})
 
-	//add logging middleware
-	loggingMiddleware := func(next http.Handler) http.Handler {
-		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-			start := time.Now()
-			next.ServeHTTP(w, r)
-			elapsed := time.Since(start)
-			log.Info().Msgf("%s %s in %s", r.Method, r.RequestURI, elapsed)
-		})
-	}
+	// the trace context of the caller is picked up here, before anything is timed
+	r.Use(otelmux.Middleware(tracing.ServiceName))
+	r.Use(requestIDMiddleware)
+	r.Use(accessLogMiddleware)
+	r.Use(metricsMiddleware)
 
-	r.Use(loggingMiddleware)
+	r.Handle("/metrics", metrics.Handler()).Methods(http.MethodGet)
 
 	//add health check endpoint
 	r.HandleFunc("/health", func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 		_ = json.NewEncoder(w).Encode(map[string]string{"status": "ok"})
 	})
+	r.HandleFunc("/healthz/live", handler.livenessHandler).Methods(http.MethodGet)
+	r.HandleFunc("/healthz/ready", handler.readinessHandler).Methods(http.MethodGet)
 
 	r.HandleFunc("/movies", handler.getMoviesHandler).Methods(http.MethodGet)
 	r.HandleFunc("/movies/{movie_id}", handler.getMovieHandler).Methods(http.MethodGet)
@@ -63,9 +77,35 @@ func Run(port string, r *mux.Router, srv service.IServices) error {
 
 	r.HandleFunc("/comments/{movie_id}", handler.addCommentHandler).Methods(http.MethodPost)
 	r.HandleFunc("/comments/{movie_id}", handler.getCommentHandler).Methods(http.MethodGet)
+	r.HandleFunc("/comments/{movie_id}/stream", handler.streamCommentsHandler).Methods(http.MethodGet)
+	r.HandleFunc("/ws/movies/{movie_id}", handler.liveMovieHandler).Methods(http.MethodGet)
+
+	handler.adminRoutes(r.PathPrefix("/admin").Subrouter(), cfg.AdminToken)
+
+	server := &http.Server{
+		Addr:              ":" + cfg.Port,
+		Handler:           r,
+		ReadHeaderTimeout: cfg.ReadHeaderTimeout.Std(),
+	}
+
+	return server.ListenAndServe()
+
+}
+
+// adminRoutes registers the admin api on the /admin subrouter, behind the
+// admin token
+func (h handlers) adminRoutes(admin *mux.Router, token string) {
+	admin.Use(adminAuthMiddleware(token))
 
-	return http.ListenAndServe(":"+port, r)
+	admin.HandleFunc("/refresh", h.triggerRefreshHandler).Methods(http.MethodPost)
+	admin.HandleFunc("/jobs/{job_id}", h.getRefreshJobHandler).Methods(http.MethodGet)
+	admin.HandleFunc("/schedule", h.getScheduleHandler).Methods(http.MethodGet)
 
+	admin.HandleFunc("/webhooks", h.createWebhookHandler).Methods(http.MethodPost)
+	admin.HandleFunc("/webhooks", h.getWebhooksHandler).Methods(http.MethodGet)
+	admin.HandleFunc("/webhooks/deliveries", h.getWebhookDeliveriesHandler).Methods(http.MethodGet)
+	admin.HandleFunc("/webhooks/deliveries/{delivery_id}/replay", h.replayWebhookDeliveryHandler).Methods(http.MethodPost)
+	admin.HandleFunc("/webhooks/{webhook_id}", h.deleteWebhookHandler).Methods(http.MethodDelete)
 }
 
 func respondWithError(w http.ResponseWriter, code int, msg string, err error) {
@@ -115,10 +155,10 @@ func (h handlers) getMoviesHandler(w http.ResponseWriter, r *http.Request) {
 
 	pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
 	if err != nil {
-		pageSize = 10 //
+		pageSize = h.defaultPageSize
 	}
 
-	movieList, count, err := h.service.GetMovies(page, pageSize)
+	movieList, count, err := h.service.GetMovies(r.Context(), page, pageSize)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error getting movies", err)
 		return
@@ -144,7 +184,7 @@ func (h handlers) getMovieHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	movie, err := h.service.GetMovieByID(movieID)
+	movie, err := h.service.GetMovieByID(r.Context(), movieID)
 	if err != nil {
 		respondWithError(w, http.StatusNotFound, "Error getting movie", err)
 		return //
@@ -183,7 +223,7 @@ func (h handlers) getMovieCharacterHandler(w http.ResponseWriter, r *http.Reques
 
 	pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
 	if err != nil {
-		pageSize = 10
+		pageSize = h.defaultPageSize
 	}
 
 	sortKey := r.URL.Query().Get("sortKey")
@@ -221,7 +261,7 @@ func (h handlers) getMovieCharacterHandler(w http.ResponseWriter, r *http.Reques
 		}
 	}
 
-	if err := h.service.ValidateMovieID(movieID); err != nil {
+	if err := h.service.ValidateMovieID(r.Context(), movieID); err != nil {
 		respondWithError(w, http.StatusNotFound, "Invalid movie id", err)
 		return
 	}
@@ -235,7 +275,7 @@ func (h handlers) getMovieCharacterHandler(w http.ResponseWriter, r *http.Reques
 		Gender:    gender,
 	}
 
-	characterList, count, err := h.service.GetCharactersByMovieID(arg)
+	characterList, count, err := h.service.GetCharactersByMovieID(r.Context(), arg)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error getting characters", err)
 		return
@@ -271,15 +311,15 @@ func (h handlers) getCommentHandler(w http.ResponseWriter, r *http.Request) {
 
 	pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
 	if err != nil {
-		pageSize = 10
+		pageSize = h.defaultPageSize
 	}
 
-	if err := h.service.ValidateMovieID(movieID); err != nil {
+	if err := h.service.ValidateMovieID(r.Context(), movieID); err != nil {
 		respondWithError(w, http.StatusNotFound, "Invalid movie id", err)
 		return
 	}
 
-	comments, count, err := h.service.GetComment(movieID, page, pageSize)
+	comments, count, err := h.service.GetComment(r.Context(), movieID, page, pageSize)
 	if err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error getting comments", err)
 		return
@@ -318,7 +358,7 @@ func (h handlers) addCommentHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	if err := h.service.ValidateMovieID(movieID); err != nil {
+	if err := h.service.ValidateMovieID(r.Context(), movieID); err != nil {
 		respondWithError(w, http.StatusNotFound, "Invalid movie id", err)
 		return
 	}
@@ -329,7 +369,7 @@ func (h handlers) addCommentHandler(w http.ResponseWriter, r *http.Request) {
 	comment.IPv4Addr = r.RemoteAddr
 	comment.CreatedAt = time.Now().UTC()
 
-	if err = h.service.SaveComment(movieID, comment); err != nil {
+	if err = h.service.SaveComment(r.Context(), movieID, comment); err != nil {
 		respondWithError(w, http.StatusInternalServerError, "Error saving comment", err)
 		return
 	}
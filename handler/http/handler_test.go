@@ -1 +1,74 @@
 package http
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+
+	"github.com/iamnator/movie-api/config"
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service"
+	"github.com/iamnator/movie-api/service/mocks"
+)
+
+func Test_adminRoutes_RequireToken(t *testing.T) {
+	tests := []struct {
+		name          string
+		token         string // configured
+		authorization string
+		status        int
+	}{
+		{name: "no token", token: "s3cret", status: http.StatusUnauthorized},
+		{name: "not a bearer token", token: "s3cret", authorization: "Basic czNjcmV0", status: http.StatusUnauthorized},
+		{name: "wrong token", token: "s3cret", authorization: "Bearer guess", status: http.StatusForbidden},
+		{name: "admin api disabled", token: "", authorization: "Bearer ", status: http.StatusUnauthorized},
+		{name: "any token while disabled", token: "", authorization: "Bearer guess", status: http.StatusForbidden},
+		{name: "valid token", token: "s3cret", authorization: "Bearer s3cret", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			srv := mocks.NewMockIServices(ctrl)
+
+			// rejected requests never reach the service
+			requests := []*http.Request{httptest.NewRequest(http.MethodGet, "/admin/schedule", nil)}
+			if tt.status == http.StatusOK {
+				srv.EXPECT().GetSchedule(gomock.Any()).Return([]model.ScheduledJob{})
+			} else {
+				requests = append(requests, httptest.NewRequest(http.MethodPost, "/admin/refresh", nil))
+			}
+
+			r := mux.NewRouter()
+			NewHandlers(srv, config.Default().Server).adminRoutes(r.PathPrefix("/admin").Subrouter(), tt.token)
+
+			for _, req := range requests {
+				if tt.authorization != "" {
+					req.Header.Set("Authorization", tt.authorization)
+				}
+				rec := httptest.NewRecorder()
+				r.ServeHTTP(rec, req)
+
+				if rec.Code != tt.status {
+					t.Errorf("%s %s: status=%d | expected=%d", req.Method, req.URL.Path, rec.Code, tt.status)
+				}
+			}
+		})
+	}
+}
+
+func Test_triggerRefreshHandler_Conflict(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv := mocks.NewMockIServices(ctrl)
+	srv.EXPECT().TriggerRefresh(gomock.Any(), 1).Return(nil, service.ErrRefreshRunning)
+
+	rec := httptest.NewRecorder()
+	NewHandlers(srv, config.Default().Server).triggerRefreshHandler(rec, httptest.NewRequest(http.MethodPost, "/admin/refresh?film_id=1", nil))
+
+	if rec.Code != http.StatusConflict {
+		t.Errorf("status=%d | expected=%d", rec.Code, http.StatusConflict)
+	}
+}
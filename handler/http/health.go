@@ -0,0 +1,48 @@
+package http
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/iamnator/movie-api/model"
+)
+
+// livenessHandler handles the liveness probe, it only tells the process is up
+//
+//	@Summary		Liveness probe
+//	@Description	Responds as long as the process serves requests, whatever the state of its dependencies
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	model.HealthReport
+//	@Router			/healthz/live [get]
+func (h handlers) livenessHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithHealth(w, model.HealthReport{Status: model.HealthOK, CheckedAt: time.Now().UTC()})
+}
+
+// readinessHandler handles the readiness probe
+//
+//	@Summary		Readiness probe
+//	@Description	Checks redis, the RediSearch indexes, postgres, the schema version and the age of the last cache refresh. Responds 503 when the instance should not receive traffic; a stale refresh only degrades it.
+//	@Tags			Health
+//	@Produce		json
+//	@Success		200	{object}	model.HealthReport
+//	@Failure		503	{object}	model.HealthReport
+//	@Router			/healthz/ready [get]
+func (h handlers) readinessHandler(w http.ResponseWriter, r *http.Request) {
+	respondWithHealth(w, h.service.Readiness(r.Context()))
+}
+
+// respondWithHealth writes the report as is, probes read the status code and
+// people the checks
+func respondWithHealth(w http.ResponseWriter, report model.HealthReport) {
+	code := http.StatusOK
+	if !report.Ready() {
+		code = http.StatusServiceUnavailable
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	_ = json.NewEncoder(w).Encode(report)
+}
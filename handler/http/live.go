@@ -0,0 +1,249 @@
+package http
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service"
+)
+
+const (
+	// liveSendBuffer is how many messages a client may lag behind before it
+	// is disconnected; it reconnects and catches up with GET /comments
+	liveSendBuffer = 16
+
+	liveWriteWait  = 10 * time.Second
+	livePongWait   = 60 * time.Second
+	livePingPeriod = livePongWait * 9 / 10
+
+	// liveMaxMessageSize bounds a message from the client, comments are at most 500 chars
+	liveMaxMessageSize = 4096
+)
+
+var upgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	// the api is public and has no cookies to protect
+	CheckOrigin: func(r *http.Request) bool { return true },
+}
+
+// liveConn is a live discussion websocket. Only its write loop writes to the
+// connection; everything else queues messages with send.
+type liveConn struct {
+	conn   *websocket.Conn
+	out    chan model.LiveMessage
+	cancel context.CancelFunc
+}
+
+// commentBucket is a token bucket pacing the comments posted on a live
+// connection. Only the read loop uses it.
+type commentBucket struct {
+	rate   float64 // tokens added per second
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newCommentBucket(perMinute, burst int, now time.Time) *commentBucket {
+	if burst < 1 {
+		burst = 1
+	}
+
+	return &commentBucket{
+		rate:   float64(perMinute) / 60,
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   now,
+	}
+}
+
+// allow takes a token, it returns false when there is none left
+func (b *commentBucket) allow(now time.Time) bool {
+	b.tokens += now.Sub(b.last).Seconds() * b.rate
+	if b.tokens > b.burst {
+		b.tokens = b.burst
+	}
+	b.last = now
+
+	if b.tokens < 1 {
+		return false
+	}
+	b.tokens--
+	return true
+}
+
+// send queues msg, disconnecting the client if it is too slow to keep up
+func (c *liveConn) send(msg model.LiveMessage) {
+	select {
+	case c.out <- msg:
+	default:
+		log.Warn().Str("client", c.conn.RemoteAddr().String()).Msg("live client too slow, disconnecting")
+		_ = c.conn.WriteControl(websocket.CloseMessage,
+			websocket.FormatCloseMessage(websocket.ClosePolicyViolation, "too slow"),
+			time.Now().Add(liveWriteWait))
+		c.cancel()
+	}
+}
+
+func (c *liveConn) writeLoop(ctx context.Context) {
+	ping := time.NewTicker(livePingPeriod)
+	defer ping.Stop()
+	defer c.conn.Close()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case msg := <-c.out:
+			_ = c.conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if err := c.conn.WriteJSON(msg); err != nil {
+				c.cancel()
+				return
+			}
+		case <-ping.C:
+			if err := c.conn.WriteControl(websocket.PingMessage, nil, time.Now().Add(liveWriteWait)); err != nil {
+				c.cancel()
+				return
+			}
+		}
+	}
+}
+
+// forward queues new comments and viewer counts until ctx is done
+func (c *liveConn) forward(ctx context.Context, comments <-chan model.CommentEventData, viewers <-chan int64) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case comment, ok := <-comments:
+			if !ok {
+				// dropped by the broker for falling behind
+				c.cancel()
+				return
+			}
+			c.send(model.LiveMessage{Type: model.LiveComment, Comment: &comment})
+		case count, ok := <-viewers:
+			if !ok {
+				viewers = nil
+				continue
+			}
+			c.send(model.LiveMessage{Type: model.LivePresence, Viewers: &count})
+		}
+	}
+}
+
+// liveMovieHandler handles the live discussion websocket of a movie
+//
+//	@Summary		Live discussion of a movie
+//	@Description	WebSocket carrying model.LiveMessage JSON messages. The server sends new comments, viewer counts (presence) and errors; the client posts comments with {"type":"comment","message":"..."} and gets an ack once saved, or an error when posting faster than the connection's rate limit. Clients too slow to keep up are disconnected.
+//	@Tags			Comments
+//	@Param			movie_id		path		int	true	"Movie ID"
+//	@Success		101				{object}	model.LiveMessage
+//	@Failure		400,404,429,501	{object}	model.GenericResponse{error=string}
+//	@Router			/ws/movies/{movie_id} [get]
+func (h handlers) liveMovieHandler(w http.ResponseWriter, r *http.Request) {
+	movieID, err := strconv.Atoi(mux.Vars(r)["movie_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid movie id", err)
+		return
+	}
+
+	client := clientIP(r)
+	if !h.streams.acquire(client) {
+		respondWithError(w, http.StatusTooManyRequests, "Too many open streams", nil)
+		return
+	}
+	defer h.streams.release(client)
+
+	// the request context is not cancelled once the connection is hijacked
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	comments, err := h.service.StreamComments(ctx, movieID, "")
+	if err != nil {
+		if errors.Is(err, service.ErrStreamingDisabled) {
+			respondWithError(w, http.StatusNotImplemented, "Streaming is not configured", err)
+			return
+		}
+		respondWithError(w, http.StatusNotFound, "Invalid movie id", err)
+		return
+	}
+
+	viewers, err := h.service.WatchMovie(ctx, movieID)
+	if err != nil && !errors.Is(err, service.ErrPresenceDisabled) {
+		respondWithError(w, http.StatusInternalServerError, "Error joining movie", err)
+		return
+	}
+
+	conn, err := upgrader.Upgrade(w, r, nil)
+	if err != nil {
+		// the upgrader already responded
+		return
+	}
+
+	c := &liveConn{
+		conn:   conn,
+		out:    make(chan model.LiveMessage, liveSendBuffer),
+		cancel: cancel,
+	}
+
+	go c.writeLoop(ctx)
+	go c.forward(ctx, comments, viewers)
+
+	bucket := newCommentBucket(h.commentsPerMinute, h.commentBurst, time.Now())
+
+	// the write loop closes the connection once ctx is done, ending the reads
+	conn.SetReadLimit(liveMaxMessageSize)
+	_ = conn.SetReadDeadline(time.Now().Add(livePongWait))
+	conn.SetPongHandler(func(string) error {
+		return conn.SetReadDeadline(time.Now().Add(livePongWait))
+	})
+
+	for {
+		var msg model.LiveMessage
+		if err := conn.ReadJSON(&msg); err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Ctx(ctx).Debug().Err(err).Msg("live connection closed")
+			}
+			return
+		}
+
+		if msg.Type != model.LiveComment {
+			c.send(model.LiveMessage{Type: model.LiveError, Error: "unsupported message type"})
+			continue
+		}
+
+		// checked before the comment is validated, so invalid ones are paced too
+		if !bucket.allow(time.Now()) {
+			c.send(model.LiveMessage{Type: model.LiveError, Error: "too many comments, slow down"})
+			continue
+		}
+
+		req := model.AddCommentRequest{Message: msg.Message}
+		if err := req.Validate(); err != nil {
+			c.send(model.LiveMessage{Type: model.LiveError, Error: err.Error()})
+			continue
+		}
+
+		comment := req.ToComment()
+		comment.SwapiMovieID = movieID
+		comment.IPv4Addr = r.RemoteAddr
+		comment.CreatedAt = time.Now().UTC()
+
+		if err := h.service.SaveComment(ctx, movieID, comment); err != nil {
+			c.send(model.LiveMessage{Type: model.LiveError, Error: "error saving comment"})
+			continue
+		}
+
+		// the comment itself arrives through the broker like everyone else's
+		c.send(model.LiveMessage{Type: model.LiveAck})
+	}
+}
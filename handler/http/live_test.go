@@ -0,0 +1,131 @@
+package http
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/gorilla/websocket"
+
+	"github.com/iamnator/movie-api/config"
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service"
+	"github.com/iamnator/movie-api/service/mocks"
+)
+
+func Test_liveMovieHandler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv := mocks.NewMockIServices(ctrl)
+
+	comments := make(chan model.CommentEventData, 1)
+	viewers := make(chan int64, 1)
+	viewers <- 2
+
+	srv.EXPECT().StreamComments(gomock.Any(), 1, "").Return((<-chan model.CommentEventData)(comments), nil)
+	srv.EXPECT().WatchMovie(gomock.Any(), 1).Return((<-chan int64)(viewers), nil)
+	srv.EXPECT().SaveComment(gomock.Any(), 1, gomock.Any()).DoAndReturn(func(_ context.Context, movieID int, comment model.Comment) error {
+		if comment.Message != "hello there" || comment.SwapiMovieID != 1 {
+			t.Errorf("comment=%+v | expected the posted message on movie 1", comment)
+		}
+		return nil
+	})
+
+	r := mux.NewRouter()
+	r.HandleFunc("/ws/movies/{movie_id}", NewHandlers(srv, config.Default().Server).liveMovieHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/movies/1", nil)
+	if err != nil {
+		t.Fatalf("error connecting: %s", err)
+	}
+	defer conn.Close()
+
+	read := func() model.LiveMessage {
+		t.Helper()
+		var msg model.LiveMessage
+		_ = conn.SetReadDeadline(time.Now().Add(time.Second))
+		if err := conn.ReadJSON(&msg); err != nil {
+			t.Fatalf("error reading: %s", err)
+		}
+		return msg
+	}
+
+	if msg := read(); msg.Type != model.LivePresence || msg.Viewers == nil || *msg.Viewers != 2 {
+		t.Errorf("message=%+v | expected 2 viewers", msg)
+	}
+
+	// invalid comments are rejected without closing the connection
+	for _, msg := range []model.LiveMessage{
+		{Type: model.LiveComment, Message: "hi"},
+		{Type: model.LivePresence},
+		{Type: model.LiveComment, Message: "hello there"},
+	} {
+		if err := conn.WriteJSON(msg); err != nil {
+			t.Fatalf("error writing: %s", err)
+		}
+	}
+	for _, want := range []model.LiveMessageType{model.LiveError, model.LiveError, model.LiveAck} {
+		if msg := read(); msg.Type != want {
+			t.Errorf("message=%+v | expected type=%s", msg, want)
+		}
+	}
+
+	comment := model.CommentEventData{ID: uuid.New(), MovieID: 1, Message: "hello there"}
+	comments <- comment
+	if msg := read(); msg.Type != model.LiveComment || msg.Comment == nil || msg.Comment.ID != comment.ID {
+		t.Errorf("message=%+v | expected comment %s", msg, comment.ID)
+	}
+}
+
+func Test_liveMovieHandler_StreamingDisabled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	srv := mocks.NewMockIServices(ctrl)
+
+	srv.EXPECT().StreamComments(gomock.Any(), 1, "").Return(nil, service.ErrStreamingDisabled)
+
+	r := mux.NewRouter()
+	r.HandleFunc("/ws/movies/{movie_id}", NewHandlers(srv, config.Default().Server).liveMovieHandler)
+	server := httptest.NewServer(r)
+	defer server.Close()
+
+	_, resp, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http")+"/ws/movies/1", nil)
+	if err == nil {
+		t.Fatalf("connected while streaming is disabled")
+	}
+	if resp == nil || resp.StatusCode != 501 {
+		t.Errorf("response=%v | expected status 501", resp)
+	}
+}
+
+func Test_commentBucket(t *testing.T) {
+	now := time.Now()
+	bucket := newCommentBucket(6, 2, now)
+
+	for i, want := range []bool{true, true, false} {
+		if got := bucket.allow(now); got != want {
+			t.Errorf("comment %d allowed=%v | expected=%v", i, got, want)
+		}
+	}
+
+	// 6 a minute adds a token every 10 seconds
+	if bucket.allow(now.Add(9 * time.Second)) {
+		t.Errorf("allowed a comment before a token was added")
+	}
+	if !bucket.allow(now.Add(11 * time.Second)) {
+		t.Errorf("refused a comment once a token was added")
+	}
+
+	// an idle connection saves up no more than the burst
+	later := now.Add(time.Hour)
+	for i, want := range []bool{true, true, false} {
+		if got := bucket.allow(later); got != want {
+			t.Errorf("comment %d after idling allowed=%v | expected=%v", i, got, want)
+		}
+	}
+}
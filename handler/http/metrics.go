@@ -0,0 +1,20 @@
+package http
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/iamnator/movie-api/pkg/metrics"
+)
+
+// metricsMiddleware records the count and latency of requests by route
+// template, so /movies/1 and /movies/2 share a series
+func metricsMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		metrics.ObserveHTTPRequest(routeTemplate(r), r.Method, rec.statusCode(), time.Since(start))
+	})
+}
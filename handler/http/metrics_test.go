@@ -0,0 +1,51 @@
+package http
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+
+	"github.com/iamnator/movie-api/pkg/metrics"
+)
+
+func Test_metricsMiddleware_RouteTemplate(t *testing.T) {
+	r := mux.NewRouter()
+	r.Use(metricsMiddleware)
+	r.HandleFunc("/metrics-test/{movie_id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+	})
+	r.Handle("/metrics", metrics.Handler())
+
+	for _, path := range []string{"/metrics-test/1", "/metrics-test/2"} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, _ := io.ReadAll(rec.Body)
+
+	expected := `movie_api_http_requests_total{method="GET",route="/metrics-test/{movie_id}",status="418"} 2`
+	if !strings.Contains(string(body), expected) {
+		t.Errorf("metrics=%s | expected to contain=%s", body, expected)
+	}
+}
+
+func Test_statusRecorder_Flush(t *testing.T) {
+	rec := httptest.NewRecorder()
+	w := &statusRecorder{ResponseWriter: rec}
+
+	var _ http.Flusher = w
+	_, _ = w.Write([]byte("data: x\n\n"))
+	w.Flush()
+
+	if !rec.Flushed {
+		t.Errorf("flushed=%v | expected=%v", rec.Flushed, true)
+	}
+	if w.status != http.StatusOK {
+		t.Errorf("status=%d | expected=%d", w.status, http.StatusOK)
+	}
+}
@@ -0,0 +1,169 @@
+package http
+
+import (
+	"bufio"
+	"crypto/subtle"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDHeader carries the id correlating the logs of a request
+const RequestIDHeader = "X-Request-ID"
+
+// maxRequestIDLen bounds the incoming request ids that are trusted as is
+const maxRequestIDLen = 128
+
+// statusRecorder keeps the status code and size of the response written by a
+// handler; it passes Flush and Hijack through so comment streams and
+// websockets keep working
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+func (w *statusRecorder) Write(b []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(b)
+	w.bytes += int64(n)
+	return n, err
+}
+
+func (w *statusRecorder) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusRecorder) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response writer does not support hijacking")
+	}
+	// the connection is handed over to the websocket
+	w.status = http.StatusSwitchingProtocols
+	return h.Hijack()
+}
+
+// statusCode is the status sent, handlers writing nothing send 200
+func (w *statusRecorder) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// routeTemplate is the template of the route matching r, so /movies/1 and
+// /movies/2 are reported alike
+func routeTemplate(r *http.Request) string {
+	if current := mux.CurrentRoute(r); current != nil {
+		if tpl, err := current.GetPathTemplate(); err == nil {
+			return tpl
+		}
+	}
+	return "unmatched"
+}
+
+// validRequestID reports whether an incoming request id is safe to log and echo
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestIDLen {
+		return false
+	}
+	for _, c := range id {
+		if c < '!' || c > '~' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestIDMiddleware tags the request with the caller's X-Request-ID, or a
+// new one, and stores a logger carrying it, and the trace id, in the request
+// context for everything down to the repositories to log with
+func requestIDMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(RequestIDHeader)
+		if !validRequestID(id) {
+			id = uuid.NewString()
+		}
+		w.Header().Set(RequestIDHeader, id)
+
+		logCtx := log.With().Str("request_id", id)
+		if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+			logCtx = logCtx.Str("trace_id", sc.TraceID().String())
+		}
+		logger := logCtx.Logger()
+
+		next.ServeHTTP(w, r.WithContext(logger.WithContext(r.Context())))
+	})
+}
+
+// accessLogMiddleware logs every served request once it is done
+func accessLogMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w}
+		next.ServeHTTP(rec, r)
+
+		status := rec.statusCode()
+
+		var event *zerolog.Event
+		switch logger := log.Ctx(r.Context()); {
+		case status >= http.StatusInternalServerError:
+			event = logger.Error()
+		case status >= http.StatusBadRequest:
+			event = logger.Warn()
+		default:
+			event = logger.Info()
+		}
+
+		event.
+			Str("method", r.Method).
+			Str("route", routeTemplate(r)).
+			Str("path", r.URL.Path).
+			Int("status", status).
+			Int64("bytes", rec.bytes).
+			Str("ip", clientIP(r)).
+			Dur("latency", time.Since(start)).
+			Msg("request served")
+	})
+}
+
+// adminAuthMiddleware only lets requests bearing the admin token through: 401
+// without a token, 403 with a wrong one, and 403 for every request when no
+// token is configured
+func adminAuthMiddleware(token string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			header := r.Header.Get("Authorization")
+			bearer := strings.TrimPrefix(header, "Bearer ")
+			switch {
+			case bearer == header || bearer == "":
+				w.Header().Set("WWW-Authenticate", `Bearer realm="admin"`)
+				respondWithError(w, http.StatusUnauthorized, "Admin token required", nil)
+			case token == "" || subtle.ConstantTimeCompare([]byte(bearer), []byte(token)) != 1:
+				respondWithError(w, http.StatusForbidden, "Invalid admin token", nil)
+			default:
+				next.ServeHTTP(w, r)
+			}
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package http
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+func Test_requestIDMiddleware(t *testing.T) {
+	var logged string
+
+	r := mux.NewRouter()
+	r.Use(requestIDMiddleware)
+	r.HandleFunc("/movies/{movie_id}", func(w http.ResponseWriter, r *http.Request) {
+		var buf bytes.Buffer
+		logger := log.Ctx(r.Context()).Output(&buf)
+		logger.Info().Msg("from the handler")
+		logged = buf.String()
+	})
+
+	tests := []struct {
+		name     string
+		incoming string
+		keep     bool
+	}{
+		{name: "honours the caller's id", incoming: "abc-123", keep: true},
+		{name: "generates a missing id", incoming: ""},
+		{name: "replaces an unsafe id", incoming: "abc\n123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/movies/1", nil)
+			if tt.incoming != "" {
+				req.Header.Set(RequestIDHeader, tt.incoming)
+			}
+			rec := httptest.NewRecorder()
+			r.ServeHTTP(rec, req)
+
+			id := rec.Header().Get(RequestIDHeader)
+			if (id == tt.incoming) != tt.keep || id == "" {
+				t.Errorf("request id=%q | incoming=%q keep=%v", id, tt.incoming, tt.keep)
+			}
+
+			var line map[string]interface{}
+			if err := json.Unmarshal([]byte(logged), &line); err != nil {
+				t.Fatalf("error decoding log line %q: %s", logged, err)
+			}
+			if line["request_id"] != id {
+				t.Errorf("logged request_id=%v | expected=%v", line["request_id"], id)
+			}
+		})
+	}
+}
+
+func Test_accessLogMiddleware(t *testing.T) {
+	var buf bytes.Buffer
+	logger := zerolog.New(&buf)
+
+	r := mux.NewRouter()
+	r.Use(func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(logger.WithContext(r.Context())))
+		})
+	})
+	r.Use(accessLogMiddleware)
+	r.HandleFunc("/movies/{movie_id}", func(w http.ResponseWriter, r *http.Request) {
+		respondWithError(w, http.StatusNotFound, "Movie not found", nil)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "/movies/42", nil)
+	req.RemoteAddr = "10.0.0.7:5555"
+	rec := httptest.NewRecorder()
+	r.ServeHTTP(rec, req)
+
+	var line map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &line); err != nil {
+		t.Fatalf("error decoding log line %q: %s", buf.String(), err)
+	}
+
+	expected := map[string]interface{}{
+		"level":  "warn",
+		"method": http.MethodGet,
+		"route":  "/movies/{movie_id}",
+		"path":   "/movies/42",
+		"status": float64(http.StatusNotFound),
+		"bytes":  float64(rec.Body.Len()),
+		"ip":     "10.0.0.7",
+	}
+	for field, value := range expected {
+		if line[field] != value {
+			t.Errorf("%s=%v | expected=%v", field, line[field], value)
+		}
+	}
+	if _, ok := line["latency"]; !ok {
+		t.Errorf("expected the latency to be logged: %s", buf.String())
+	}
+}
@@ -0,0 +1,149 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/iamnator/movie-api/service"
+)
+
+// streamLimiter counts open streams per client ip
+type streamLimiter struct {
+	mu       sync.Mutex
+	total    int
+	byClient map[string]int
+
+	// maxPerClient and maxTotal bound the open streams of a client ip and of
+	// the whole instance
+	maxPerClient, maxTotal int
+}
+
+func newStreamLimiter(maxPerClient, maxTotal int) *streamLimiter {
+	return &streamLimiter{
+		byClient:     make(map[string]int),
+		maxPerClient: maxPerClient,
+		maxTotal:     maxTotal,
+	}
+}
+
+// acquire reserves a stream for client, it returns false when a limit is reached
+func (l *streamLimiter) acquire(client string) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if l.total >= l.maxTotal || l.byClient[client] >= l.maxPerClient {
+		return false
+	}
+
+	l.total++
+	l.byClient[client]++
+	return true
+}
+
+func (l *streamLimiter) release(client string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.total--
+	if l.byClient[client]--; l.byClient[client] <= 0 {
+		delete(l.byClient, client)
+	}
+}
+
+// clientIP is the ip of the remote address without its port
+func clientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+// streamCommentsHandler handles the request to stream new comments of a movie
+//
+//	@Summary		Stream new comments of a movie
+//	@Description	Server-Sent Events stream of the comments added to a movie, as "comment" events whose id is the comment id. Send the Last-Event-ID header when reconnecting to get the comments missed meanwhile. A ping comment is sent every 15 seconds.
+//	@Tags			Comments
+//	@Produce		text/event-stream
+//	@Param			movie_id		path		int		true	"Movie ID"
+//	@Param			Last-Event-ID	header		string	false	"Id of the last comment received"
+//	@Success		200				{object}	model.CommentEventData
+//	@Failure		400,404,429,501	{object}	model.GenericResponse{error=string}
+//	@Router			/comments/{movie_id}/stream [get]
+func (h handlers) streamCommentsHandler(w http.ResponseWriter, r *http.Request) {
+	movieID, err := strconv.Atoi(mux.Vars(r)["movie_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid movie id", err)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		respondWithError(w, http.StatusInternalServerError, "Streaming is not supported", nil)
+		return
+	}
+
+	client := clientIP(r)
+	if !h.streams.acquire(client) {
+		respondWithError(w, http.StatusTooManyRequests, "Too many open streams", nil)
+		return
+	}
+	defer h.streams.release(client)
+
+	comments, err := h.service.StreamComments(r.Context(), movieID, r.Header.Get("Last-Event-ID"))
+	if err != nil {
+		if errors.Is(err, service.ErrStreamingDisabled) {
+			respondWithError(w, http.StatusNotImplemented, "Streaming is not configured", err)
+			return
+		}
+		respondWithError(w, http.StatusNotFound, "Invalid movie id", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("Connection", "keep-alive")
+	w.Header().Set("X-Accel-Buffering", "no") // disable proxy buffering
+	w.WriteHeader(http.StatusOK)
+
+	// clients reconnect after 3s when the stream is cut
+	_, _ = fmt.Fprint(w, "retry: 3000\n\n")
+	flusher.Flush()
+
+	heartbeat := time.NewTicker(h.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-heartbeat.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				return
+			}
+		case comment, ok := <-comments:
+			if !ok {
+				// dropped for falling behind, the client resumes with Last-Event-ID
+				return
+			}
+
+			data, err := json.Marshal(comment)
+			if err != nil {
+				continue
+			}
+			if _, err := fmt.Fprintf(w, "id: %s\nevent: comment\ndata: %s\n\n", comment.ID, data); err != nil {
+				return
+			}
+		}
+
+		flusher.Flush()
+	}
+}
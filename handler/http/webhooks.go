@@ -0,0 +1,171 @@
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/google/uuid"
+	"github.com/gorilla/mux"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service"
+)
+
+// respondWithWebhookError maps webhook service errors to status codes
+func respondWithWebhookError(w http.ResponseWriter, msg string, err error) {
+	switch {
+	case errors.Is(err, service.ErrWebhooksDisabled):
+		respondWithError(w, http.StatusNotImplemented, "Webhooks are not configured", err)
+	case errors.Is(err, service.ErrWebhookTargetForbidden):
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook url", err)
+	case errors.Is(err, service.ErrWebhookNotFound), errors.Is(err, service.ErrWebhookDeliveryNotFound):
+		respondWithError(w, http.StatusNotFound, msg, err)
+	default:
+		respondWithError(w, http.StatusInternalServerError, msg, err)
+	}
+}
+
+// createWebhookHandler handles the request to subscribe a url to change events
+//
+//	@Summary		Create a webhook
+//	@Description	Subscribes a public http(s) url to movie, character and comment events. Deliveries are signed with an HMAC-SHA256 of <X-Webhook-Timestamp>.<body> keyed with the secret, sent in the X-Webhook-Signature header as sha256=<hex>; receivers should reject timestamps more than 5 minutes from their clock. The secret is only returned here.
+//	@Tags			Admin
+//	@Accept			json
+//	@Param			webhook				body		model.AddWebhookRequest	true	"Webhook"
+//	@Success		201					{object}	model.GenericResponse{data=model.WebhookSubscription}
+//	@Failure		400,401,403,501,502	{object}	model.GenericResponse{error=string}
+//	@Security		AdminToken
+//	@Router			/admin/webhooks [post]
+func (h handlers) createWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	var req model.AddWebhookRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	if err := req.Validate(); err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid request payload", err)
+		return
+	}
+
+	subscription, err := h.service.CreateWebhook(r.Context(), req)
+	if err != nil {
+		respondWithWebhookError(w, "Error creating webhook", err)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusCreated, "Webhook created", 0, subscription)
+}
+
+// getWebhooksHandler handles the request to list webhooks
+//
+//	@Summary		List webhooks
+//	@Description	Lists webhook subscriptions, without their secrets
+//	@Tags			Admin
+//	@Success		200				{object}	model.GenericResponse{data=[]model.WebhookSubscription}
+//	@Failure		401,403,501,502	{object}	model.GenericResponse{error=string}
+//	@Security		AdminToken
+//	@Router			/admin/webhooks [get]
+func (h handlers) getWebhooksHandler(w http.ResponseWriter, r *http.Request) {
+	subscriptions, err := h.service.GetWebhooks(r.Context())
+	if err != nil {
+		respondWithWebhookError(w, "Error getting webhooks", err)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Success", int64(len(subscriptions)), subscriptions)
+}
+
+// deleteWebhookHandler handles the request to unsubscribe a webhook
+//
+//	@Summary		Delete a webhook
+//	@Description	Deletes a webhook subscription; its pending deliveries are dead-lettered
+//	@Tags			Admin
+//	@Param			webhook_id				path		string	true	"Webhook ID"
+//	@Success		200						{object}	model.GenericResponse{message=string}
+//	@Failure		400,401,403,404,501,502	{object}	model.GenericResponse{error=string}
+//	@Security		AdminToken
+//	@Router			/admin/webhooks/{webhook_id} [delete]
+func (h handlers) deleteWebhookHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["webhook_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid webhook id", err)
+		return
+	}
+
+	if err := h.service.DeleteWebhook(r.Context(), id); err != nil {
+		respondWithWebhookError(w, "Error deleting webhook", err)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Webhook deleted", 0, nil)
+}
+
+// getWebhookDeliveriesHandler handles the request to list webhook deliveries
+//
+//	@Summary		List webhook deliveries
+//	@Description	Lists webhook deliveries, newest first. Use state=dead for the dead-letter list.
+//	@Tags			Admin
+//	@Param			state				query		string	false	"pending, delivered or dead"
+//	@Param			page				query		int		false	"Page number"
+//	@Param			pageSize			query		int		false	"Page size"
+//	@Success		200					{object}	model.GenericResponse{data=[]model.WebhookDelivery}
+//	@Failure		400,401,403,501,502	{object}	model.GenericResponse{error=string}
+//	@Security		AdminToken
+//	@Router			/admin/webhooks/deliveries [get]
+func (h handlers) getWebhookDeliveriesHandler(w http.ResponseWriter, r *http.Request) {
+	state := model.WebhookDeliveryState(r.URL.Query().Get("state"))
+	switch state {
+	case "", model.WebhookDeliveryPending, model.WebhookDeliveryDelivered, model.WebhookDeliveryDead:
+	default:
+		respondWithError(w, http.StatusBadRequest, "Invalid state", nil)
+		return
+	}
+
+	//get page and page size from query params
+	page, err := strconv.Atoi(r.URL.Query().Get("page"))
+	if err != nil {
+		page = 1
+	}
+
+	pageSize, err := strconv.Atoi(r.URL.Query().Get("pageSize"))
+	if err != nil {
+		pageSize = h.defaultPageSize
+	}
+
+	deliveries, count, err := h.service.GetWebhookDeliveries(r.Context(), state, page, pageSize)
+	if err != nil {
+		respondWithWebhookError(w, "Error getting webhook deliveries", err)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusOK, "Success", count, deliveries)
+}
+
+// replayWebhookDeliveryHandler handles the request to send a delivery again
+//
+//	@Summary		Replay a webhook delivery
+//	@Description	Queues a delivery, typically a dead one, again with a fresh retry budget
+//	@Tags			Admin
+//	@Param			delivery_id				path		string	true	"Delivery ID"
+//	@Success		202						{object}	model.GenericResponse{data=model.WebhookDelivery}
+//	@Failure		400,401,403,404,501,502	{object}	model.GenericResponse{error=string}
+//	@Security		AdminToken
+//	@Router			/admin/webhooks/deliveries/{delivery_id}/replay [post]
+func (h handlers) replayWebhookDeliveryHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := uuid.Parse(mux.Vars(r)["delivery_id"])
+	if err != nil {
+		respondWithError(w, http.StatusBadRequest, "Invalid delivery id", err)
+		return
+	}
+
+	delivery, err := h.service.ReplayWebhookDelivery(r.Context(), id)
+	if err != nil {
+		respondWithWebhookError(w, "Error replaying webhook delivery", err)
+		return
+	}
+
+	respondWithSuccess(w, http.StatusAccepted, "Delivery queued", 0, delivery)
+}
@@ -29,6 +29,14 @@ func TestMain(m *testing.M) {
 
 	log.Printf("Integration tests: host %s is available", host)
 
+	// the api fills its cache from swapi, the bundled snapshot when it runs
+	// with SWAPI_SOURCE=fixtures, right after it starts
+	err = cacheCheck(attempts)
+	if err != nil {
+		log.Fatalf("Integration tests: cache of host %s is not filled: %s", host, err)
+		return
+	}
+
 	code := m.Run()
 	os.Exit(code)
 }
@@ -52,6 +60,26 @@ func healthCheck(attempts int) error {
 	return err
 }
 
+func cacheCheck(attempts int) error {
+	var err error
+
+	for attempts > 0 {
+		// count is left out of the response while there are no movies
+		err = Do(Get(basePath+"/movies"), Expect().Body().JSON().Contains("count"))
+		if err == nil {
+			return nil
+		}
+
+		log.Printf("Integration tests: movies are not cached yet, attempts left: %d", attempts)
+
+		time.Sleep(time.Second)
+
+		attempts--
+	}
+
+	return err
+}
+
 // HTTP GET: /movies
 func TestGetMovies(t *testing.T) {
 
@@ -95,6 +123,19 @@ func TestGetMovie(t *testing.T) {
 	)
 }
 
+// HTTP GET: /movies/{movie_id}, against the bundled swapi snapshot
+func TestGetMovieFromFixtures(t *testing.T) {
+
+	movieID := 1
+
+	Test(t,
+		Description("GetMovie from the swapi fixtures"),
+		Get(basePath+"/%s/%d", "movies", movieID),
+		Expect().Status().Equal(http.StatusOK),
+		Expect().Body().JSON().JQ(".data.name").Equal("A New Hope"),
+	)
+}
+
 // HTTP GET: /characters/{movie_id}
 func TestGetMovieCharacters(t *testing.T) {
 
@@ -1,20 +1,13 @@
 package main
 
 import (
+	"context"
 	"log"
-	"time"
+	"os"
 
-	"github.com/go-resty/resty/v2"
-	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
-
-	"github.com/iamnator/movie-api/adapter/cache"
-	"github.com/iamnator/movie-api/adapter/repository"
-	"github.com/iamnator/movie-api/docs"
-	"github.com/iamnator/movie-api/env"
-	"github.com/iamnator/movie-api/handler/http"
-	"github.com/iamnator/movie-api/service"
-	"github.com/iamnator/movie-api/thirdparty/swapi"
+	"github.com/rs/zerolog"
+	zlog "github.com/rs/zerolog/log"
 )
 
 //	@title			Busha Movie API documentation
@@ -23,46 +16,17 @@ import (
 
 //	@contact.name	Nator Verinumbe
 //	@contact.email	natorverinumbe@gmail.com
-func main() {
-
-	if er := env.Init(); er != nil {
-		panic(er)
-	}
-
-	//programmatically set swagger info
-	docs.SwaggerInfo.Title = "Busha Movie API"
-	docs.SwaggerInfo.Description = "This is a sample server for a movie API."
-	docs.SwaggerInfo.Version = "1.0"
-
-	docs.SwaggerInfo.Host = env.Get().HOST_MACHINE
-	docs.SwaggerInfo.BasePath = "/"
-	docs.SwaggerInfo.Schemes = []string{"http", "https"}
-
-	r := mux.NewRouter()
 
-	redisCache, err := cache.NewRedisCache(env.Get().REDIS_URL) //
-	if err != nil {
-		panic(err)
-	}
-	log.Println("Connected to redis")
+//	@securityDefinitions.apikey	AdminToken
+//	@in							header
+//	@name						Authorization
+//	@description				Bearer followed by the admin token (ADMIN_TOKEN)
+func main() {
 
-	commentRepo, err := repository.NewPgxCommentRepository(env.Get().POSTGRES_URL)
-	if err != nil {
-		panic(err)
-	}
-	log.Println("Connected to postgres")
+	// contexts without a request or job logger log with the global one
+	zerolog.DefaultContextLogger = &zlog.Logger
 
-	restyClient := resty.New()
-	restyClient.SetTimeout(120 * time.Second)
-	restyClient.SetRetryCount(3)
-	swapiClient, err := swapi.NewSwapi(restyClient.GetClient())
-	if err != nil {
-		panic(err)
+	if err := run(context.Background(), os.Args[1:], os.Getenv, os.Stdout); err != nil {
+		log.Fatal(err)
 	}
-
-	srv := service.NewServices(redisCache, commentRepo, swapiClient)
-
-	log.Println("Starting server on port ", env.Get().PORT)
-
-	log.Fatal(http.Run(env.Get().PORT, r, srv))
 }
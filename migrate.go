@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/iamnator/movie-api/adapter/repository"
+	"github.com/iamnator/movie-api/config"
+	"github.com/iamnator/movie-api/database"
+)
+
+// migrate applies or reverts the migrations bundled with the binary, or
+// lists them
+func migrate(ctx context.Context, cfg config.Config, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("migrate", flag.ContinueOnError)
+	steps := fs.Int("steps", 1, "how many migrations down reverts")
+	action, err := subcommand(fs, args, "up", "down", "status")
+	if err != nil {
+		return err
+	}
+	if *steps < 1 {
+		return errUsage
+	}
+
+	migrator, err := repository.NewMigrator(cfg.Postgres.URL, database.Migrations())
+	if err != nil {
+		return err
+	}
+	defer migrator.Close()
+
+	var changed []repository.Migration
+	switch action {
+	case "up":
+		changed, err = migrator.Up(ctx)
+	case "down":
+		changed, err = migrator.Down(ctx, *steps)
+	case "status":
+		return migrationStatus(ctx, migrator, stdout)
+	}
+
+	for _, migration := range changed {
+		fmt.Fprintf(stdout, "%s %d_%s\n", action, migration.Version, migration.Name)
+	}
+	if err == nil && len(changed) == 0 {
+		fmt.Fprintln(stdout, "no change")
+	}
+
+	return err
+}
+
+func migrationStatus(ctx context.Context, migrator *repository.Migrator, stdout io.Writer) error {
+	version, migrations, err := migrator.Status(ctx)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "version %d of %d", version.Current, version.Required)
+	if version.Dirty {
+		fmt.Fprint(stdout, ", dirty")
+	}
+	fmt.Fprint(stdout, "\n\n")
+
+	tw := tabwriter.NewWriter(stdout, 0, 4, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tNAME\tAPPLIED")
+	for _, migration := range migrations {
+		fmt.Fprintf(tw, "%d\t%s\t%t\n", migration.Version, migration.Name, migration.Applied)
+	}
+
+	return tw.Flush()
+}
@@ -1,6 +1,9 @@
 package model
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 type (
 	GetCharactersByMovieIDArgs struct {
@@ -13,25 +16,28 @@ type (
 	}
 
 	Character struct {
-		ID       int    `json:"character_id"` //from swapi
-		MovieID  int    `json:"movie_id"`     //from swapi
-		Name     string `json:"name"`
-		Gender   string `json:"gender"`
-		HeightCm int    `json:"height_cm"`
+		ID          int       `json:"character_id"` //from swapi
+		MovieID     int       `json:"movie_id"`     //from swapi
+		Name        string    `json:"name"`
+		Gender      string    `json:"gender"`
+		HeightCm    int       `json:"height_cm"`    // 0 when the height is not known
+		HeightKnown bool      `json:"height_known"` // false when swapi does not know it, or serves it malformed
+		UpdatedAt   time.Time `json:"updated_at"`   // edited time on swapi
 	}
 
 	CharacterList_Character struct {
-		Name     string  `json:"name"`
-		Gender   string  `json:"gender"`
-		HeightCm int     `json:"height_cm"`
-		HeightFt string  `json:"height_ft"`
-		HeightIn float64 `json:"height_in"`
+		Name        string  `json:"name"`
+		Gender      string  `json:"gender"`
+		HeightCm    int     `json:"height_cm"`
+		HeightKnown bool    `json:"height_known"`
+		HeightFt    string  `json:"height_ft"`
+		HeightIn    float64 `json:"height_in"`
 	}
 
 	CharacterList struct {
 		Characters []CharacterList_Character `json:"characters"`
 		TotalCount int                       `json:"total_count"`
-		TotalCm    int                       `json:"total_cm"`
+		TotalCm    int                       `json:"total_cm"` // sum of the known heights
 		TotalFt    string                    `json:"total_ft"`
 		TotalIn    float64                   `json:"total_in"`
 	}
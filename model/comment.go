@@ -50,3 +50,14 @@ type Comment struct {
 func (Comment) TableName() string {
 	return "comment"
 }
+
+// EventData is the comment as published to other services, without the
+// commenter's ip address
+func (c Comment) EventData() CommentEventData {
+	return CommentEventData{
+		ID:        c.ID,
+		MovieID:   c.SwapiMovieID,
+		Message:   c.Message,
+		CreatedAt: c.CreatedAt,
+	}
+}
@@ -0,0 +1,49 @@
+package model
+
+import "time"
+
+type HealthStatus string
+
+const (
+	HealthOK       HealthStatus = "ok"
+	HealthDegraded HealthStatus = "degraded" // working, but worth a look; still ready
+	HealthFailed   HealthStatus = "failed"
+)
+
+// names of the dependencies checked for readiness
+const (
+	HealthCheckRedis      = "redis"
+	HealthCheckRediSearch = "redisearch"
+	HealthCheckPostgres   = "postgres"
+	HealthCheckMigrations = "migrations"
+	HealthCheckRefresh    = "refresh"
+	HealthCheckSwapi      = "swapi"
+)
+
+// HealthCheck is the status of a single dependency
+type HealthCheck struct {
+	Status  HealthStatus           `json:"status" example:"ok" swaggertype:"string"`
+	Error   string                 `json:"error,omitempty"`
+	Details map[string]interface{} `json:"details,omitempty"`
+	Latency string                 `json:"latency" example:"1.2ms"`
+}
+
+// HealthReport tells whether the instance can serve traffic, it is ready
+// unless a check failed
+type HealthReport struct {
+	Status    HealthStatus           `json:"status" example:"ok" swaggertype:"string"`
+	Checks    map[string]HealthCheck `json:"checks,omitempty"`
+	CheckedAt time.Time              `json:"checked_at"`
+}
+
+// Ready reports whether no check failed
+func (r HealthReport) Ready() bool {
+	return r.Status != HealthFailed
+}
+
+// SchemaVersion is the migration state of the database
+type SchemaVersion struct {
+	Current  int64 `json:"current"`
+	Required int64 `json:"required"` // latest migration the code relies on
+	Dirty    bool  `json:"dirty"`    // a migration failed half way
+}
@@ -0,0 +1,21 @@
+package model
+
+type LiveMessageType string
+
+const (
+	LiveComment  LiveMessageType = "comment"  // a new comment, or a comment posted by the client
+	LivePresence LiveMessageType = "presence" // how many viewers the movie has
+	LiveAck      LiveMessageType = "ack"      // the client's comment was saved
+	LiveError    LiveMessageType = "error"
+)
+
+// LiveMessage is a message of the live discussion websocket of a movie. The
+// client posts {"type":"comment","message":"..."}; the server sends comments,
+// presence counts, acks and errors.
+type LiveMessage struct {
+	Type    LiveMessageType   `json:"type" example:"comment" swaggertype:"string"`
+	Message string            `json:"message,omitempty"`
+	Comment *CommentEventData `json:"comment,omitempty"`
+	Viewers *int64            `json:"viewers,omitempty"`
+	Error   string            `json:"error,omitempty"`
+}
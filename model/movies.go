@@ -13,6 +13,7 @@ type (
 		ReleaseDate  time.Time `json:"release_date"`
 		CreatedAt    time.Time `json:"created_at"`
 		UpdatedAt    time.Time `json:"updated_at"`
+		CommentCount int64     `json:"comment_count"` // copied from the database by the comment_counts job, not swapi
 	}
 )
 
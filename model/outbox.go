@@ -0,0 +1,31 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// outbox topics
+const (
+	TopicCommentCreated = "comment.created"
+)
+
+// OutboxMessage is an event recorded in the same transaction as the change it
+// describes, then published by the relay. Publishing is at least once;
+// consumers deduplicate on IdempotencyKey.
+type OutboxMessage struct {
+	ID             uuid.UUID  `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid();column:id"`
+	IdempotencyKey string     `json:"idempotency_key" gorm:"column:idempotency_key;not null;uniqueIndex"`
+	Topic          string     `json:"topic" gorm:"column:topic;not null"`
+	Payload        RawJSON    `json:"payload" gorm:"column:payload;type:jsonb;not null"`
+	Attempts       int        `json:"attempts" gorm:"column:attempts;not null"`
+	LastError      string     `json:"last_error,omitempty" gorm:"column:last_error"`
+	LockedUntil    *time.Time `json:"-" gorm:"column:locked_until"` // claimed by a relay until then
+	CreatedAt      time.Time  `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp"`
+	PublishedAt    *time.Time `json:"published_at,omitempty" gorm:"column:published_at"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
@@ -0,0 +1,13 @@
+package model
+
+import "time"
+
+// Planet is a planet of the films, cached by the planets job
+type Planet struct {
+	ID         int       `json:"planet_id"` //from swapi
+	Name       string    `json:"name"`
+	Climate    string    `json:"climate"`
+	Terrain    string    `json:"terrain"`
+	Population string    `json:"population"` // "unknown" when swapi does not know it
+	UpdatedAt  time.Time `json:"updated_at"` // edited time on swapi
+}
@@ -0,0 +1,97 @@
+package model
+
+import "time"
+
+type RefreshJobState string
+
+const (
+	RefreshJobRunning   RefreshJobState = "running"
+	RefreshJobSucceeded RefreshJobState = "succeeded"
+	RefreshJobFailed    RefreshJobState = "failed"
+	RefreshJobPartial   RefreshJobState = "partially_failed" // some films or characters could not be cached
+	RefreshJobSkipped   RefreshJobState = "skipped"          // another instance holds the refresh lock
+)
+
+type RefreshJobTrigger string
+
+const (
+	RefreshTriggerWarmUp    RefreshJobTrigger = "warm_up"
+	RefreshTriggerScheduled RefreshJobTrigger = "scheduled"
+	RefreshTriggerManual    RefreshJobTrigger = "manual"
+	RefreshTriggerCommand   RefreshJobTrigger = "command" // the refresh command of the cli
+)
+
+// names of the periodic jobs, used to override their schedule
+const (
+	JobFilms           = "films"
+	JobCharacters      = "characters"
+	JobPlanets         = "planets"
+	JobCommentCounts   = "comment_counts"
+	JobIPAnonymisation = "ip_anonymisation"
+	JobOutboxPruning   = "outbox_pruning"
+)
+
+// ScheduledJob reports when a periodic job runs
+type ScheduledJob struct {
+	Name           string     `json:"name" example:"films"`
+	Schedule       string     `json:"schedule" example:"0 */3 * * *"` // cron expression, "off" when disabled
+	Enabled        bool       `json:"enabled"`
+	Running        bool       `json:"running"`
+	NextRunAt      *time.Time `json:"next_run_at,omitempty"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastFinishedAt *time.Time `json:"last_finished_at,omitempty"`
+	LastError      string     `json:"last_error,omitempty"`
+}
+
+// RefreshJob reports the progress of a SWAPI -> cache refresh
+type RefreshJob struct {
+	ID                string            `json:"id" example:"6f1f1c0e-6d6b-4c8e-a7d5-1f4f7c1b2d3e"`
+	FilmID            int               `json:"film_id,omitempty" example:"1"` // 0 means all films
+	AllCharacters     bool              `json:"all_characters,omitempty"`      // recheck every character, not only those of changed films
+	Trigger           RefreshJobTrigger `json:"trigger" example:"manual" swaggertype:"string"`
+	State             RefreshJobState   `json:"state" example:"running" swaggertype:"string"`
+	Coalesced         bool              `json:"coalesced,omitempty"`     // the trigger joined an already running job
+	FencingToken      int64             `json:"fencing_token,omitempty"` // token of the refresh lock held by the job
+	StartedAt         time.Time         `json:"started_at"`
+	EndedAt           *time.Time        `json:"ended_at,omitempty"`
+	FilmsFetched      int               `json:"films_fetched"`
+	CharactersFetched int               `json:"characters_fetched"`
+	Errors            []string          `json:"errors,omitempty"`
+	SwapiBackends     []string          `json:"swapi_backends,omitempty" example:"swapi.dev"` // the swapi backends that served the refresh, in the order they first answered
+	Warnings          []DataWarning     `json:"warnings,omitempty"`                           // values swapi served malformed or unknown
+	Result            *RefreshResult    `json:"result,omitempty"`
+}
+
+// DataWarning flags a value of a film or character swapi served malformed, or
+// without a value
+type DataWarning struct {
+	Resource string `json:"resource" example:"https://swapi.dev/api/people/42/"` // swapi url
+	Field    string `json:"field" example:"height"`
+	Value    string `json:"value" example:"unknown"`
+	Problem  string `json:"problem" example:"unknown"` // "malformed" or "unknown"
+}
+
+// RefreshResult lists which films and characters a refresh cached
+type RefreshResult struct {
+	SucceededFilmIDs      []int `json:"succeeded_film_ids,omitempty"`
+	FailedFilmIDs         []int `json:"failed_film_ids,omitempty"`
+	SucceededCharacterIDs []int `json:"succeeded_character_ids,omitempty"`
+	FailedCharacterIDs    []int `json:"failed_character_ids,omitempty"`
+
+	Changes RefreshChanges `json:"changes"`
+}
+
+// RefreshChanges summarises which cached documents a refresh added, rewrote
+// because they were edited on swapi, or removed because they are gone upstream
+type RefreshChanges struct {
+	FilmsAdded        []int `json:"films_added,omitempty"`
+	FilmsUpdated      []int `json:"films_updated,omitempty"`
+	FilmsRemoved      []int `json:"films_removed,omitempty"`
+	CharactersAdded   []int `json:"characters_added,omitempty"`
+	CharactersUpdated []int `json:"characters_updated,omitempty"`
+	CharactersRemoved []int `json:"characters_removed,omitempty"`
+}
+
+func (r RefreshResult) HasFailures() bool {
+	return len(r.FailedFilmIDs) > 0 || len(r.FailedCharacterIDs) > 0
+}
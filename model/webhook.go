@@ -0,0 +1,130 @@
+package model
+
+import (
+	"time"
+
+	validation "github.com/go-ozzo/ozzo-validation/v4"
+	"github.com/go-ozzo/ozzo-validation/v4/is"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"gorm.io/gorm"
+)
+
+type WebhookEventType string
+
+const (
+	EventMovieAdded       WebhookEventType = "movie.added"
+	EventMovieUpdated     WebhookEventType = "movie.updated"
+	EventCharacterAdded   WebhookEventType = "character.added"
+	EventCharacterUpdated WebhookEventType = "character.updated"
+	EventCommentCreated   WebhookEventType = "comment.created"
+)
+
+// WebhookEventTypes lists every event a webhook can subscribe to
+var WebhookEventTypes = []WebhookEventType{
+	EventMovieAdded,
+	EventMovieUpdated,
+	EventCharacterAdded,
+	EventCharacterUpdated,
+	EventCommentCreated,
+}
+
+type WebhookDeliveryState string
+
+const (
+	WebhookDeliveryPending   WebhookDeliveryState = "pending"
+	WebhookDeliveryDelivered WebhookDeliveryState = "delivered"
+	WebhookDeliveryDead      WebhookDeliveryState = "dead" // gave up retrying, can be replayed
+)
+
+// RawJSON is a JSON document stored as text and marshalled as is
+type RawJSON string
+
+func (r RawJSON) MarshalJSON() ([]byte, error) {
+	if r == "" {
+		return []byte("null"), nil
+	}
+	return []byte(r), nil
+}
+
+type AddWebhookRequest struct {
+	URL    string   `json:"url" example:"https://example.com/hooks/movies"`
+	Events []string `json:"events" example:"movie.updated,comment.created"` // empty subscribes to every event
+	Secret string   `json:"secret" example:"s3cr3t"`                        // generated when empty
+}
+
+func (a AddWebhookRequest) Validate() error {
+	events := make([]interface{}, 0, len(WebhookEventTypes))
+	for _, event := range WebhookEventTypes {
+		events = append(events, string(event))
+	}
+
+	return validation.ValidateStruct(&a,
+		validation.Field(&a.URL, validation.Required, is.URL, validation.Length(0, 2048)),
+		validation.Field(&a.Events, validation.Each(validation.In(events...))),
+		validation.Field(&a.Secret, validation.Length(0, 256)))
+}
+
+// WebhookSubscription is a url notified of the events it subscribed to
+type WebhookSubscription struct {
+	ID        uuid.UUID      `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid();column:id"`
+	URL       string         `json:"url" gorm:"column:url;not null"`
+	Events    pq.StringArray `json:"events" gorm:"column:events;type:text[]" swaggertype:"array,string"` // empty means every event
+	Secret    string         `json:"secret,omitempty" gorm:"column:secret;not null"`                     // only returned on creation
+	CreatedAt time.Time      `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp"`
+	DeletedAt gorm.DeletedAt `json:"-" gorm:"column:deleted_at" swaggerignore:"true"`
+}
+
+func (WebhookSubscription) TableName() string {
+	return "webhook_subscription"
+}
+
+// Subscribed reports whether the subscription wants events of type t
+func (w WebhookSubscription) Subscribed(t WebhookEventType) bool {
+	if len(w.Events) == 0 {
+		return true
+	}
+
+	for _, event := range w.Events {
+		if event == string(t) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// WebhookEvent is the body posted to webhooks
+type WebhookEvent struct {
+	ID        uuid.UUID        `json:"id"` // the same for every subscription, use it to deduplicate
+	Type      WebhookEventType `json:"type" swaggertype:"string"`
+	CreatedAt time.Time        `json:"created_at"`
+	Data      interface{}      `json:"data" swaggertype:"object"`
+}
+
+// CommentEventData is the data of a comment.created event
+type CommentEventData struct {
+	ID        uuid.UUID `json:"id"`
+	MovieID   int       `json:"movie_id"`
+	Message   string    `json:"message"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// WebhookDelivery is an event to post, or posted, to one subscription
+type WebhookDelivery struct {
+	ID             uuid.UUID            `json:"id" gorm:"primaryKey;type:uuid;default:gen_random_uuid();column:id"`
+	SubscriptionID uuid.UUID            `json:"subscription_id" gorm:"column:subscription_id;type:uuid;not null"`
+	EventID        uuid.UUID            `json:"event_id" gorm:"column:event_id;type:uuid;not null"`
+	EventType      WebhookEventType     `json:"event_type" gorm:"column:event_type;not null" swaggertype:"string"`
+	Payload        RawJSON              `json:"payload" gorm:"column:payload;type:jsonb;not null" swaggertype:"object"`
+	State          WebhookDeliveryState `json:"state" gorm:"column:state;not null" swaggertype:"string"`
+	Attempts       int                  `json:"attempts" gorm:"column:attempts;not null"`
+	LastError      string               `json:"last_error,omitempty" gorm:"column:last_error"`
+	NextAttemptAt  time.Time            `json:"next_attempt_at" gorm:"column:next_attempt_at;not null"`
+	CreatedAt      time.Time            `json:"created_at" gorm:"column:created_at;not null;default:current_timestamp"`
+	DeliveredAt    *time.Time           `json:"delivered_at,omitempty" gorm:"column:delivered_at"`
+}
+
+func (WebhookDelivery) TableName() string {
+	return "webhook_delivery"
+}
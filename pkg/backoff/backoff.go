@@ -0,0 +1,190 @@
+package backoff
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"time"
+
+	"github.com/iamnator/movie-api/pkg/clock"
+)
+
+const (
+	DefaultInitialInterval     = 500 * time.Millisecond
+	DefaultMaxInterval         = time.Minute
+	DefaultMultiplier          = 2.0
+	DefaultRandomizationFactor = 0.5
+)
+
+type (
+	// Policy describes how an operation is retried.
+	//
+	// The delay before retry n (starting at 1) is
+	// InitialInterval * Multiplier^(n-1), capped at MaxInterval, and then
+	// spread by ±RandomizationFactor to avoid synchronised retries.
+	Policy struct {
+		InitialInterval     time.Duration
+		MaxInterval         time.Duration
+		Multiplier          float64
+		RandomizationFactor float64
+
+		// MaxAttempts is the total number of calls to the operation, 0 means unlimited
+		MaxAttempts int
+		// MaxElapsedTime bounds the whole retry loop, 0 means unlimited
+		MaxElapsedTime time.Duration
+
+		// Clock defaults to clock.System
+		Clock clock.Clock
+		// Rand returns a number in [0, 1); defaults to math/rand
+		Rand func() float64
+
+		// OnRetry is called before sleeping for delay after a failed attempt
+		OnRetry func(attempt int, delay time.Duration, err error)
+		// OnGiveUp is called once when the policy stops retrying
+		OnGiveUp func(attempts int, err error)
+	}
+
+	permanentError struct {
+		err error
+	}
+
+	// delayHint is implemented by errors telling how long to wait before
+	// retrying, such as a 429 response with a Retry-After header
+	delayHint interface {
+		RetryDelay() time.Duration
+	}
+)
+
+// ErrExhausted is wrapped by the error returned from Retry when the
+// attempt or elapsed time budget is used up.
+var ErrExhausted = errors.New("retry budget exhausted")
+
+// DefaultPolicy returns a policy with sensible defaults and no attempt limit.
+func DefaultPolicy() Policy {
+	return Policy{
+		InitialInterval:     DefaultInitialInterval,
+		MaxInterval:         DefaultMaxInterval,
+		Multiplier:          DefaultMultiplier,
+		RandomizationFactor: DefaultRandomizationFactor,
+	}
+}
+
+// Permanent wraps err so that Retry returns it immediately.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &permanentError{err: err}
+}
+
+func (p *permanentError) Error() string {
+	return p.err.Error()
+}
+
+func (p *permanentError) Unwrap() error {
+	return p.err
+}
+
+func (p Policy) clock() clock.Clock {
+	if p.Clock == nil {
+		return clock.System
+	}
+	return p.Clock
+}
+
+func (p Policy) random() float64 {
+	if p.Rand == nil {
+		return rand.Float64()
+	}
+	return p.Rand()
+}
+
+// Delay returns the jittered delay to wait after the given failed attempt.
+func (p Policy) Delay(attempt int) time.Duration {
+	if attempt < 1 {
+		attempt = 1
+	}
+
+	multiplier := p.Multiplier
+	if multiplier < 1 {
+		multiplier = 1
+	}
+
+	base := float64(p.InitialInterval) * math.Pow(multiplier, float64(attempt-1))
+	if p.MaxInterval > 0 && base > float64(p.MaxInterval) {
+		base = float64(p.MaxInterval)
+	}
+
+	factor := p.RandomizationFactor
+	if factor <= 0 {
+		return time.Duration(base)
+	}
+	if factor > 1 {
+		factor = 1
+	}
+
+	// spread the delay uniformly over [base*(1-factor), base*(1+factor))
+	delta := factor * base
+	return time.Duration(base - delta + p.random()*2*delta)
+}
+
+// Retry calls op until it succeeds, returns a Permanent error, the context is
+// done, or the policy's attempt/elapsed budget is used up. An error with a
+// RetryDelay() time.Duration method, like a throttled response, delays the
+// next attempt at least that long.
+func (p Policy) Retry(ctx context.Context, op func(ctx context.Context) error) error {
+	clock := p.clock()
+	start := clock.Now()
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		if err = op(ctx); err == nil {
+			return nil
+		}
+
+		var permanent *permanentError
+		if errors.As(err, &permanent) {
+			p.giveUp(attempt, permanent.err)
+			return permanent.err
+		}
+
+		if ctx.Err() != nil {
+			p.giveUp(attempt, err)
+			return ctx.Err()
+		}
+
+		if p.MaxAttempts > 0 && attempt >= p.MaxAttempts {
+			p.giveUp(attempt, err)
+			return fmt.Errorf("%w after %d attempts: %v", ErrExhausted, attempt, err)
+		}
+
+		delay := p.Delay(attempt)
+		var hint delayHint
+		if errors.As(err, &hint) && hint.RetryDelay() > delay {
+			delay = hint.RetryDelay()
+		}
+		if p.MaxElapsedTime > 0 && clock.Now().Add(delay).Sub(start) > p.MaxElapsedTime {
+			p.giveUp(attempt, err)
+			return fmt.Errorf("%w after %s: %v", ErrExhausted, clock.Now().Sub(start), err)
+		}
+
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, delay, err)
+		}
+
+		select {
+		case <-ctx.Done():
+			p.giveUp(attempt, err)
+			return ctx.Err()
+		case <-clock.After(delay):
+		}
+	}
+}
+
+func (p Policy) giveUp(attempts int, err error) {
+	if p.OnGiveUp != nil {
+		p.OnGiveUp(attempts, err)
+	}
+}
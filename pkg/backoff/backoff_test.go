@@ -0,0 +1,232 @@
+package backoff_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/iamnator/movie-api/pkg/backoff"
+)
+
+// fakeClock advances instantly whenever After is called and records the
+// requested delays.
+type fakeClock struct {
+	now    time.Time
+	delays []time.Duration
+}
+
+func (f *fakeClock) Now() time.Time {
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.delays = append(f.delays, d)
+	f.now = f.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- f.now
+	return ch
+}
+
+func newPolicy(clock *fakeClock) backoff.Policy {
+	return backoff.Policy{
+		InitialInterval: time.Second,
+		MaxInterval:     10 * time.Second,
+		Multiplier:      2,
+		Clock:           clock,
+	}
+}
+
+func Test_Policy_Delay(t *testing.T) {
+	p := newPolicy(&fakeClock{})
+
+	tests := []struct {
+		Attempt int
+		Want    time.Duration
+	}{
+		{Attempt: 1, Want: time.Second},
+		{Attempt: 2, Want: 2 * time.Second},
+		{Attempt: 3, Want: 4 * time.Second},
+		{Attempt: 4, Want: 8 * time.Second},
+		{Attempt: 5, Want: 10 * time.Second},
+		{Attempt: 50, Want: 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		if got := p.Delay(tt.Attempt); got != tt.Want {
+			t.Errorf("attempt=%d | delay_gotten=%v | delay_expected=%v", tt.Attempt, got, tt.Want)
+		}
+	}
+}
+
+func Test_Policy_DelayJitter(t *testing.T) {
+	p := newPolicy(&fakeClock{})
+	p.RandomizationFactor = 0.5
+
+	tests := []struct {
+		Rand float64
+		Want time.Duration
+	}{
+		{Rand: 0, Want: 2 * time.Second},
+		{Rand: 0.5, Want: 4 * time.Second},
+		{Rand: 0.75, Want: 5 * time.Second},
+	}
+
+	for _, tt := range tests {
+		r := tt.Rand
+		p.Rand = func() float64 { return r }
+		if got := p.Delay(3); got != tt.Want {
+			t.Errorf("rand=%v | delay_gotten=%v | delay_expected=%v", tt.Rand, got, tt.Want)
+		}
+	}
+}
+
+func Test_Policy_RetryUntilSuccess(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	p := newPolicy(clock)
+
+	var retries int
+	p.OnRetry = func(attempt int, delay time.Duration, err error) {
+		retries++
+	}
+
+	var calls int
+	err := p.Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		if calls < 4 {
+			return errors.New("swapi is down")
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if calls != 4 || retries != 3 {
+		t.Errorf("calls=%d | retries=%d", calls, retries)
+	}
+
+	want := []time.Duration{time.Second, 2 * time.Second, 4 * time.Second}
+	if len(clock.delays) != len(want) {
+		t.Fatalf("delays=%v | expected=%v", clock.delays, want)
+	}
+	for i := range want {
+		if clock.delays[i] != want[i] {
+			t.Errorf("delays=%v | expected=%v", clock.delays, want)
+			break
+		}
+	}
+}
+
+func Test_Policy_MaxAttempts(t *testing.T) {
+	p := newPolicy(&fakeClock{})
+	p.MaxAttempts = 3
+
+	var gaveUp int
+	p.OnGiveUp = func(attempts int, err error) {
+		gaveUp = attempts
+	}
+
+	var calls int
+	err := p.Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errors.New("swapi is down")
+	})
+
+	if !errors.Is(err, backoff.ErrExhausted) {
+		t.Errorf("error=%v | expected=%v", err, backoff.ErrExhausted)
+	}
+	if calls != 3 || gaveUp != 3 {
+		t.Errorf("calls=%d | gave_up_after=%d", calls, gaveUp)
+	}
+}
+
+func Test_Policy_MaxElapsedTime(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	p := newPolicy(clock)
+	p.MaxElapsedTime = 5 * time.Second
+
+	var calls int
+	err := p.Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		return errors.New("swapi is down")
+	})
+
+	if !errors.Is(err, backoff.ErrExhausted) {
+		t.Errorf("error=%v | expected=%v", err, backoff.ErrExhausted)
+	}
+
+	// slept 1s and 2s, the next 4s delay would exceed the 5s budget
+	if calls != 3 {
+		t.Errorf("calls=%d | expected=3", calls)
+	}
+}
+
+// throttledError asks to wait before retrying, like a 429 with Retry-After
+type throttledError time.Duration
+
+func (e throttledError) Error() string {
+	return "too many requests"
+}
+
+func (e throttledError) RetryDelay() time.Duration {
+	return time.Duration(e)
+}
+
+func Test_Policy_RetryDelayHint(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	p := newPolicy(clock)
+
+	var calls int
+	err := p.Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		switch calls {
+		case 1:
+			return throttledError(30 * time.Second) // longer than the policy's delay, and MaxInterval
+		case 2:
+			return throttledError(time.Millisecond) // shorter, the policy's delay wins
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []time.Duration{30 * time.Second, 2 * time.Second}
+	if len(clock.delays) != len(want) || clock.delays[0] != want[0] || clock.delays[1] != want[1] {
+		t.Errorf("delays=%v | expected=%v", clock.delays, want)
+	}
+}
+
+func Test_Policy_Permanent(t *testing.T) {
+	p := newPolicy(&fakeClock{})
+
+	notFound := errors.New("not found")
+
+	var calls int
+	err := p.Retry(context.Background(), func(ctx context.Context) error {
+		calls++
+		return backoff.Permanent(notFound)
+	})
+
+	if err != notFound || calls != 1 {
+		t.Errorf("error=%v | calls=%d", err, calls)
+	}
+}
+
+func Test_Policy_ContextCancelled(t *testing.T) {
+	p := newPolicy(&fakeClock{})
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	var calls int
+	err := p.Retry(ctx, func(ctx context.Context) error {
+		calls++
+		cancel()
+		return errors.New("swapi is down")
+	})
+
+	if err != context.Canceled || calls != 1 {
+		t.Errorf("error=%v | calls=%d", err, calls)
+	}
+}
@@ -0,0 +1,49 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/rs/zerolog/log"
+)
+
+var cacheDocumentsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "cache_documents"),
+	"Documents in each cache index.",
+	[]string{"index"}, nil,
+)
+
+// cacheCountTimeout keeps a slow cache from stalling the whole scrape
+const cacheCountTimeout = 5 * time.Second
+
+// cacheCollector reads the document counts of the cache indexes at scrape time
+type cacheCollector struct {
+	count func(ctx context.Context) (map[string]int64, error)
+}
+
+// RegisterCacheDocuments exposes the document count of every cache index,
+// read with count on each scrape
+func RegisterCacheDocuments(count func(ctx context.Context) (map[string]int64, error)) error {
+	return Registry.Register(cacheCollector{count: count})
+}
+
+func (c cacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- cacheDocumentsDesc
+}
+
+func (c cacheCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), cacheCountTimeout)
+	defer cancel()
+
+	counts, err := c.count(ctx)
+	if err != nil {
+		// the other metrics are still worth scraping
+		log.Error().Err(err).Msg("error counting cache documents")
+		return
+	}
+
+	for index, count := range counts {
+		ch <- prometheus.MustNewConstMetric(cacheDocumentsDesc, prometheus.GaugeValue, float64(count), index)
+	}
+}
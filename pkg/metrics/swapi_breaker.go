@@ -0,0 +1,41 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var swapiBreakerStateDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "swapi_circuit_breaker_state"),
+	"State of the circuit breaker guarding swapi, 1 for the current state.",
+	[]string{"state"}, nil,
+)
+
+// swapiBreakerStates are the states reported, every one of them is exported
+// so a state left reads 0
+var swapiBreakerStates = []string{"closed", "open", "half-open"}
+
+// swapiBreakerCollector reads the state of the breaker at scrape time
+type swapiBreakerCollector struct {
+	state func() string
+}
+
+// RegisterSwapiBreaker exposes the state of the swapi circuit breaker, read
+// with state on each scrape
+func RegisterSwapiBreaker(state func() string) error {
+	return Registry.Register(swapiBreakerCollector{state: state})
+}
+
+func (c swapiBreakerCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- swapiBreakerStateDesc
+}
+
+func (c swapiBreakerCollector) Collect(ch chan<- prometheus.Metric) {
+	current := c.state()
+	for _, state := range swapiBreakerStates {
+		var value float64
+		if state == current {
+			value = 1
+		}
+		ch <- prometheus.MustNewConstMetric(swapiBreakerStateDesc, prometheus.GaugeValue, value, state)
+	}
+}
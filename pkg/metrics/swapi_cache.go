@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var swapiCacheRequestsDesc = prometheus.NewDesc(
+	prometheus.BuildFQName(namespace, "", "swapi_cache_requests_total"),
+	"Requests of the swapi client, by how its response cache answered them: hit, revalidated (304 not modified) or miss.",
+	[]string{"result"}, nil,
+)
+
+// swapiCacheCollector reads the counts of the swapi client at scrape time
+type swapiCacheCollector struct {
+	stats func() (hits, revalidated, misses int64)
+}
+
+// RegisterSwapiCache exposes the hits, revalidations and misses of the swapi
+// client's response cache, read with stats on each scrape
+func RegisterSwapiCache(stats func() (hits, revalidated, misses int64)) error {
+	return Registry.Register(swapiCacheCollector{stats: stats})
+}
+
+func (c swapiCacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- swapiCacheRequestsDesc
+}
+
+func (c swapiCacheCollector) Collect(ch chan<- prometheus.Metric) {
+	hits, revalidated, misses := c.stats()
+
+	ch <- prometheus.MustNewConstMetric(swapiCacheRequestsDesc, prometheus.CounterValue, float64(hits), "hit")
+	ch <- prometheus.MustNewConstMetric(swapiCacheRequestsDesc, prometheus.CounterValue, float64(revalidated), "revalidated")
+	ch <- prometheus.MustNewConstMetric(swapiCacheRequestsDesc, prometheus.CounterValue, float64(misses), "miss")
+}
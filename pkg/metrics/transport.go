@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// swapiTransport counts and times the requests sent to swapi
+type swapiTransport struct {
+	next http.RoundTripper
+}
+
+// InstrumentSwapi wraps the transport of the swapi http client, next defaults
+// to http.DefaultTransport
+func InstrumentSwapi(next http.RoundTripper) http.RoundTripper {
+	if next == nil {
+		next = http.DefaultTransport
+	}
+	return swapiTransport{next: next}
+}
+
+func (t swapiTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := t.next.RoundTrip(req)
+
+	status := "error"
+	if err == nil {
+		status = strconv.Itoa(resp.StatusCode)
+	}
+
+	swapiRequests.WithLabelValues(status).Inc()
+	swapiRequestDuration.WithLabelValues(status).Observe(time.Since(start).Seconds())
+
+	return resp, err
+}
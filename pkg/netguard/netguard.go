@@ -0,0 +1,82 @@
+// Package netguard keeps outgoing requests to user supplied urls, such as
+// webhooks, off the loopback, link-local and private networks the api runs in.
+package netguard
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+	"syscall"
+)
+
+// ErrForbiddenTarget is returned for urls and addresses that must not be reached
+var ErrForbiddenTarget = errors.New("target is not a public http(s) address")
+
+// Allowed reports whether ip is a public unicast address
+func Allowed(ip net.IP) bool {
+	return ip != nil &&
+		!ip.IsLoopback() &&
+		!ip.IsPrivate() &&
+		!ip.IsLinkLocalUnicast() &&
+		!ip.IsLinkLocalMulticast() &&
+		!ip.IsInterfaceLocalMulticast() &&
+		!ip.IsMulticast() &&
+		!ip.IsUnspecified()
+}
+
+// CheckURL rejects urls that are not http(s) or whose host is, or resolves to,
+// an address Allowed refuses. Hosts that do not resolve yet are let through,
+// the address is checked again when it is dialed, see Control.
+func CheckURL(ctx context.Context, rawURL string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrForbiddenTarget, err)
+	}
+	if u.Scheme != "http" && u.Scheme != "https" {
+		return fmt.Errorf("%w: scheme %q", ErrForbiddenTarget, u.Scheme)
+	}
+
+	host := u.Hostname()
+	if host == "" {
+		return fmt.Errorf("%w: no host", ErrForbiddenTarget)
+	}
+	if host = strings.ToLower(strings.TrimSuffix(host, ".")); host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+	}
+
+	if ip := net.ParseIP(host); ip != nil {
+		if !Allowed(ip) {
+			return fmt.Errorf("%w: %s", ErrForbiddenTarget, ip)
+		}
+		return nil
+	}
+
+	addrs, err := net.DefaultResolver.LookupIPAddr(ctx, host)
+	if err != nil {
+		return nil
+	}
+	for _, addr := range addrs {
+		if !Allowed(addr.IP) {
+			return fmt.Errorf("%w: %s resolves to %s", ErrForbiddenTarget, host, addr.IP)
+		}
+	}
+
+	return nil
+}
+
+// Control is a net.Dialer Control refusing connections to addresses Allowed
+// refuses. It runs once the host is resolved, so a name pointed at a private
+// address after CheckURL passed is still refused.
+func Control(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	if ip := net.ParseIP(host); !Allowed(ip) {
+		return fmt.Errorf("%w: %s", ErrForbiddenTarget, host)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package netguard_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/iamnator/movie-api/pkg/netguard"
+)
+
+func Test_CheckURL(t *testing.T) {
+	tests := []struct {
+		URL       string
+		Forbidden bool
+	}{
+		{URL: "https://93.184.216.34/hooks", Forbidden: false},
+		{URL: "http://[2606:2800:220:1:248:1893:25c8:1946]:8080/", Forbidden: false},
+		{URL: "ftp://93.184.216.34/", Forbidden: true},
+		{URL: "file:///etc/passwd", Forbidden: true},
+		{URL: "gopher://example.com/", Forbidden: true},
+		{URL: "http://localhost:6379/", Forbidden: true},
+		{URL: "http://api.localhost/", Forbidden: true},
+		{URL: "http://127.0.0.1/", Forbidden: true},
+		{URL: "http://[::1]/", Forbidden: true},
+		{URL: "http://0.0.0.0/", Forbidden: true},
+		{URL: "http://169.254.169.254/latest/meta-data/", Forbidden: true},
+		{URL: "http://10.0.0.7/", Forbidden: true},
+		{URL: "http://172.16.3.1/", Forbidden: true},
+		{URL: "http://192.168.1.1/", Forbidden: true},
+		{URL: "http://[fd00::1]/", Forbidden: true},
+		{URL: "http://[::ffff:127.0.0.1]/", Forbidden: true},
+	}
+
+	for _, tt := range tests {
+		err := netguard.CheckURL(context.Background(), tt.URL)
+		if forbidden := errors.Is(err, netguard.ErrForbiddenTarget); forbidden != tt.Forbidden {
+			t.Errorf("url=%s | err=%v | forbidden_expected=%v", tt.URL, err, tt.Forbidden)
+		}
+	}
+}
+
+func Test_Control(t *testing.T) {
+	tests := []struct {
+		Address   string
+		Forbidden bool
+	}{
+		{Address: "93.184.216.34:443", Forbidden: false},
+		{Address: "127.0.0.1:80", Forbidden: true},
+		{Address: "[::1]:80", Forbidden: true},
+		{Address: "10.1.2.3:443", Forbidden: true},
+		{Address: "169.254.169.254:80", Forbidden: true},
+	}
+
+	for _, tt := range tests {
+		err := netguard.Control("tcp", tt.Address, nil)
+		if forbidden := errors.Is(err, netguard.ErrForbiddenTarget); forbidden != tt.Forbidden {
+			t.Errorf("address=%s | err=%v | forbidden_expected=%v", tt.Address, err, tt.Forbidden)
+		}
+	}
+}
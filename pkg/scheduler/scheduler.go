@@ -0,0 +1,197 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/robfig/cron/v3"
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/pkg/clock"
+)
+
+// Disabled is the spec that turns a job off on this instance
+const Disabled = "off"
+
+var ErrJobExists = errors.New("job is already registered")
+
+type (
+	// Scheduler runs named jobs on cron schedules.
+	Scheduler struct {
+		clock clock.Clock
+
+		mu      sync.Mutex
+		jobs    map[string]*job
+		started bool
+	}
+
+	// Status is a snapshot of a job's schedule and last run
+	Status struct {
+		Name           string
+		Spec           string
+		Enabled        bool
+		Running        bool
+		NextRunAt      *time.Time
+		LastRunAt      *time.Time
+		LastFinishedAt *time.Time
+		LastError      string
+	}
+
+	job struct {
+		name     string
+		spec     string
+		schedule cron.Schedule // nil when disabled
+		run      func(ctx context.Context) error
+
+		running        bool
+		nextRunAt      time.Time
+		lastRunAt      time.Time
+		lastFinishedAt time.Time
+		lastErr        error
+	}
+)
+
+// New returns a scheduler driven by c, clock.System if nil.
+func New(c clock.Clock) *Scheduler {
+	if c == nil {
+		c = clock.System
+	}
+
+	return &Scheduler{
+		clock: c,
+		jobs:  make(map[string]*job),
+	}
+}
+
+// Register adds a job running on spec, a standard 5 field cron expression or
+// a descriptor such as "@hourly" or "@every 3h". A spec of Disabled registers
+// the job without ever running it.
+func (s *Scheduler) Register(name, spec string, run func(ctx context.Context) error) error {
+	j := &job{
+		name: name,
+		spec: spec,
+		run:  run,
+	}
+
+	if spec != Disabled {
+		schedule, err := cron.ParseStandard(spec)
+		if err != nil {
+			return fmt.Errorf("invalid schedule %q for job %s: %w", spec, name, err)
+		}
+		j.schedule = schedule
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.started {
+		return errors.New("scheduler already started")
+	}
+
+	if _, ok := s.jobs[name]; ok {
+		return fmt.Errorf("%w: %s", ErrJobExists, name)
+	}
+
+	s.jobs[name] = j
+	return nil
+}
+
+// Start runs every enabled job on its schedule until ctx is done.
+func (s *Scheduler) Start(ctx context.Context) {
+	s.mu.Lock()
+	s.started = true
+	jobs := make([]*job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		jobs = append(jobs, j)
+	}
+	s.mu.Unlock()
+
+	for _, j := range jobs {
+		if j.schedule == nil {
+			log.Info().Str("job", j.name).Msg("scheduled job disabled")
+			continue
+		}
+		go s.loop(ctx, j)
+	}
+}
+
+func (s *Scheduler) loop(ctx context.Context, j *job) {
+	for {
+		now := s.clock.Now()
+		next := j.schedule.Next(now)
+
+		s.mu.Lock()
+		j.nextRunAt = next
+		s.mu.Unlock()
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(next.Sub(now)):
+		}
+
+		s.runJob(ctx, j)
+	}
+}
+
+func (s *Scheduler) runJob(ctx context.Context, j *job) {
+	s.mu.Lock()
+	j.running = true
+	j.lastRunAt = s.clock.Now()
+	s.mu.Unlock()
+
+	log.Info().Str("job", j.name).Msg("running scheduled job ...")
+
+	err := j.run(ctx)
+	if err != nil {
+		log.Error().Err(err).Str("job", j.name).Msg("error running scheduled job")
+	} else {
+		log.Info().Str("job", j.name).Msg("scheduled job ran successfully")
+	}
+
+	s.mu.Lock()
+	j.running = false
+	j.lastFinishedAt = s.clock.Now()
+	j.lastErr = err
+	s.mu.Unlock()
+}
+
+// Status returns every registered job sorted by name.
+func (s *Scheduler) Status() []Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	statuses := make([]Status, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		status := Status{
+			Name:           j.name,
+			Spec:           j.spec,
+			Enabled:        j.schedule != nil,
+			Running:        j.running,
+			NextRunAt:      timePtr(j.nextRunAt),
+			LastRunAt:      timePtr(j.lastRunAt),
+			LastFinishedAt: timePtr(j.lastFinishedAt),
+		}
+		if j.lastErr != nil {
+			status.LastError = j.lastErr.Error()
+		}
+		statuses = append(statuses, status)
+	}
+
+	sort.Slice(statuses, func(i, k int) bool {
+		return statuses[i].Name < statuses[k].Name
+	})
+
+	return statuses
+}
+
+func timePtr(t time.Time) *time.Time {
+	if t.IsZero() {
+		return nil
+	}
+	return &t
+}
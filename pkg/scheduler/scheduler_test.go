@@ -0,0 +1,170 @@
+package scheduler_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/iamnator/movie-api/pkg/scheduler"
+)
+
+// fakeClock only moves when Advance is called
+type fakeClock struct {
+	mu      sync.Mutex
+	now     time.Time
+	waiters []waiter
+	added   chan struct{}
+}
+
+type waiter struct {
+	at time.Time
+	ch chan time.Time
+}
+
+func newFakeClock(now time.Time) *fakeClock {
+	return &fakeClock{now: now, added: make(chan struct{}, 100)}
+}
+
+func (f *fakeClock) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+func (f *fakeClock) After(d time.Duration) <-chan time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	ch := make(chan time.Time, 1)
+	f.waiters = append(f.waiters, waiter{at: f.now.Add(d), ch: ch})
+	f.added <- struct{}{}
+	return ch
+}
+
+// Advance moves the clock forward and fires every timer that is due
+func (f *fakeClock) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.now = f.now.Add(d)
+
+	var pending []waiter
+	for _, w := range f.waiters {
+		if w.at.After(f.now) {
+			pending = append(pending, w)
+			continue
+		}
+		w.ch <- f.now
+	}
+	f.waiters = pending
+}
+
+// waitForTimer blocks until a job loop is waiting on the clock
+func (f *fakeClock) waitForTimer(t *testing.T) {
+	t.Helper()
+
+	select {
+	case <-f.added:
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for the scheduler to wait on the clock")
+	}
+}
+
+func Test_Scheduler_RunsOnSchedule(t *testing.T) {
+	start := time.Date(2023, 3, 1, 10, 15, 0, 0, time.UTC)
+	clock := newFakeClock(start)
+
+	s := scheduler.New(clock)
+
+	ran := make(chan struct{}, 1)
+	if err := s.Register("films", "0 * * * *", func(ctx context.Context) error {
+		ran <- struct{}{}
+		return errors.New("swapi is down")
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	s.Start(ctx)
+	clock.waitForTimer(t)
+
+	status := s.Status()[0]
+	if status.NextRunAt == nil || !status.NextRunAt.Equal(start.Add(45*time.Minute)) {
+		t.Errorf("next_run_at=%v | expected=%v", status.NextRunAt, start.Add(45*time.Minute))
+	}
+	if status.LastRunAt != nil {
+		t.Errorf("last_run_at=%v | expected no run yet", status.LastRunAt)
+	}
+
+	clock.Advance(44 * time.Minute)
+	select {
+	case <-ran:
+		t.Fatal("job ran before its schedule")
+	default:
+	}
+
+	clock.Advance(time.Minute)
+	<-ran
+	clock.waitForTimer(t)
+
+	status = s.Status()[0]
+	if status.LastRunAt == nil || !status.LastRunAt.Equal(start.Add(45*time.Minute)) {
+		t.Errorf("last_run_at=%v | expected=%v", status.LastRunAt, start.Add(45*time.Minute))
+	}
+	if status.NextRunAt == nil || !status.NextRunAt.Equal(start.Add(105*time.Minute)) {
+		t.Errorf("next_run_at=%v | expected=%v", status.NextRunAt, start.Add(105*time.Minute))
+	}
+	if status.LastError != "swapi is down" {
+		t.Errorf("last_error=%q | expected the job error", status.LastError)
+	}
+}
+
+func Test_Scheduler_Disabled(t *testing.T) {
+	clock := newFakeClock(time.Date(2023, 3, 1, 10, 15, 0, 0, time.UTC))
+	s := scheduler.New(clock)
+
+	if err := s.Register("ip_anonymisation", scheduler.Disabled, func(ctx context.Context) error {
+		t.Error("disabled job ran")
+		return nil
+	}); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	s.Start(ctx)
+
+	clock.Advance(24 * time.Hour)
+
+	status := s.Status()[0]
+	if status.Enabled || status.NextRunAt != nil {
+		t.Errorf("unexpected status for a disabled job: %+v", status)
+	}
+}
+
+func Test_Scheduler_Register(t *testing.T) {
+	s := scheduler.New(nil)
+	noop := func(ctx context.Context) error { return nil }
+
+	tests := []struct {
+		Name    string
+		Spec    string
+		WantErr bool
+	}{
+		{Name: "films", Spec: "0 */3 * * *"},
+		{Name: "characters", Spec: "@every 6h"},
+		{Name: "comment_counts", Spec: "every minute", WantErr: true},
+		{Name: "films", Spec: "@daily", WantErr: true},
+	}
+
+	for _, tt := range tests {
+		err := s.Register(tt.Name, tt.Spec, noop)
+		if (err != nil) != tt.WantErr {
+			t.Errorf("name=%s | spec=%s | error=%v | want_error=%v", tt.Name, tt.Spec, err, tt.WantErr)
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"io"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/adapter/cache"
+	"github.com/iamnator/movie-api/adapter/instrument"
+	"github.com/iamnator/movie-api/adapter/lock"
+	"github.com/iamnator/movie-api/adapter/repository"
+	"github.com/iamnator/movie-api/adapter/webhook"
+	"github.com/iamnator/movie-api/config"
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service"
+)
+
+// refresh refreshes the cache once and prints the finished job. It takes the
+// refresh lock like the instances serving the api, so it is skipped while one
+// of them refreshes; webhook deliveries it queues are sent by them.
+func refresh(ctx context.Context, cfg config.Config, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("refresh", flag.ContinueOnError)
+	filmID := fs.Int("film", 0, "swapi id of the only film to refresh, 0 refreshes every film")
+	if positional, err := parseArgs(fs, args); err != nil || len(positional) > 0 {
+		return errUsage
+	}
+
+	redisClient, err := openRedis(ctx, cfg.Redis.URL)
+	if err != nil {
+		return err
+	}
+	defer closeRedis(redisClient)
+
+	db, err := repository.Open(cfg.Postgres.URL)
+	if err != nil {
+		return err
+	}
+	defer closePostgres(db)
+
+	// the documents cached are kept, the refresh only rewrites those changed
+	redisCache, err := cache.OpenRedisCache(redisClient)
+	if err != nil {
+		return err
+	}
+	defer redisCache.Close()
+
+	locker := lock.NewRedisLocker(redisClient)
+
+	// next to the lock, so any instance reports a job whichever ran it
+	jobStore := lock.NewRedisJobStore(redisClient)
+
+	commentRepo := repository.NewPgxCommentRepository(db)
+	webhookRepo := repository.NewPgxWebhookRepository(db)
+
+	swapiClient, _, err := newSwapi(cfg, redisClient)
+	if err != nil {
+		return err
+	}
+
+	srv := instrument.Services(service.NewServices(instrument.Cache(redisCache), instrument.CommentRepository(commentRepo), swapiClient,
+		service.WithoutBackgroundJobs(),
+		service.WithLocker(locker),
+		service.WithJobStore(jobStore),
+		service.WithRefreshTimeout(cfg.Refresh.Timeout.Std()),
+		service.WithWebhooks(instrument.WebhookRepository(webhookRepo), webhook.NewHTTPSender(nil)),
+		service.WithSwapiTracker(swapiClient),
+	))
+
+	job, err := srv.RunRefresh(ctx, *filmID)
+
+	stats := swapiClient.CacheStats()
+	log.Info().Int64("hits", stats.Hits).Int64("revalidated", stats.Revalidated).Int64("misses", stats.Misses).
+		Msg("swapi response cache")
+	if job != nil {
+		enc := json.NewEncoder(stdout)
+		enc.SetIndent("", "  ")
+		if er := enc.Encode(job); er != nil {
+			return er
+		}
+	}
+
+	// scripts checking the exit code learn of a partial refresh too
+	if err == nil && job.State == model.RefreshJobPartial {
+		return errors.New("some films or characters could not be cached")
+	}
+
+	return err
+}
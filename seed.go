@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/iamnator/movie-api/adapter/cache"
+	"github.com/iamnator/movie-api/adapter/repository"
+	"github.com/iamnator/movie-api/config"
+	"github.com/iamnator/movie-api/database"
+	"github.com/iamnator/movie-api/model"
+)
+
+// fixtures is the data loaded by seed, in the shape of the cache and database
+type fixtures struct {
+	Movies     []model.MovieDetails `json:"movies"`
+	Characters []model.Character    `json:"characters"` // by their movie_id
+	Comments   []model.Comment      `json:"comments"`
+}
+
+// seed loads fixtures into the cache and the database, by default those
+// bundled in database/seed. Seeding twice is harmless: documents are
+// overwritten and repeated comments are saved once.
+func seed(ctx context.Context, cfg config.Config, args []string, stdout io.Writer) error {
+	fs := flag.NewFlagSet("seed", flag.ContinueOnError)
+	file := fs.String("file", "", "json fixtures file, the bundled fixtures when empty")
+	if positional, err := parseArgs(fs, args); err != nil || len(positional) > 0 {
+		return errUsage
+	}
+
+	data := database.Fixtures()
+	if *file != "" {
+		var err error
+		if data, err = os.ReadFile(*file); err != nil {
+			return err
+		}
+	}
+
+	f, err := parseFixtures(data)
+	if err != nil {
+		return err
+	}
+
+	redisClient, err := openRedis(ctx, cfg.Redis.URL)
+	if err != nil {
+		return err
+	}
+	defer closeRedis(redisClient)
+
+	db, err := repository.Open(cfg.Postgres.URL)
+	if err != nil {
+		return err
+	}
+	defer closePostgres(db)
+
+	redisCache, err := cache.OpenRedisCache(redisClient)
+	if err != nil {
+		return err
+	}
+	defer redisCache.Close()
+
+	commentRepo := repository.NewPgxCommentRepository(db)
+
+	if len(f.Movies) > 0 {
+		if err := redisCache.SetMovies(ctx, 0, f.Movies); err != nil {
+			return fmt.Errorf("error caching movies: %w", err)
+		}
+	}
+
+	for movieID, characters := range f.charactersByMovie() {
+		if err := redisCache.SetCharactersByMovieID(ctx, 0, movieID, characters); err != nil {
+			return fmt.Errorf("error caching characters of movie %d: %w", movieID, err)
+		}
+	}
+
+	for i := range f.Comments {
+		if err := commentRepo.AddComment(ctx, &f.Comments[i]); err != nil {
+			return fmt.Errorf("error saving comment %d: %w", i, err)
+		}
+	}
+
+	// the counts cached with the movies now match the database
+	counts, err := commentRepo.GetCommentCounts(ctx)
+	if err != nil {
+		return err
+	}
+	movieCounts := make(map[int]int64, len(f.Movies))
+	for _, movie := range f.Movies {
+		movieCounts[movie.ID] = counts[movie.ID]
+	}
+	if err := redisCache.SetMovieCommentCounts(ctx, movieCounts); err != nil {
+		return err
+	}
+
+	// a seeded cache is as good as a refreshed one for readiness
+	if err := redisCache.SetRefreshedAt(ctx, 0, time.Now()); err != nil {
+		return err
+	}
+
+	fmt.Fprintf(stdout, "seeded %d movies, %d characters and %d comments\n", len(f.Movies), len(f.Characters), len(f.Comments))
+	return nil
+}
+
+// parseFixtures decodes fixtures, rejecting characters and comments of movies
+// the fixtures do not hold
+func parseFixtures(data []byte) (fixtures, error) {
+	var f fixtures
+	if err := json.Unmarshal(data, &f); err != nil {
+		return f, fmt.Errorf("error decoding fixtures: %w", err)
+	}
+
+	movies := make(map[int]bool, len(f.Movies))
+	for _, movie := range f.Movies {
+		movies[movie.ID] = true
+	}
+
+	for _, character := range f.Characters {
+		if !movies[character.MovieID] {
+			return f, fmt.Errorf("character %d is in unknown movie %d", character.ID, character.MovieID)
+		}
+	}
+
+	for i, comment := range f.Comments {
+		if !movies[comment.SwapiMovieID] {
+			return f, fmt.Errorf("comment %d is on unknown movie %d", i, comment.SwapiMovieID)
+		}
+		if err := (model.AddCommentRequest{Message: comment.Message}).Validate(); err != nil {
+			return f, fmt.Errorf("comment %d: %w", i, err)
+		}
+	}
+
+	return f, nil
+}
+
+func (f fixtures) charactersByMovie() map[int][]model.Character {
+	byMovie := make(map[int][]model.Character)
+	for _, character := range f.Characters {
+		byMovie[character.MovieID] = append(byMovie[character.MovieID], character)
+	}
+	return byMovie
+}
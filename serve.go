@@ -0,0 +1,124 @@
+package main
+
+import (
+	"context"
+	"io"
+	"log"
+
+	"github.com/gorilla/mux"
+
+	"github.com/iamnator/movie-api/adapter/broker"
+	"github.com/iamnator/movie-api/adapter/cache"
+	"github.com/iamnator/movie-api/adapter/instrument"
+	"github.com/iamnator/movie-api/adapter/lock"
+	"github.com/iamnator/movie-api/adapter/outbox"
+	"github.com/iamnator/movie-api/adapter/presence"
+	"github.com/iamnator/movie-api/adapter/repository"
+	"github.com/iamnator/movie-api/adapter/webhook"
+	"github.com/iamnator/movie-api/config"
+	"github.com/iamnator/movie-api/docs"
+	"github.com/iamnator/movie-api/handler/http"
+	"github.com/iamnator/movie-api/pkg/metrics"
+	"github.com/iamnator/movie-api/service"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+// serve runs the api until the server fails
+func serve(ctx context.Context, cfg config.Config, args []string, _ io.Writer) error {
+	if len(args) > 0 {
+		return errUsage
+	}
+
+	log.Printf("Loaded config:\n%s", cfg)
+
+	//programmatically set swagger info
+	docs.SwaggerInfo.Title = "Busha Movie API"
+	docs.SwaggerInfo.Description = "This is a sample server for a movie API."
+	docs.SwaggerInfo.Version = "1.0"
+
+	docs.SwaggerInfo.Host = cfg.Server.Host
+	docs.SwaggerInfo.BasePath = "/"
+	docs.SwaggerInfo.Schemes = []string{"http", "https"}
+
+	r := mux.NewRouter()
+
+	// every adapter shares the one redis client and the one postgres pool
+	redisClient, err := openRedis(ctx, cfg.Redis.URL)
+	if err != nil {
+		return err
+	}
+	defer closeRedis(redisClient)
+	log.Println("Connected to redis")
+
+	db, err := repository.Open(cfg.Postgres.URL)
+	if err != nil {
+		return err
+	}
+	defer closePostgres(db)
+	log.Println("Connected to postgres")
+
+	// the cached documents survive restarts, a schema change is applied with
+	// the cache reindex command
+	redisCache, err := cache.OpenRedisCache(redisClient)
+	if err != nil {
+		return err
+	}
+	defer redisCache.Close()
+
+	locker := lock.NewRedisLocker(redisClient)
+
+	// next to the lock, so any instance reports a job whichever ran it
+	jobStore := lock.NewRedisJobStore(redisClient)
+
+	commentRepo := repository.NewPgxCommentRepository(db)
+	webhookRepo := repository.NewPgxWebhookRepository(db)
+	outboxRepo := repository.NewPgxOutboxRepository(db)
+
+	commentBroker, err := broker.NewRedisCommentBroker(redisClient)
+	if err != nil {
+		return err
+	}
+	defer commentBroker.Close()
+
+	moviePresence := presence.NewRedisPresence(redisClient)
+
+	var outboxSink ports.IOutboxSink
+	switch cfg.Outbox.Sink {
+	case "stdout":
+		outboxSink = outbox.NewWriterSink(nil)
+	default:
+		outboxSink = outbox.NewRedisStreamSink(redisClient)
+	}
+
+	swapiClient, swapiBreaker, err := newSwapi(cfg, redisClient)
+	if err != nil {
+		return err
+	}
+
+	if err := metrics.RegisterCacheDocuments(redisCache.DocumentCounts); err != nil {
+		return err
+	}
+
+	opts := []service.Option{
+		service.WithLocker(locker),
+		service.WithJobStore(jobStore),
+		service.WithSchedules(cfg.Refresh.Schedules),
+		service.WithRefreshTimeout(cfg.Refresh.Timeout.Std()),
+		service.WithIPRetention(cfg.Comments.IPRetention.Std()),
+		service.WithWebhooks(instrument.WebhookRepository(webhookRepo), webhook.NewHTTPSender(webhook.NewClient())),
+		service.WithOutboxRelay(instrument.OutboxRepository(outboxRepo), outboxSink),
+		service.WithOutboxRetention(cfg.Outbox.Retention.Std()),
+		service.WithCommentBroker(commentBroker),
+		service.WithPresence(moviePresence),
+		service.WithSwapiTracker(swapiClient),
+	}
+	if swapiBreaker != nil {
+		opts = append(opts, service.WithSwapiBreaker(swapiBreaker))
+	}
+
+	srv := instrument.Services(service.NewServices(instrument.Cache(redisCache), instrument.CommentRepository(commentRepo), swapiClient, opts...))
+
+	log.Println("Starting server on port ", cfg.Server.Port)
+
+	return http.Run(cfg.Server, r, srv)
+}
@@ -3,52 +3,196 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/rs/zerolog/log"
+	"math"
 	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 	"time"
 
 	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+	"github.com/iamnator/movie-api/thirdparty/swapi"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
 )
 
-func (s service) backGroundJOB() error {
+func (s service) backGroundJOB(ctx context.Context, trigger model.RefreshJobTrigger) error {
 	//get all movies and characters
-	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
-	defer cancel()
-	return s.refreshMovieCache(ctx)
+	return s.runRefresh(ctx, model.RefreshJob{Trigger: trigger})
 }
 
-func (s service) refreshMovieCache(ctx context.Context) error {
+// characterChunkSize is how many characters are fetched from swapi at once;
+// it takes a while to fetch all the characters so progress is saved per chunk
+const characterChunkSize = 10
+
+// refreshPlan is what the film stage of a refresh hands to the character stage
+type refreshPlan struct {
+	movieCharacters map[int][]int // movieID -> character ids listed on swapi
+	partialCasts    map[int]bool  // movies listing characters whose id could not be read
+	changedMovies   map[int]bool  // movies added or edited on swapi since they were cached
+	refreshed       map[int]bool  // movies fetched this run
+	full            bool          // every film was fetched and read, anything else cached is gone upstream
+	allCharacters   bool          // recheck every character, not only those of changed movies
+	fence           int64         // fencing token of the refresh lock, carried by every write
+}
 
-	films, err := s.swapiClient.GetFilms(ctx)
+// refreshMovieCache fetches the given films (all films if none is given) and
+// their characters from swapi and caches them, recording progress on job.
+//
+// It runs as a pipeline: films are fetched and cached first, then their
+// characters are fetched and cached chunk by chunk. It only returns once every
+// stage is done. A failed chunk does not stop the others; failures are
+// reported in the result instead. An error is returned only when no film
+// could be cached.
+//
+// The refresh is incremental: documents are only rewritten when swapi's
+// edited time is newer than the cached one, and documents that disappeared
+// upstream are deleted. Characters are only fetched when they are not cached
+// yet or one of their films changed, unless the job asks for all characters.
+func (s service) refreshMovieCache(ctx context.Context, job *refreshJob, filmIDs ...int) (model.RefreshResult, error) {
+	var result model.RefreshResult
+
+	films, err := s.swapiClient.GetFilms(ctx, filmIDs...)
 	if err != nil {
-		log.Error().Err(err).Msg("error getting movies")
-		return errors.New("error getting movies")
+		log.Ctx(ctx).Error().Err(err).Msg("error getting movies")
+		return result, errors.New("error getting movies")
+	}
+
+	job.addFilms(len(films))
+	log.Ctx(ctx).Info().Msgf("length of films fetched: %d", len(films))
+
+	movies, movieCharacters, partialCasts, skipped := collectMovies(job, films)
+
+	// a film that could not be read may be any of the cached ones, so the
+	// refresh no longer knows which are gone
+	plan := refreshPlan{
+		movieCharacters: movieCharacters,
+		partialCasts:    partialCasts,
+		changedMovies:   make(map[int]bool),
+		refreshed:       make(map[int]bool),
+		full:            len(filmIDs) == 0 && skipped == 0,
+		allCharacters:   job.snapshot().AllCharacters,
+		fence:           job.snapshot().FencingToken,
+	}
+
+	cachedMovies, versionsErr := s.cache.GetMovieVersions(ctx)
+	if versionsErr != nil {
+		log.Ctx(ctx).Warn().Err(versionsErr).Msg("error getting cached movie versions, rewriting every movie")
+	}
+
+	var changed []model.MovieDetails
+	for _, movie := range movies {
+		plan.refreshed[movie.ID] = true
+
+		cachedAt, ok := cachedMovies[movie.ID]
+		switch {
+		case !ok:
+			result.Changes.FilmsAdded = append(result.Changes.FilmsAdded, movie.ID)
+		case movie.UpdatedAt.After(cachedAt):
+			result.Changes.FilmsUpdated = append(result.Changes.FilmsUpdated, movie.ID)
+		default:
+			continue
+		}
+
+		plan.changedMovies[movie.ID] = true
+		changed = append(changed, movie)
 	}
 
-	log.Info().Msgf("length of films fetched: %d", len(films))
+	//save movies to cache
+	if len(changed) > 0 {
+		if err := s.cache.SetMovies(ctx, plan.fence, changed); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error saving movies")
+			for _, movie := range changed {
+				result.FailedFilmIDs = append(result.FailedFilmIDs, movie.ID)
+			}
+			return result, errors.New("error saving movies")
+		}
+	}
+
+	for _, movie := range movies {
+		result.SucceededFilmIDs = append(result.SucceededFilmIDs, movie.ID)
+	}
+
+	s.publish(ctx, movieEvents(changed, cachedMovies, s.clock.Now().UTC())...)
+
+	log.Ctx(ctx).Info().Msgf("length of movies cached: %d", len(changed))
+
+	// only a full refresh knows which films are gone upstream
+	if plan.full && versionsErr == nil {
+		var removed []int
+		for id := range cachedMovies {
+			if !plan.refreshed[id] {
+				removed = append(removed, id)
+			}
+		}
+		sort.Ints(removed)
+
+		if len(removed) > 0 {
+			if err := s.cache.DeleteMovies(ctx, plan.fence, removed...); err != nil {
+				log.Ctx(ctx).Error().Err(err).Ints("ids", removed).Msg("error deleting removed movies")
+				job.addError(err)
+			} else {
+				result.Changes.FilmsRemoved = removed
+			}
+		}
+	}
 
-	characterIDChan := make(chan struct {
-		charID  int
-		movieID int
-	}, 5)
-	defer close(characterIDChan)
-	go s.refreshCharacterCache(characterIDChan)
+	s.refreshCharacterCache(ctx, job, plan, &result)
 
-	var movies []model.MovieDetails
-	var filmID int
-	var movie model.MovieDetails
+	sort.Ints(result.SucceededFilmIDs)
+	sort.Ints(result.FailedFilmIDs)
+
+	log.Ctx(ctx).Info().
+		Int("films_added", len(result.Changes.FilmsAdded)).
+		Int("films_updated", len(result.Changes.FilmsUpdated)).
+		Int("films_removed", len(result.Changes.FilmsRemoved)).
+		Int("characters_added", len(result.Changes.CharactersAdded)).
+		Int("characters_updated", len(result.Changes.CharactersUpdated)).
+		Int("characters_removed", len(result.Changes.CharactersRemoved)).
+		Msg("refresh change summary")
+
+	return result, nil
+}
+
+// movieEvents returns a movie.added or movie.updated event per changed movie,
+// depending on whether it was cached before
+func movieEvents(changed []model.MovieDetails, cached map[int]time.Time, now time.Time) []model.WebhookEvent {
+	var events []model.WebhookEvent
+	for _, movie := range changed {
+		eventType := model.EventMovieUpdated
+		if _, ok := cached[movie.ID]; !ok {
+			eventType = model.EventMovieAdded
+		}
+		events = append(events, newWebhookEvent(eventType, now, movie))
+	}
+	return events
+}
+
+// collectMovies maps swapi films to cacheable movies and returns the character
+// ids of each movie. Films or characters with unusable urls are recorded as
+// job errors and skipped: it returns how many films were skipped, and the
+// movies some characters of which were, since what is cached for them cannot
+// be told gone upstream.
+func collectMovies(job *refreshJob, films []lib.Film) (movies []model.MovieDetails, movieCharacters map[int][]int, partialCasts map[int]bool, skipped int) {
+
+	movieCharacters = make(map[int][]int) // movieID, []characterID
+	partialCasts = make(map[int]bool)
 
 	for _, film := range films {
 
-		filmID, err = GetFilmIDFromURL(film.URL)
+		filmID, err := GetFilmIDFromURL(film.URL)
 		if err != nil {
-			log.Error().Err(err).Msg("error getting film id")
-			return errors.New("error getting film id")
+			log.Error().Err(err).Str("url", film.URL).Msg("error getting film id")
+			job.addError(fmt.Errorf("error getting film id from %q: %w", film.URL, err))
+			skipped++
+			continue
 		}
 
-		movie = model.MovieDetails{
+		job.addWarnings(dataWarnings(film.Validate())...)
+
+		movies = append(movies, model.MovieDetails{
 			ID:           filmID,
 			Name:         film.Title,
 			EpisodeID:    film.EpisodeID,
@@ -58,37 +202,32 @@ func (s service) refreshMovieCache(ctx context.Context) error {
 			ReleaseDate:  film.GetReleaseDate(),
 			CreatedAt:    film.GetCreated(),
 			UpdatedAt:    film.GetEdited(),
-		}
-
-		movies = append(movies, movie)
+		})
 
 		for _, characterURL := range film.CharacterURLs {
 
 			characterID, err := GetCharacterIDFromURL(characterURL)
 			if err != nil {
-				log.Error().Err(err).Msg("error getting character id")
-				return errors.New("error getting character id")
+				log.Error().Err(err).Str("url", characterURL).Msg("error getting character id")
+				job.addError(fmt.Errorf("error getting character id from %q: %w", characterURL, err))
+				partialCasts[filmID] = true
+				continue
 			}
 
-			characterIDChan <- struct {
-				charID  int
-				movieID int
-			}{
-				charID:  characterID,
-				movieID: filmID,
-			}
+			movieCharacters[filmID] = append(movieCharacters[filmID], characterID)
 		}
 	}
 
-	//save movies to cache
-	if err := s.cache.SetMovies(movies); err != nil {
-		log.Error().Err(err).Msg("error saving movies")
-		return errors.New("error saving movies")
-	}
-
-	log.Info().Msgf("length of movies cached: %d", len(movies))
+	return movies, movieCharacters, partialCasts, skipped
+}
 
-	return nil
+// dataWarnings returns the warnings of a swapi resource as recorded on a job
+func dataWarnings(warnings []lib.Warning) []model.DataWarning {
+	out := make([]model.DataWarning, len(warnings))
+	for i, w := range warnings {
+		out[i] = model.DataWarning{Resource: w.URL, Field: w.Field, Value: w.Value, Problem: w.Problem}
+	}
+	return out
 }
 
 func chunkSlice(slice []int, chunkSize int) [][]int {
@@ -106,106 +245,218 @@ func chunkSlice(slice []int, chunkSize int) [][]int {
 	return chunks
 }
 
-func (s service) refreshCharacterCache(chn chan struct {
-	charID  int
-	movieID int
-}) {
-
-	movCharMap := make(map[int][]int) // movieID, []characterID
-	var chxIDs []int
+// refreshCharacterCache fetches and caches the characters of every movie in
+// the plan in chunks, carrying on past chunks that fail.
+func (s service) refreshCharacterCache(ctx context.Context, job *refreshJob, plan refreshPlan, result *model.RefreshResult) {
 
-	for msg := range chn {
-		if _, ok := movCharMap[msg.movieID]; !ok {
-			movCharMap[msg.movieID] = []int{msg.charID}
-		} else {
-			movCharMap[msg.movieID] = append(movCharMap[msg.movieID], msg.charID)
-		}
+	cached, versionsErr := s.cache.GetCharacterVersions(ctx)
+	if versionsErr != nil {
+		log.Ctx(ctx).Warn().Err(versionsErr).Msg("error getting cached character versions, refetching every character")
 	}
 
+	// a character usually appears in several movies, fetch it once
+	var chxIDs []int
 	var chxMap = make(map[int]bool)
-	for _, v := range movCharMap {
-		for _, chxID := range v {
-			if _, ok := chxMap[chxID]; !ok {
+	needed := make(map[ports.CharacterKey]bool)
+	for movieID, charIDs := range plan.movieCharacters {
+		for _, chxID := range charIDs {
+			key := ports.CharacterKey{MovieID: movieID, CharacterID: chxID}
+			needed[key] = true
+
+			// the movie's cast did not change and the character is cached
+			if _, ok := cached[key]; ok && !plan.changedMovies[movieID] && !plan.allCharacters {
+				continue
+			}
+
+			if !chxMap[chxID] {
 				chxMap[chxID] = true
 				chxIDs = append(chxIDs, chxID)
 			}
 		}
 	}
+	sort.Ints(chxIDs)
 
-	log.Info().Msgf("length of movie characters to fetch: %d", len(chxIDs))
-
-	// chunk the slice of character ids
-	//
-	// the reason for this is that it takes a while to fetch all the characters
-	var steps [][]int
-	if len(chxIDs) > 10 {
-		steps = chunkSlice(chxIDs, 10)
-	} else {
-		steps = [][]int{chxIDs}
+	var movieIDs []int
+	for movieID := range plan.movieCharacters {
+		movieIDs = append(movieIDs, movieID)
 	}
+	sort.Ints(movieIDs)
+
+	log.Ctx(ctx).Info().Msgf("length of movie characters to fetch: %d", len(chxIDs))
+
+	steps := chunkSlice(chxIDs, characterChunkSize)
+
+	log.Ctx(ctx).Info().Msgf("length of steps: %d", len(steps))
 
-	log.Info().Msgf("length of steps: %d", len(steps))
+	succeeded := make(map[int]bool)
+	failed := make(map[int]bool)
+	added := make(map[int]bool)
+	updated := make(map[int]bool)
 
 	for _, stepIds := range steps {
 
-		characters, err := s.swapiClient.GetCharacters(context.Background(), stepIds...)
+		if ctx.Err() != nil {
+			log.Ctx(ctx).Error().Err(ctx.Err()).Msg("refresh cancelled, skipping remaining characters")
+			job.addError(ctx.Err())
+			for _, id := range stepIds {
+				failed[id] = true
+			}
+			continue
+		}
+
+		// the chunk policy is the only retry layer: the client sends each
+		// request once, and a retry only asks for the characters not fetched
+		fetched := make(map[int]lib.Person, len(stepIds))
+		pending := stepIds
+		err := s.chunkPolicy.Retry(lib.WithoutRetries(ctx), func(ctx context.Context) error {
+			characters, err := s.swapiClient.GetCharacters(ctx, pending...)
+			for _, character := range characters {
+				id, err := GetCharacterIDFromURL(character.URL)
+				if err != nil {
+					log.Ctx(ctx).Error().Err(err).Str("url", character.URL).Msg("error getting character id")
+					continue
+				}
+				fetched[id] = character
+				job.addWarnings(dataWarnings(character.Validate())...)
+			}
+
+			var charErr *swapi.CharactersError
+			if errors.As(err, &charErr) {
+				pending = charErr.IDs()
+			}
+			return err
+		})
 		if err != nil {
-			log.Error().Err(err).Msg("error getting character")
+			log.Ctx(ctx).Error().Err(err).Ints("ids", pending).Msg("error getting character chunk")
+			job.addError(err)
 		}
 
-		if len(characters) == 0 {
-			log.Info().Msg("no characters to cache")
-			return
+		for _, id := range stepIds {
+			if _, ok := fetched[id]; !ok {
+				failed[id] = true
+			} else {
+				succeeded[id] = true
+			}
 		}
 
-		log.Info().Msgf("length of fetched characters: %d", len(characters))
-
-		movieCharacterMap := make(map[int][]model.Character) // movieID, []character
-
-		for _, character := range characters {
-
-			var heightCm int
-			for movieID, charIDs := range movCharMap {
-
-				for _, charID := range charIDs {
-					id, _ := GetCharacterIDFromURL(character.URL)
-					if charID == id {
-						if h, er := strconv.Atoi(character.Height); er == nil {
-							heightCm = h
-						}
-
-						if _, ok := movieCharacterMap[movieID]; !ok { //
-
-							movieCharacterMap[movieID] = []model.Character{model.Character{
-								ID:       id,
-								MovieID:  movieID,
-								Name:     character.Name,
-								Gender:   character.Gender,
-								HeightCm: heightCm,
-							}}
-						} else {
-							movieCharacterMap[movieID] = append(movieCharacterMap[movieID], model.Character{
-								ID:       id,
-								MovieID:  movieID,
-								Name:     character.Name,
-								Gender:   character.Gender,
-								HeightCm: heightCm,
-							})
-						}
-					}
+		job.addCharacters(len(fetched))
+		log.Ctx(ctx).Info().Msgf("length of fetched characters: %d", len(fetched))
+
+		var written int
+		for _, movieID := range movieIDs {
+
+			var characterList []model.Character
+			var isNew []bool
+			for _, charID := range plan.movieCharacters[movieID] {
+				character, ok := fetched[charID]
+				if !ok {
+					continue
+				}
+
+				cachedAt, isCached := cached[ports.CharacterKey{MovieID: movieID, CharacterID: charID}]
+				if isCached && !character.GetEdited().After(cachedAt) {
+					continue
 				}
+
+				// unknown and malformed heights were flagged when fetched
+				var heightCm int
+				height, er := character.GetHeight()
+				if er == nil && height.Known() {
+					heightCm = int(math.Round(*height.Value))
+				}
+
+				characterList = append(characterList, model.Character{
+					ID:          charID,
+					MovieID:     movieID,
+					Name:        character.Name,
+					Gender:      character.Gender,
+					HeightCm:    heightCm,
+					HeightKnown: er == nil && height.Known(),
+					UpdatedAt:   character.GetEdited(),
+				})
+				isNew = append(isNew, !isCached)
 			}
-		}
 
-		for msg, characterList := range movieCharacterMap {
-			if err := s.cache.SetCharactersByMovieID(msg, characterList); err != nil {
-				log.Error().Err(err).Msg("error saving character")
-				return
+			if len(characterList) == 0 {
+				continue
 			}
+
+			if err := s.cache.SetCharactersByMovieID(ctx, plan.fence, movieID, characterList); err != nil {
+				log.Ctx(ctx).Error().Err(err).Int("movie_id", movieID).Msg("error saving character")
+				job.addError(err)
+				for _, character := range characterList {
+					failed[character.ID] = true
+				}
+				continue
+			}
+
+			written += len(characterList)
+			var events []model.WebhookEvent
+			now := s.clock.Now().UTC()
+			for i, character := range characterList {
+				eventType := model.EventCharacterUpdated
+				if isNew[i] {
+					added[character.ID] = true
+					eventType = model.EventCharacterAdded
+				} else {
+					updated[character.ID] = true
+				}
+				events = append(events, newWebhookEvent(eventType, now, character))
+			}
+			s.publish(ctx, events...)
+		}
+
+		log.Ctx(ctx).Info().Msgf("length of characters cached: %d", written)
+	}
+
+	for _, id := range chxIDs {
+		switch {
+		case failed[id]:
+			result.FailedCharacterIDs = append(result.FailedCharacterIDs, id)
+		case succeeded[id]:
+			result.SucceededCharacterIDs = append(result.SucceededCharacterIDs, id)
+		}
+	}
+
+	result.Changes.CharactersAdded = sortedIDs(added)
+	result.Changes.CharactersUpdated = sortedIDs(updated)
+
+	if versionsErr != nil {
+		// without the cached versions there is no telling what is stale
+		return
+	}
+
+	// drop characters no longer listed in a refreshed movie, or whose movie is gone
+	var stale []ports.CharacterKey
+	removed := make(map[int]bool)
+	for key := range cached {
+		if needed[key] || plan.partialCasts[key.MovieID] || !(plan.full || plan.refreshed[key.MovieID]) {
+			continue
 		}
-		log.Info().Msgf("length of characters cached: %d", len(characters))
+		stale = append(stale, key)
+		removed[key.CharacterID] = true
+	}
+
+	if len(stale) == 0 {
+		return
 	}
 
+	if err := s.cache.DeleteCharacters(ctx, plan.fence, stale...); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error deleting removed characters")
+		job.addError(err)
+		return
+	}
+
+	result.Changes.CharactersRemoved = sortedIDs(removed)
+}
+
+func sortedIDs(set map[int]bool) []int {
+	var ids []int
+	for id := range set {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
 }
 
 func GetFilmIDFromURL(urL string) (int, error) {
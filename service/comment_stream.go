@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+var ErrStreamingDisabled = errors.New("comment streaming is not configured")
+
+// commentResumeLimit is how many missed comments are read at once on resume
+const commentResumeLimit = 100
+
+// StreamComments returns the comments of a movie as they are saved on any
+// instance. When lastEventID is the id of a comment, every comment created
+// after it is replayed first, a page at a time; an unknown id streams from now
+// on. The channel is closed once ctx is done or the subscriber falls too far
+// behind, in which case it should resume from the last comment it got.
+func (s service) StreamComments(ctx context.Context, movieID int, lastEventID string) (<-chan model.CommentEventData, error) {
+	if s.broker == nil {
+		return nil, ErrStreamingDisabled
+	}
+
+	//check if movie exists
+	if _, err := s.cache.GetMovieByID(ctx, movieID); err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("movie not found")
+		return nil, errors.New("movie not found")
+	}
+
+	// subscribe before reading the backlog so nothing saved in between is missed
+	live, cancel := s.broker.Subscribe(movieID)
+
+	// the first page is read here so a failure is reported to the caller
+	var backlog []model.Comment
+	afterID, err := uuid.Parse(lastEventID)
+	resuming := err == nil
+	if resuming {
+		backlog, err = s.commentRepository.GetCommentsAfter(ctx, movieID, afterID, commentResumeLimit)
+		if err != nil && !errors.Is(err, ports.ErrNotFound) {
+			cancel()
+			log.Ctx(ctx).Error().Err(err).Msg("error getting missed comments")
+			return nil, errors.New("error getting comments")
+		}
+	}
+
+	out := make(chan model.CommentEventData)
+
+	go func() {
+		defer close(out)
+		defer cancel()
+
+		// a comment saved while the backlog was read arrives twice
+		replayed := make(map[uuid.UUID]bool, len(backlog))
+
+		for resuming {
+			for _, comment := range backlog {
+				replayed[comment.ID] = true
+
+				select {
+				case out <- comment.EventData():
+				case <-ctx.Done():
+					return
+				}
+			}
+
+			// a short page is the last one, the rest arrives live
+			if len(backlog) < commentResumeLimit {
+				break
+			}
+
+			var err error
+			backlog, err = s.commentRepository.GetCommentsAfter(ctx, movieID, backlog[len(backlog)-1].ID, commentResumeLimit)
+			if err != nil {
+				// the subscriber resumes from the last comment it got
+				log.Ctx(ctx).Error().Err(err).Msg("error getting missed comments")
+				return
+			}
+		}
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case comment, ok := <-live:
+				if !ok {
+					return
+				}
+				if replayed[comment.ID] {
+					continue
+				}
+
+				select {
+				case out <- comment:
+				case <-ctx.Done():
+					return
+				}
+			}
+		}
+	}()
+
+	return out, nil
+}
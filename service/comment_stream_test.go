@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports/mocks"
+)
+
+func Test_StreamComments_ResumesWithoutDuplicates(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cache := mocks.NewMockICache(ctrl)
+	repo := mocks.NewMockICommentRepository(ctrl)
+	broker := mocks.NewMockICommentBroker(ctrl)
+
+	s := service{cache: cache, commentRepository: repo, broker: broker}
+
+	lastSeen := uuid.New()
+	missed := []model.Comment{
+		{ID: uuid.New(), SwapiMovieID: 1, Message: "missed 1"},
+		{ID: uuid.New(), SwapiMovieID: 1, Message: "missed 2"},
+	}
+	fresh := model.CommentEventData{ID: uuid.New(), MovieID: 1, Message: "fresh"}
+
+	// missed 2 was saved while the backlog was read, so it is published live too
+	live := make(chan model.CommentEventData, 2)
+	live <- missed[1].EventData()
+	live <- fresh
+	cancelled := make(chan struct{})
+
+	cache.EXPECT().GetMovieByID(gomock.Any(), 1).Return(&model.MovieDetails{ID: 1}, nil)
+	broker.EXPECT().Subscribe(1).Return(live, func() { close(cancelled) })
+	repo.EXPECT().GetCommentsAfter(gomock.Any(), 1, lastSeen, commentResumeLimit).Return(missed, nil)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	comments, err := s.StreamComments(ctx, 1, lastSeen.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := []uuid.UUID{missed[0].ID, missed[1].ID, fresh.ID}
+	for i, id := range want {
+		select {
+		case got := <-comments:
+			if got.ID != id {
+				t.Errorf("comment %d=%s | expected=%s", i, got.Message, id)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("comment %d not received", i)
+		}
+	}
+
+	cancel()
+	select {
+	case <-cancelled:
+	case <-time.After(time.Second):
+		t.Fatalf("subscription not cancelled with the context")
+	}
+}
+
+func Test_StreamComments_ResumesEveryPage(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cache := mocks.NewMockICache(ctrl)
+	repo := mocks.NewMockICommentRepository(ctrl)
+	broker := mocks.NewMockICommentBroker(ctrl)
+
+	s := service{cache: cache, commentRepository: repo, broker: broker}
+
+	// more comments were missed than a page holds
+	lastSeen := uuid.New()
+	missed := make([]model.Comment, 2*commentResumeLimit+50)
+	for i := range missed {
+		missed[i] = model.Comment{ID: uuid.New(), SwapiMovieID: 1, Message: fmt.Sprintf("missed %d", i)}
+	}
+	fresh := model.CommentEventData{ID: uuid.New(), MovieID: 1, Message: "fresh"}
+
+	live := make(chan model.CommentEventData, 1)
+	live <- fresh
+
+	cache.EXPECT().GetMovieByID(gomock.Any(), 1).Return(&model.MovieDetails{ID: 1}, nil)
+	broker.EXPECT().Subscribe(1).Return(live, func() {})
+	gomock.InOrder(
+		repo.EXPECT().GetCommentsAfter(gomock.Any(), 1, lastSeen, commentResumeLimit).Return(missed[:100], nil),
+		repo.EXPECT().GetCommentsAfter(gomock.Any(), 1, missed[99].ID, commentResumeLimit).Return(missed[100:200], nil),
+		repo.EXPECT().GetCommentsAfter(gomock.Any(), 1, missed[199].ID, commentResumeLimit).Return(missed[200:], nil),
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	comments, err := s.StreamComments(ctx, 1, lastSeen.String())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := make([]uuid.UUID, 0, len(missed)+1)
+	for _, comment := range missed {
+		want = append(want, comment.ID)
+	}
+	want = append(want, fresh.ID)
+
+	for i, id := range want {
+		select {
+		case got := <-comments:
+			if got.ID != id {
+				t.Fatalf("comment %d=%s | expected=%s", i, got.Message, id)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("comment %d not received", i)
+		}
+	}
+}
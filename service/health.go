@@ -0,0 +1,190 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/iamnator/movie-api/model"
+)
+
+const (
+	// healthCheckTimeout bounds each readiness check, a hung dependency must
+	// not hang the load balancer's probe
+	healthCheckTimeout = 2 * time.Second
+
+	// refreshStaleAfter is how old the last refresh may be before readiness is
+	// degraded; twice the default films schedule. A stale cache still serves,
+	// so it never fails readiness on its own
+	refreshStaleAfter = 6 * time.Hour
+
+	// swapiBreakerClosed is the state of a circuit breaker letting requests through
+	swapiBreakerClosed = "closed"
+)
+
+// healthCheck checks a dependency, returning details worth reporting
+type healthCheck func(ctx context.Context) (model.HealthStatus, map[string]interface{}, error)
+
+// Readiness checks every dependency concurrently. The instance is not ready
+// when redis or postgres are unreachable, the schema is behind or the cache
+// was never filled. Swapi being unreachable only degrades it, the cache still
+// serves.
+func (s service) Readiness(ctx context.Context) model.HealthReport {
+	checks := map[string]healthCheck{
+		model.HealthCheckRedis:      s.checkRedis,
+		model.HealthCheckRediSearch: s.checkRediSearch,
+		model.HealthCheckPostgres:   s.checkPostgres,
+		model.HealthCheckMigrations: s.checkMigrations,
+		model.HealthCheckRefresh:    s.checkRefresh,
+	}
+	if s.swapiBreaker != nil {
+		checks[model.HealthCheckSwapi] = s.checkSwapi
+	}
+
+	report := model.HealthReport{
+		Status:    model.HealthOK,
+		Checks:    make(map[string]model.HealthCheck, len(checks)),
+		CheckedAt: s.clock.Now().UTC(),
+	}
+
+	var (
+		mu sync.Mutex
+		wg sync.WaitGroup
+	)
+	for name, check := range checks {
+		wg.Add(1)
+		go func(name string, check healthCheck) {
+			defer wg.Done()
+
+			result := runHealthCheck(ctx, check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[name] = result
+		}(name, check)
+	}
+	wg.Wait()
+
+	for _, result := range report.Checks {
+		switch result.Status {
+		case model.HealthFailed:
+			report.Status = model.HealthFailed
+		case model.HealthDegraded:
+			if report.Status == model.HealthOK {
+				report.Status = model.HealthDegraded
+			}
+		}
+	}
+
+	return report
+}
+
+func runHealthCheck(ctx context.Context, check healthCheck) model.HealthCheck {
+	ctx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	status, details, err := check(ctx)
+
+	result := model.HealthCheck{
+		Status:  status,
+		Details: details,
+		Latency: time.Since(start).String(),
+	}
+	if err != nil {
+		result.Status = model.HealthFailed
+		result.Error = err.Error()
+	}
+
+	return result
+}
+
+func (s service) checkRedis(ctx context.Context) (model.HealthStatus, map[string]interface{}, error) {
+	return model.HealthOK, nil, s.cache.Ping(ctx)
+}
+
+// checkRediSearch fails when an index is missing or empty, an instance with a
+// cold cache has nothing to serve
+func (s service) checkRediSearch(ctx context.Context) (model.HealthStatus, map[string]interface{}, error) {
+	counts, err := s.cache.DocumentCounts(ctx)
+	if err != nil {
+		return model.HealthFailed, nil, err
+	}
+
+	details := make(map[string]interface{}, len(counts))
+	for index, count := range counts {
+		details[index] = count
+	}
+
+	for index, count := range counts {
+		if count == 0 {
+			return model.HealthFailed, details, fmt.Errorf("index %s is empty", index)
+		}
+	}
+
+	return model.HealthOK, details, nil
+}
+
+func (s service) checkPostgres(ctx context.Context) (model.HealthStatus, map[string]interface{}, error) {
+	return model.HealthOK, nil, s.commentRepository.Ping(ctx)
+}
+
+func (s service) checkMigrations(ctx context.Context) (model.HealthStatus, map[string]interface{}, error) {
+	version, err := s.commentRepository.SchemaVersion(ctx)
+	if err != nil {
+		return model.HealthFailed, nil, err
+	}
+
+	details := map[string]interface{}{
+		"current":  version.Current,
+		"required": version.Required,
+		"dirty":    version.Dirty,
+	}
+
+	switch {
+	case version.Dirty:
+		return model.HealthFailed, details, fmt.Errorf("migration %d is dirty", version.Current)
+	case version.Current < version.Required:
+		return model.HealthFailed, details, fmt.Errorf("schema is at version %d, %d is required", version.Current, version.Required)
+	}
+
+	return model.HealthOK, details, nil
+}
+
+func (s service) checkRefresh(ctx context.Context) (model.HealthStatus, map[string]interface{}, error) {
+	refreshedAt, err := s.cache.GetRefreshedAt(ctx)
+	if err != nil {
+		return model.HealthFailed, nil, err
+	}
+	if refreshedAt.IsZero() {
+		return model.HealthFailed, nil, errors.New("cache was never refreshed")
+	}
+
+	age := s.clock.Now().Sub(refreshedAt)
+	details := map[string]interface{}{
+		"refreshed_at": refreshedAt.UTC(),
+		"age":          age.Round(time.Second).String(),
+	}
+
+	if age > refreshStaleAfter {
+		return model.HealthDegraded, details, nil
+	}
+
+	return model.HealthOK, details, nil
+}
+
+// checkSwapi degrades readiness while the circuit breaker guarding swapi is
+// not closed, refreshes fail until swapi recovers
+func (s service) checkSwapi(ctx context.Context) (model.HealthStatus, map[string]interface{}, error) {
+	state := s.swapiBreaker.State()
+	details := map[string]interface{}{
+		"circuit_breaker": state,
+	}
+
+	if state != swapiBreakerClosed {
+		return model.HealthDegraded, details, nil
+	}
+	return model.HealthOK, details, nil
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/service/ports/mocks"
+)
+
+func Test_Readiness(t *testing.T) {
+	now := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name        string
+		pingErr     error
+		counts      map[string]int64
+		version     model.SchemaVersion
+		refreshedAt time.Time
+		status      model.HealthStatus
+		failed      []string
+	}{
+		{
+			name:        "ready",
+			counts:      map[string]int64{"movies": 6, "characters": 87},
+			version:     model.SchemaVersion{Current: 4, Required: 4},
+			refreshedAt: now.Add(-time.Hour),
+			status:      model.HealthOK,
+		},
+		{
+			name:        "stale refresh only degrades",
+			counts:      map[string]int64{"movies": 6, "characters": 87},
+			version:     model.SchemaVersion{Current: 4, Required: 4},
+			refreshedAt: now.Add(-refreshStaleAfter - time.Minute),
+			status:      model.HealthDegraded,
+		},
+		{
+			name:    "cold instance",
+			counts:  map[string]int64{"movies": 0, "characters": 0},
+			version: model.SchemaVersion{Current: 4, Required: 4},
+			status:  model.HealthFailed,
+			failed:  []string{model.HealthCheckRediSearch, model.HealthCheckRefresh},
+		},
+		{
+			name:        "redis down and schema behind",
+			pingErr:     errors.New("connection refused"),
+			counts:      map[string]int64{"movies": 6, "characters": 87},
+			version:     model.SchemaVersion{Current: 3, Required: 4},
+			refreshedAt: now.Add(-time.Hour),
+			status:      model.HealthFailed,
+			failed:      []string{model.HealthCheckRedis, model.HealthCheckMigrations},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			cache := mocks.NewMockICache(ctrl)
+			repo := mocks.NewMockICommentRepository(ctrl)
+
+			cache.EXPECT().Ping(gomock.Any()).Return(tt.pingErr)
+			cache.EXPECT().DocumentCounts(gomock.Any()).Return(tt.counts, nil)
+			cache.EXPECT().GetRefreshedAt(gomock.Any()).Return(tt.refreshedAt, nil)
+			repo.EXPECT().Ping(gomock.Any()).Return(nil)
+			repo.EXPECT().SchemaVersion(gomock.Any()).Return(tt.version, nil)
+
+			s := service{cache: cache, commentRepository: repo, clock: fixedClock(now)}
+
+			report := s.Readiness(context.Background())
+			if report.Status != tt.status {
+				t.Errorf("status=%s | expected=%s: %+v", report.Status, tt.status, report.Checks)
+			}
+
+			if len(report.Checks) != 5 {
+				t.Errorf("checks=%d | expected=%d", len(report.Checks), 5)
+			}
+
+			for _, name := range tt.failed {
+				if check := report.Checks[name]; check.Status != model.HealthFailed || check.Error == "" {
+					t.Errorf("%s=%+v | expected a failed check", name, check)
+				}
+			}
+		})
+	}
+}
+
+func Test_Readiness_SwapiBreaker(t *testing.T) {
+	now := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		state  string
+		status model.HealthStatus
+	}{
+		{state: "closed", status: model.HealthOK},
+		{state: "half-open", status: model.HealthDegraded},
+		{state: "open", status: model.HealthDegraded},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.state, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			cache := mocks.NewMockICache(ctrl)
+			repo := mocks.NewMockICommentRepository(ctrl)
+			breaker := mocks.NewMockICircuitBreaker(ctrl)
+
+			cache.EXPECT().Ping(gomock.Any()).Return(nil)
+			cache.EXPECT().DocumentCounts(gomock.Any()).Return(map[string]int64{"movies": 6, "characters": 87}, nil)
+			cache.EXPECT().GetRefreshedAt(gomock.Any()).Return(now.Add(-time.Hour), nil)
+			repo.EXPECT().Ping(gomock.Any()).Return(nil)
+			repo.EXPECT().SchemaVersion(gomock.Any()).Return(model.SchemaVersion{Current: 4, Required: 4}, nil)
+			breaker.EXPECT().State().Return(tt.state)
+
+			s := service{cache: cache, commentRepository: repo, swapiBreaker: breaker, clock: fixedClock(now)}
+
+			// swapi being down never makes the instance unready
+			report := s.Readiness(context.Background())
+			if report.Status != tt.status || !report.Ready() {
+				t.Errorf("status=%s | expected=%s: %+v", report.Status, tt.status, report.Checks)
+			}
+
+			check := report.Checks[model.HealthCheckSwapi]
+			if check.Status != tt.status || check.Details["circuit_breaker"] != tt.state {
+				t.Errorf("swapi=%+v | expected=%s with state %s", check, tt.status, tt.state)
+			}
+		})
+	}
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+// lockTTL is how long a job lock survives its holder crashing; the lease is
+// renewed while the job runs
+const lockTTL = 30 * time.Second
+
+// runExclusive runs fn while holding the named distributed lock, so a single
+// instance sharing the cache runs it at a time. It returns ran=false without
+// an error when another instance holds the lock. ctx passed to fn is
+// cancelled as soon as the lease may belong to someone else. Without a
+// locker fn always runs, with a zero token.
+func (s service) runExclusive(ctx context.Context, name string, fn func(ctx context.Context, token int64) error) (ran bool, err error) {
+	if s.locker == nil {
+		return true, fn(ctx, 0)
+	}
+
+	lease, err := s.locker.Acquire(ctx, name, lockTTL)
+	if errors.Is(err, ports.ErrLockNotAcquired) {
+		return false, nil
+	}
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Str("lock", name).Msg("error acquiring lock")
+		return false, err
+	}
+
+	defer func() {
+		if err := lease.Release(context.Background()); err != nil {
+			log.Ctx(ctx).Error().Err(err).Str("lock", name).Msg("error releasing lock")
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	// stop writing as soon as the lease may belong to someone else
+	go func() {
+		select {
+		case <-lease.Lost():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	return true, fn(ctx, lease.Token())
+}
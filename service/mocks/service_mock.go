@@ -5,9 +5,11 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
 
 	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
 	model "github.com/iamnator/movie-api/model"
 )
 
@@ -34,10 +36,39 @@ func (m *MockIServices) EXPECT() *MockIServicesMockRecorder {
 	return m.recorder
 }
 
+// CreateWebhook mocks base method.
+func (m *MockIServices) CreateWebhook(arg0 context.Context, arg1 model.AddWebhookRequest) (*model.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CreateWebhook", arg0, arg1)
+	ret0, _ := ret[0].(*model.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// CreateWebhook indicates an expected call of CreateWebhook.
+func (mr *MockIServicesMockRecorder) CreateWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CreateWebhook", reflect.TypeOf((*MockIServices)(nil).CreateWebhook), arg0, arg1)
+}
+
+// DeleteWebhook mocks base method.
+func (m *MockIServices) DeleteWebhook(arg0 context.Context, arg1 uuid.UUID) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeleteWebhook", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteWebhook indicates an expected call of DeleteWebhook.
+func (mr *MockIServicesMockRecorder) DeleteWebhook(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteWebhook", reflect.TypeOf((*MockIServices)(nil).DeleteWebhook), arg0, arg1)
+}
+
 // GetCharactersByMovieID mocks base method.
-func (m *MockIServices) GetCharactersByMovieID(arg0 model.GetCharactersByMovieIDArgs) (*model.CharacterList, int64, error) {
+func (m *MockIServices) GetCharactersByMovieID(arg0 context.Context, arg1 model.GetCharactersByMovieIDArgs) (*model.CharacterList, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCharactersByMovieID", arg0)
+	ret := m.ctrl.Call(m, "GetCharactersByMovieID", arg0, arg1)
 	ret0, _ := ret[0].(*model.CharacterList)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -45,15 +76,15 @@ func (m *MockIServices) GetCharactersByMovieID(arg0 model.GetCharactersByMovieID
 }
 
 // GetCharactersByMovieID indicates an expected call of GetCharactersByMovieID.
-func (mr *MockIServicesMockRecorder) GetCharactersByMovieID(arg0 interface{}) *gomock.Call {
+func (mr *MockIServicesMockRecorder) GetCharactersByMovieID(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCharactersByMovieID", reflect.TypeOf((*MockIServices)(nil).GetCharactersByMovieID), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCharactersByMovieID", reflect.TypeOf((*MockIServices)(nil).GetCharactersByMovieID), arg0, arg1)
 }
 
 // GetComment mocks base method.
-func (m *MockIServices) GetComment(arg0, arg1, arg2 int) ([]model.Comment, int64, error) {
+func (m *MockIServices) GetComment(arg0 context.Context, arg1, arg2, arg3 int) ([]model.Comment, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetComment", arg0, arg1, arg2)
+	ret := m.ctrl.Call(m, "GetComment", arg0, arg1, arg2, arg3)
 	ret0, _ := ret[0].([]model.Comment)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -61,30 +92,30 @@ func (m *MockIServices) GetComment(arg0, arg1, arg2 int) ([]model.Comment, int64
 }
 
 // GetComment indicates an expected call of GetComment.
-func (mr *MockIServicesMockRecorder) GetComment(arg0, arg1, arg2 interface{}) *gomock.Call {
+func (mr *MockIServicesMockRecorder) GetComment(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComment", reflect.TypeOf((*MockIServices)(nil).GetComment), arg0, arg1, arg2)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComment", reflect.TypeOf((*MockIServices)(nil).GetComment), arg0, arg1, arg2, arg3)
 }
 
 // GetMovieByID mocks base method.
-func (m *MockIServices) GetMovieByID(arg0 int) (*model.Movie, error) {
+func (m *MockIServices) GetMovieByID(arg0 context.Context, arg1 int) (*model.Movie, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMovieByID", arg0)
+	ret := m.ctrl.Call(m, "GetMovieByID", arg0, arg1)
 	ret0, _ := ret[0].(*model.Movie)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetMovieByID indicates an expected call of GetMovieByID.
-func (mr *MockIServicesMockRecorder) GetMovieByID(arg0 interface{}) *gomock.Call {
+func (mr *MockIServicesMockRecorder) GetMovieByID(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovieByID", reflect.TypeOf((*MockIServices)(nil).GetMovieByID), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovieByID", reflect.TypeOf((*MockIServices)(nil).GetMovieByID), arg0, arg1)
 }
 
 // GetMovies mocks base method.
-func (m *MockIServices) GetMovies(arg0, arg1 int) ([]model.Movie, int64, error) {
+func (m *MockIServices) GetMovies(arg0 context.Context, arg1, arg2 int) ([]model.Movie, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMovies", arg0, arg1)
+	ret := m.ctrl.Call(m, "GetMovies", arg0, arg1, arg2)
 	ret0, _ := ret[0].([]model.Movie)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -92,21 +123,184 @@ func (m *MockIServices) GetMovies(arg0, arg1 int) ([]model.Movie, int64, error)
 }
 
 // GetMovies indicates an expected call of GetMovies.
-func (mr *MockIServicesMockRecorder) GetMovies(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockIServicesMockRecorder) GetMovies(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovies", reflect.TypeOf((*MockIServices)(nil).GetMovies), arg0, arg1, arg2)
+}
+
+// GetRefreshJob mocks base method.
+func (m *MockIServices) GetRefreshJob(arg0 context.Context, arg1 string) (*model.RefreshJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshJob", arg0, arg1)
+	ret0, _ := ret[0].(*model.RefreshJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshJob indicates an expected call of GetRefreshJob.
+func (mr *MockIServicesMockRecorder) GetRefreshJob(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshJob", reflect.TypeOf((*MockIServices)(nil).GetRefreshJob), arg0, arg1)
+}
+
+// GetSchedule mocks base method.
+func (m *MockIServices) GetSchedule(arg0 context.Context) []model.ScheduledJob {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetSchedule", arg0)
+	ret0, _ := ret[0].([]model.ScheduledJob)
+	return ret0
+}
+
+// GetSchedule indicates an expected call of GetSchedule.
+func (mr *MockIServicesMockRecorder) GetSchedule(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetSchedule", reflect.TypeOf((*MockIServices)(nil).GetSchedule), arg0)
+}
+
+// GetWebhookDeliveries mocks base method.
+func (m *MockIServices) GetWebhookDeliveries(arg0 context.Context, arg1 model.WebhookDeliveryState, arg2, arg3 int) ([]model.WebhookDelivery, int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhookDeliveries", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]model.WebhookDelivery)
+	ret1, _ := ret[1].(int64)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// GetWebhookDeliveries indicates an expected call of GetWebhookDeliveries.
+func (mr *MockIServicesMockRecorder) GetWebhookDeliveries(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovies", reflect.TypeOf((*MockIServices)(nil).GetMovies), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhookDeliveries", reflect.TypeOf((*MockIServices)(nil).GetWebhookDeliveries), arg0, arg1, arg2, arg3)
+}
+
+// GetWebhooks mocks base method.
+func (m *MockIServices) GetWebhooks(arg0 context.Context) ([]model.WebhookSubscription, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetWebhooks", arg0)
+	ret0, _ := ret[0].([]model.WebhookSubscription)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetWebhooks indicates an expected call of GetWebhooks.
+func (mr *MockIServicesMockRecorder) GetWebhooks(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetWebhooks", reflect.TypeOf((*MockIServices)(nil).GetWebhooks), arg0)
+}
+
+// Readiness mocks base method.
+func (m *MockIServices) Readiness(arg0 context.Context) model.HealthReport {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Readiness", arg0)
+	ret0, _ := ret[0].(model.HealthReport)
+	return ret0
+}
+
+// Readiness indicates an expected call of Readiness.
+func (mr *MockIServicesMockRecorder) Readiness(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Readiness", reflect.TypeOf((*MockIServices)(nil).Readiness), arg0)
+}
+
+// ReplayWebhookDelivery mocks base method.
+func (m *MockIServices) ReplayWebhookDelivery(arg0 context.Context, arg1 uuid.UUID) (*model.WebhookDelivery, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReplayWebhookDelivery", arg0, arg1)
+	ret0, _ := ret[0].(*model.WebhookDelivery)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReplayWebhookDelivery indicates an expected call of ReplayWebhookDelivery.
+func (mr *MockIServicesMockRecorder) ReplayWebhookDelivery(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReplayWebhookDelivery", reflect.TypeOf((*MockIServices)(nil).ReplayWebhookDelivery), arg0, arg1)
+}
+
+// RunRefresh mocks base method.
+func (m *MockIServices) RunRefresh(arg0 context.Context, arg1 int) (*model.RefreshJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RunRefresh", arg0, arg1)
+	ret0, _ := ret[0].(*model.RefreshJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RunRefresh indicates an expected call of RunRefresh.
+func (mr *MockIServicesMockRecorder) RunRefresh(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RunRefresh", reflect.TypeOf((*MockIServices)(nil).RunRefresh), arg0, arg1)
 }
 
 // SaveComment mocks base method.
-func (m *MockIServices) SaveComment(arg0 int, arg1 model.Comment) error {
+func (m *MockIServices) SaveComment(arg0 context.Context, arg1 int, arg2 model.Comment) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SaveComment", arg0, arg1)
+	ret := m.ctrl.Call(m, "SaveComment", arg0, arg1, arg2)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SaveComment indicates an expected call of SaveComment.
-func (mr *MockIServicesMockRecorder) SaveComment(arg0, arg1 interface{}) *gomock.Call {
+func (mr *MockIServicesMockRecorder) SaveComment(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveComment", reflect.TypeOf((*MockIServices)(nil).SaveComment), arg0, arg1, arg2)
+}
+
+// StreamComments mocks base method.
+func (m *MockIServices) StreamComments(arg0 context.Context, arg1 int, arg2 string) (<-chan model.CommentEventData, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "StreamComments", arg0, arg1, arg2)
+	ret0, _ := ret[0].(<-chan model.CommentEventData)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// StreamComments indicates an expected call of StreamComments.
+func (mr *MockIServicesMockRecorder) StreamComments(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "StreamComments", reflect.TypeOf((*MockIServices)(nil).StreamComments), arg0, arg1, arg2)
+}
+
+// TriggerRefresh mocks base method.
+func (m *MockIServices) TriggerRefresh(arg0 context.Context, arg1 int) (*model.RefreshJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "TriggerRefresh", arg0, arg1)
+	ret0, _ := ret[0].(*model.RefreshJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// TriggerRefresh indicates an expected call of TriggerRefresh.
+func (mr *MockIServicesMockRecorder) TriggerRefresh(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "TriggerRefresh", reflect.TypeOf((*MockIServices)(nil).TriggerRefresh), arg0, arg1)
+}
+
+// ValidateMovieID mocks base method.
+func (m *MockIServices) ValidateMovieID(arg0 context.Context, arg1 int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ValidateMovieID", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// ValidateMovieID indicates an expected call of ValidateMovieID.
+func (mr *MockIServicesMockRecorder) ValidateMovieID(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ValidateMovieID", reflect.TypeOf((*MockIServices)(nil).ValidateMovieID), arg0, arg1)
+}
+
+// WatchMovie mocks base method.
+func (m *MockIServices) WatchMovie(arg0 context.Context, arg1 int) (<-chan int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "WatchMovie", arg0, arg1)
+	ret0, _ := ret[0].(<-chan int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// WatchMovie indicates an expected call of WatchMovie.
+func (mr *MockIServicesMockRecorder) WatchMovie(arg0, arg1 interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveComment", reflect.TypeOf((*MockIServices)(nil).SaveComment), arg0, arg1)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "WatchMovie", reflect.TypeOf((*MockIServices)(nil).WatchMovie), arg0, arg1)
 }
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/backoff"
+)
+
+const (
+	// outboxPollInterval is how often the relay looks for unpublished messages
+	outboxPollInterval = time.Second
+	outboxBatchSize    = 100
+
+	// outboxClaimLease must outlast publishing a whole batch; messages left
+	// claimed by a crashed relay are picked up again once it expires
+	outboxClaimLease = 30 * time.Second
+)
+
+// defaultOutboxPolicy spaces publish attempts of a message from a second to 5
+// minutes apart and never gives up, delivery is at least once.
+func defaultOutboxPolicy() backoff.Policy {
+	p := backoff.DefaultPolicy()
+	p.InitialInterval = time.Second
+	p.MaxInterval = 5 * time.Minute
+	return p
+}
+
+// relayOutbox publishes outbox messages to the sink until ctx is done
+func (s service) relayOutbox(ctx context.Context) {
+	for {
+		s.relayOutboxBatch(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(outboxPollInterval):
+		}
+	}
+}
+
+// outboxWebhookEvent is the webhook event announcing message, if its topic
+// has one. The event takes the id of the message, so a message relayed twice
+// is delivered under the same event id for subscribers to deduplicate.
+func outboxWebhookEvent(message model.OutboxMessage) (model.WebhookEvent, bool) {
+	switch message.Topic {
+	case model.TopicCommentCreated:
+		event := newWebhookEvent(model.EventCommentCreated, message.CreatedAt, message.Payload)
+		event.ID = message.ID
+		return event, true
+	default:
+		return model.WebhookEvent{}, false
+	}
+}
+
+// relayOutboxBatch claims a batch of messages and publishes them oldest first,
+// queueing the webhook deliveries they announce once the sink accepted them. A
+// message is marked published only once the sink accepted it, so a crash in
+// between publishes it again; consumers deduplicate on its idempotency key.
+// The batch stops at the first failure, the rest are claimable again when the
+// lease expires.
+//
+// Order only holds within a batch: relays on other instances publish other
+// batches meanwhile, and a failed message is retried after newer ones may
+// have been published, so consumers must not rely on a global order.
+func (s service) relayOutboxBatch(ctx context.Context) {
+	messages, err := s.outbox.ClaimOutbox(ctx, s.clock.Now(), outboxBatchSize, outboxClaimLease)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error claiming outbox messages")
+		return
+	}
+
+	for _, message := range messages {
+		logger := log.With().Str("outbox_id", message.ID.String()).Str("topic", message.Topic).Logger()
+
+		if err := s.outboxSink.Publish(ctx, message); err != nil {
+			retryAt := s.clock.Now().Add(s.outboxPolicy.Delay(message.Attempts + 1))
+			logger.Warn().Err(err).Int("attempt", message.Attempts+1).Time("retry_at", retryAt).Msg("error publishing outbox message")
+
+			if err := s.outbox.MarkOutboxFailed(ctx, message.ID, err.Error(), retryAt); err != nil {
+				logger.Error().Err(err).Msg("error recording outbox failure")
+			}
+			return
+		}
+
+		if event, ok := outboxWebhookEvent(message); ok {
+			s.publish(ctx, event)
+		}
+
+		if err := s.outbox.MarkOutboxPublished(ctx, message.ID, s.clock.Now()); err != nil {
+			// it is published again once the claim expires
+			logger.Error().Err(err).Msg("error marking outbox message published")
+		}
+	}
+}
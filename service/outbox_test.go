@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/backoff"
+	"github.com/iamnator/movie-api/service/ports/mocks"
+)
+
+func Test_relayOutboxBatch(t *testing.T) {
+	now := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+	messages := []model.OutboxMessage{
+		{ID: uuid.New(), IdempotencyKey: "comment.created:1", Topic: model.TopicCommentCreated},
+		{ID: uuid.New(), IdempotencyKey: "comment.created:2", Topic: model.TopicCommentCreated, Attempts: 1},
+		{ID: uuid.New(), IdempotencyKey: "comment.created:3", Topic: model.TopicCommentCreated},
+	}
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockIOutboxRepository(ctrl)
+	sink := mocks.NewMockIOutboxSink(ctrl)
+
+	s := service{
+		clock:        fixedClock(now),
+		outbox:       repo,
+		outboxSink:   sink,
+		outboxPolicy: backoff.Policy{InitialInterval: time.Second, Multiplier: 2},
+	}
+
+	repo.EXPECT().ClaimOutbox(gomock.Any(), now, outboxBatchSize, outboxClaimLease).Return(messages, nil)
+
+	// the second message fails, the third is left for the next batch to keep the order
+	gomock.InOrder(
+		sink.EXPECT().Publish(gomock.Any(), messages[0]).Return(nil),
+		repo.EXPECT().MarkOutboxPublished(gomock.Any(), messages[0].ID, now).Return(nil),
+		sink.EXPECT().Publish(gomock.Any(), messages[1]).Return(errors.New("redis is down")),
+		repo.EXPECT().MarkOutboxFailed(gomock.Any(), messages[1].ID, "redis is down", now.Add(2*time.Second)).Return(nil),
+	)
+
+	s.relayOutboxBatch(context.Background())
+}
+
+func Test_relayOutboxBatch_QueuesCommentWebhook(t *testing.T) {
+	now := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+	message := model.OutboxMessage{
+		ID:             uuid.New(),
+		IdempotencyKey: "comment.created:1",
+		Topic:          model.TopicCommentCreated,
+		Payload:        `{"id":"1","movie_id":4,"message":"nice"}`,
+		CreatedAt:      now.Add(-time.Minute),
+	}
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockIOutboxRepository(ctrl)
+	sink := mocks.NewMockIOutboxSink(ctrl)
+	webhooks := mocks.NewMockIWebhookRepository(ctrl)
+
+	s := service{
+		clock:        fixedClock(now),
+		outbox:       repo,
+		outboxSink:   sink,
+		outboxPolicy: backoff.Policy{InitialInterval: time.Second, Multiplier: 2},
+		webhooks:     webhooks,
+	}
+
+	repo.EXPECT().ClaimOutbox(gomock.Any(), now, outboxBatchSize, outboxClaimLease).Return([]model.OutboxMessage{message}, nil)
+	sink.EXPECT().Publish(gomock.Any(), message).Return(nil)
+	webhooks.EXPECT().GetSubscriptions(gomock.Any()).Return([]model.WebhookSubscription{{ID: uuid.New()}}, nil)
+	webhooks.EXPECT().AddDeliveries(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, deliveries []model.WebhookDelivery) error {
+		if len(deliveries) != 1 {
+			t.Fatalf("deliveries=%d | expected=1", len(deliveries))
+		}
+
+		delivery := deliveries[0]
+		if delivery.EventID != message.ID || delivery.EventType != model.EventCommentCreated {
+			t.Errorf("event=%s %s | expected=%s %s", delivery.EventType, delivery.EventID, model.EventCommentCreated, message.ID)
+		}
+		expected := `{"id":"` + message.ID.String() + `","type":"comment.created","created_at":"2023-02-01T11:59:00Z","data":` + string(message.Payload) + `}`
+		if string(delivery.Payload) != expected {
+			t.Errorf("payload=%s | expected=%s", delivery.Payload, expected)
+		}
+		return nil
+	})
+	repo.EXPECT().MarkOutboxPublished(gomock.Any(), message.ID, now).Return(nil)
+
+	s.relayOutboxBatch(context.Background())
+}
+
+func Test_pruneOutbox(t *testing.T) {
+	now := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+
+	ctrl := gomock.NewController(t)
+	repo := mocks.NewMockIOutboxRepository(ctrl)
+
+	s := service{
+		clock:           fixedClock(now),
+		outbox:          repo,
+		outboxRetention: 48 * time.Hour,
+	}
+
+	repo.EXPECT().DeletePublishedOutbox(gomock.Any(), now.Add(-48*time.Hour)).Return(int64(3), nil)
+	if err := s.pruneOutbox(context.Background()); err != nil {
+		t.Errorf("err=%v | expected=nil", err)
+	}
+
+	repo.EXPECT().DeletePublishedOutbox(gomock.Any(), gomock.Any()).Return(int64(0), errors.New("connection refused"))
+	if err := s.pruneOutbox(context.Background()); err == nil {
+		t.Errorf("err=nil | expected an error")
+	}
+}
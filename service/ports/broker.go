@@ -0,0 +1,17 @@
+package ports
+
+import (
+	"context"
+
+	"github.com/iamnator/movie-api/model"
+)
+
+//go:generate mockgen -source=broker.go -destination=./mocks/broker.go  -package=mocks github.com/iamnator/movie-api/service/ports ICommentBroker
+type ICommentBroker interface {
+	// Publish fans a new comment out to subscribers of its movie on every instance
+	Publish(ctx context.Context, comment model.CommentEventData) error
+	// Subscribe returns the comments published for movieID until cancel is
+	// called. The channel is closed on cancel, or early when the subscriber
+	// falls too far behind.
+	Subscribe(movieID int) (comments <-chan model.CommentEventData, cancel func())
+}
@@ -1,6 +1,16 @@
 package ports
 
-import "github.com/iamnator/movie-api/model"
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamnator/movie-api/model"
+)
+
+// ErrStaleFence is returned by the writes of ICache when their fencing token is
+// lower than one the cache already accepted, the lock they ran under was lost
+var ErrStaleFence = errors.New("fencing token is older than the last write")
 
 type GetCharacterFiler struct {
 	SortKey   string
@@ -8,13 +18,47 @@ type GetCharacterFiler struct {
 	Gender    string
 }
 
+// CharacterKey identifies the cached document of a character in a movie
+type CharacterKey struct {
+	MovieID     int
+	CharacterID int
+}
+
+// The writes take the fencing token of the lock the writer holds. The cache
+// keeps the highest token it accepted and rejects lower ones with
+// ErrStaleFence, so a refresh that lost its lock cannot overwrite the one that
+// took over. A zero token writes unfenced, for writers holding no lock.
+//
 //go:generate mockgen -source=cache.go -destination=./mocks/cache.go  -package=mocks github.com/iamnator/movie-api/service/ports ICache
 type ICache interface {
-	SetMovies([]model.MovieDetails) error
-	SetMovieByID(id int, movie model.MovieDetails) error
-	SetCharactersByMovieID(id int, characters []model.Character) error
+	SetMovies(ctx context.Context, fence int64, movies []model.MovieDetails) error
+	SetMovieByID(ctx context.Context, fence int64, id int, movie model.MovieDetails) error
+	SetCharactersByMovieID(ctx context.Context, fence int64, id int, characters []model.Character) error
+	// SetPlanets caches the planets, outside of the indexes
+	SetPlanets(ctx context.Context, fence int64, planets []model.Planet) error
+
+	GetMovies(ctx context.Context, page, pageSize int) ([]model.MovieDetails, int64, error)
+	GetMovieByID(ctx context.Context, id int) (*model.MovieDetails, error)
+	GetCharactersByMovieID(ctx context.Context, id int, page, pageSize int, filter GetCharacterFiler) ([]model.Character, int64, error)
+
+	// GetMovieVersions returns the swapi edited time of every cached movie by movie id
+	GetMovieVersions(ctx context.Context) (map[int]time.Time, error)
+	// GetCharacterVersions returns the swapi edited time of every cached character document
+	GetCharacterVersions(ctx context.Context) (map[CharacterKey]time.Time, error)
+
+	// SetMovieCommentCounts overwrites the comment count copied on the given
+	// movies; the api reads the counts from the comment repository
+	SetMovieCommentCounts(ctx context.Context, counts map[int]int64) error
+
+	DeleteMovies(ctx context.Context, fence int64, ids ...int) error
+	DeleteCharacters(ctx context.Context, fence int64, keys ...CharacterKey) error
+
+	// DocumentCounts returns how many documents each index holds, by index name
+	DocumentCounts(ctx context.Context) (map[string]int64, error)
 
-	GetMovies(page, pageSize int) ([]model.MovieDetails, int64, error)
-	GetMovieByID(id int) (*model.MovieDetails, error)
-	GetCharactersByMovieID(id int, page, pageSize int, filter GetCharacterFiler) ([]model.Character, int64, error)
+	Ping(ctx context.Context) error
+	// SetRefreshedAt records when a refresh of the cache last succeeded, on any instance
+	SetRefreshedAt(ctx context.Context, fence int64, at time.Time) error
+	// GetRefreshedAt returns the zero time when the cache was never refreshed
+	GetRefreshedAt(ctx context.Context) (time.Time, error)
 }
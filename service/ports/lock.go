@@ -0,0 +1,35 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/iamnator/movie-api/model"
+)
+
+// ErrLockNotAcquired is returned by ILocker.Acquire when another instance holds the lock
+var ErrLockNotAcquired = errors.New("lock is held by another instance")
+
+//go:generate mockgen -source=lock.go -destination=./mocks/lock.go  -package=mocks github.com/iamnator/movie-api/service/ports ILocker,ILease,IJobStore
+type ILocker interface {
+	// Acquire takes the named lease for ttl and keeps renewing it until it is released
+	Acquire(ctx context.Context, name string, ttl time.Duration) (ILease, error)
+}
+
+type ILease interface {
+	// Token is a fencing token, strictly increasing across acquisitions of the same lock
+	Token() int64
+	// Lost is closed when the lease could not be renewed and may be held by someone else
+	Lost() <-chan struct{}
+	Release(ctx context.Context) error
+}
+
+// IJobStore keeps the refresh jobs next to the refresh lock, so any instance
+// reports a job whichever ran it
+type IJobStore interface {
+	// SaveJob records job, replacing the record of the same id
+	SaveJob(ctx context.Context, job model.RefreshJob) error
+	// GetJob returns the record of the job, ErrNotFound once it expired
+	GetJob(ctx context.Context, id string) (model.RefreshJob, error)
+}
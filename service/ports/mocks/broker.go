@@ -0,0 +1,65 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: broker.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/iamnator/movie-api/model"
+)
+
+// MockICommentBroker is a mock of ICommentBroker interface.
+type MockICommentBroker struct {
+	ctrl     *gomock.Controller
+	recorder *MockICommentBrokerMockRecorder
+}
+
+// MockICommentBrokerMockRecorder is the mock recorder for MockICommentBroker.
+type MockICommentBrokerMockRecorder struct {
+	mock *MockICommentBroker
+}
+
+// NewMockICommentBroker creates a new mock instance.
+func NewMockICommentBroker(ctrl *gomock.Controller) *MockICommentBroker {
+	mock := &MockICommentBroker{ctrl: ctrl}
+	mock.recorder = &MockICommentBrokerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockICommentBroker) EXPECT() *MockICommentBrokerMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockICommentBroker) Publish(ctx context.Context, comment model.CommentEventData) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, comment)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockICommentBrokerMockRecorder) Publish(ctx, comment interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockICommentBroker)(nil).Publish), ctx, comment)
+}
+
+// Subscribe mocks base method.
+func (m *MockICommentBroker) Subscribe(movieID int) (<-chan model.CommentEventData, func()) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Subscribe", movieID)
+	ret0, _ := ret[0].(<-chan model.CommentEventData)
+	ret1, _ := ret[1].(func())
+	return ret0, ret1
+}
+
+// Subscribe indicates an expected call of Subscribe.
+func (mr *MockICommentBrokerMockRecorder) Subscribe(movieID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Subscribe", reflect.TypeOf((*MockICommentBroker)(nil).Subscribe), movieID)
+}
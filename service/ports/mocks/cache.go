@@ -5,7 +5,9 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	model "github.com/iamnator/movie-api/model"
@@ -35,10 +37,78 @@ func (m *MockICache) EXPECT() *MockICacheMockRecorder {
 	return m.recorder
 }
 
+// DeleteCharacters mocks base method.
+func (m *MockICache) DeleteCharacters(ctx context.Context, fence int64, keys ...ports.CharacterKey) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, fence}
+	for _, a := range keys {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteCharacters", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteCharacters indicates an expected call of DeleteCharacters.
+func (mr *MockICacheMockRecorder) DeleteCharacters(ctx, fence interface{}, keys ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, fence}, keys...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteCharacters", reflect.TypeOf((*MockICache)(nil).DeleteCharacters), varargs...)
+}
+
+// DeleteMovies mocks base method.
+func (m *MockICache) DeleteMovies(ctx context.Context, fence int64, ids ...int) error {
+	m.ctrl.T.Helper()
+	varargs := []interface{}{ctx, fence}
+	for _, a := range ids {
+		varargs = append(varargs, a)
+	}
+	ret := m.ctrl.Call(m, "DeleteMovies", varargs...)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// DeleteMovies indicates an expected call of DeleteMovies.
+func (mr *MockICacheMockRecorder) DeleteMovies(ctx, fence interface{}, ids ...interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	varargs := append([]interface{}{ctx, fence}, ids...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteMovies", reflect.TypeOf((*MockICache)(nil).DeleteMovies), varargs...)
+}
+
+// DocumentCounts mocks base method.
+func (m *MockICache) DocumentCounts(ctx context.Context) (map[string]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DocumentCounts", ctx)
+	ret0, _ := ret[0].(map[string]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DocumentCounts indicates an expected call of DocumentCounts.
+func (mr *MockICacheMockRecorder) DocumentCounts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DocumentCounts", reflect.TypeOf((*MockICache)(nil).DocumentCounts), ctx)
+}
+
+// GetCharacterVersions mocks base method.
+func (m *MockICache) GetCharacterVersions(ctx context.Context) (map[ports.CharacterKey]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCharacterVersions", ctx)
+	ret0, _ := ret[0].(map[ports.CharacterKey]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCharacterVersions indicates an expected call of GetCharacterVersions.
+func (mr *MockICacheMockRecorder) GetCharacterVersions(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCharacterVersions", reflect.TypeOf((*MockICache)(nil).GetCharacterVersions), ctx)
+}
+
 // GetCharactersByMovieID mocks base method.
-func (m *MockICache) GetCharactersByMovieID(id, page, pageSize int, filter ports.GetCharacterFiler) ([]model.Character, int64, error) {
+func (m *MockICache) GetCharactersByMovieID(ctx context.Context, id, page, pageSize int, filter ports.GetCharacterFiler) ([]model.Character, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCharactersByMovieID", id, page, pageSize, filter)
+	ret := m.ctrl.Call(m, "GetCharactersByMovieID", ctx, id, page, pageSize, filter)
 	ret0, _ := ret[0].([]model.Character)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -46,30 +116,45 @@ func (m *MockICache) GetCharactersByMovieID(id, page, pageSize int, filter ports
 }
 
 // GetCharactersByMovieID indicates an expected call of GetCharactersByMovieID.
-func (mr *MockICacheMockRecorder) GetCharactersByMovieID(id, page, pageSize, filter interface{}) *gomock.Call {
+func (mr *MockICacheMockRecorder) GetCharactersByMovieID(ctx, id, page, pageSize, filter interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCharactersByMovieID", reflect.TypeOf((*MockICache)(nil).GetCharactersByMovieID), id, page, pageSize, filter)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCharactersByMovieID", reflect.TypeOf((*MockICache)(nil).GetCharactersByMovieID), ctx, id, page, pageSize, filter)
 }
 
 // GetMovieByID mocks base method.
-func (m *MockICache) GetMovieByID(id int) (*model.MovieDetails, error) {
+func (m *MockICache) GetMovieByID(ctx context.Context, id int) (*model.MovieDetails, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMovieByID", id)
+	ret := m.ctrl.Call(m, "GetMovieByID", ctx, id)
 	ret0, _ := ret[0].(*model.MovieDetails)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetMovieByID indicates an expected call of GetMovieByID.
-func (mr *MockICacheMockRecorder) GetMovieByID(id interface{}) *gomock.Call {
+func (mr *MockICacheMockRecorder) GetMovieByID(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovieByID", reflect.TypeOf((*MockICache)(nil).GetMovieByID), ctx, id)
+}
+
+// GetMovieVersions mocks base method.
+func (m *MockICache) GetMovieVersions(ctx context.Context) (map[int]time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMovieVersions", ctx)
+	ret0, _ := ret[0].(map[int]time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMovieVersions indicates an expected call of GetMovieVersions.
+func (mr *MockICacheMockRecorder) GetMovieVersions(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovieByID", reflect.TypeOf((*MockICache)(nil).GetMovieByID), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovieVersions", reflect.TypeOf((*MockICache)(nil).GetMovieVersions), ctx)
 }
 
 // GetMovies mocks base method.
-func (m *MockICache) GetMovies(page, pageSize int) ([]model.MovieDetails, int64, error) {
+func (m *MockICache) GetMovies(ctx context.Context, page, pageSize int) ([]model.MovieDetails, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetMovies", page, pageSize)
+	ret := m.ctrl.Call(m, "GetMovies", ctx, page, pageSize)
 	ret0, _ := ret[0].([]model.MovieDetails)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -77,49 +162,120 @@ func (m *MockICache) GetMovies(page, pageSize int) ([]model.MovieDetails, int64,
 }
 
 // GetMovies indicates an expected call of GetMovies.
-func (mr *MockICacheMockRecorder) GetMovies(page, pageSize interface{}) *gomock.Call {
+func (mr *MockICacheMockRecorder) GetMovies(ctx, page, pageSize interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovies", reflect.TypeOf((*MockICache)(nil).GetMovies), page, pageSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovies", reflect.TypeOf((*MockICache)(nil).GetMovies), ctx, page, pageSize)
+}
+
+// GetRefreshedAt mocks base method.
+func (m *MockICache) GetRefreshedAt(ctx context.Context) (time.Time, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetRefreshedAt", ctx)
+	ret0, _ := ret[0].(time.Time)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetRefreshedAt indicates an expected call of GetRefreshedAt.
+func (mr *MockICacheMockRecorder) GetRefreshedAt(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetRefreshedAt", reflect.TypeOf((*MockICache)(nil).GetRefreshedAt), ctx)
+}
+
+// Ping mocks base method.
+func (m *MockICache) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockICacheMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockICache)(nil).Ping), ctx)
 }
 
 // SetCharactersByMovieID mocks base method.
-func (m *MockICache) SetCharactersByMovieID(id int, characters []model.Character) error {
+func (m *MockICache) SetCharactersByMovieID(ctx context.Context, fence int64, id int, characters []model.Character) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetCharactersByMovieID", id, characters)
+	ret := m.ctrl.Call(m, "SetCharactersByMovieID", ctx, fence, id, characters)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetCharactersByMovieID indicates an expected call of SetCharactersByMovieID.
-func (mr *MockICacheMockRecorder) SetCharactersByMovieID(id, characters interface{}) *gomock.Call {
+func (mr *MockICacheMockRecorder) SetCharactersByMovieID(ctx, fence, id, characters interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCharactersByMovieID", reflect.TypeOf((*MockICache)(nil).SetCharactersByMovieID), id, characters)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetCharactersByMovieID", reflect.TypeOf((*MockICache)(nil).SetCharactersByMovieID), ctx, fence, id, characters)
 }
 
 // SetMovieByID mocks base method.
-func (m *MockICache) SetMovieByID(id int, movie model.MovieDetails) error {
+func (m *MockICache) SetMovieByID(ctx context.Context, fence int64, id int, movie model.MovieDetails) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetMovieByID", id, movie)
+	ret := m.ctrl.Call(m, "SetMovieByID", ctx, fence, id, movie)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetMovieByID indicates an expected call of SetMovieByID.
-func (mr *MockICacheMockRecorder) SetMovieByID(id, movie interface{}) *gomock.Call {
+func (mr *MockICacheMockRecorder) SetMovieByID(ctx, fence, id, movie interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMovieByID", reflect.TypeOf((*MockICache)(nil).SetMovieByID), ctx, fence, id, movie)
+}
+
+// SetMovieCommentCounts mocks base method.
+func (m *MockICache) SetMovieCommentCounts(ctx context.Context, counts map[int]int64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetMovieCommentCounts", ctx, counts)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetMovieCommentCounts indicates an expected call of SetMovieCommentCounts.
+func (mr *MockICacheMockRecorder) SetMovieCommentCounts(ctx, counts interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMovieByID", reflect.TypeOf((*MockICache)(nil).SetMovieByID), id, movie)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMovieCommentCounts", reflect.TypeOf((*MockICache)(nil).SetMovieCommentCounts), ctx, counts)
 }
 
 // SetMovies mocks base method.
-func (m *MockICache) SetMovies(arg0 []model.MovieDetails) error {
+func (m *MockICache) SetMovies(ctx context.Context, fence int64, movies []model.MovieDetails) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "SetMovies", arg0)
+	ret := m.ctrl.Call(m, "SetMovies", ctx, fence, movies)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // SetMovies indicates an expected call of SetMovies.
-func (mr *MockICacheMockRecorder) SetMovies(arg0 interface{}) *gomock.Call {
+func (mr *MockICacheMockRecorder) SetMovies(ctx, fence, movies interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMovies", reflect.TypeOf((*MockICache)(nil).SetMovies), ctx, fence, movies)
+}
+
+// SetPlanets mocks base method.
+func (m *MockICache) SetPlanets(ctx context.Context, fence int64, planets []model.Planet) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPlanets", ctx, fence, planets)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPlanets indicates an expected call of SetPlanets.
+func (mr *MockICacheMockRecorder) SetPlanets(ctx, fence, planets interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPlanets", reflect.TypeOf((*MockICache)(nil).SetPlanets), ctx, fence, planets)
+}
+
+// SetRefreshedAt mocks base method.
+func (m *MockICache) SetRefreshedAt(ctx context.Context, fence int64, at time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRefreshedAt", ctx, fence, at)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRefreshedAt indicates an expected call of SetRefreshedAt.
+func (mr *MockICacheMockRecorder) SetRefreshedAt(ctx, fence, at interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetMovies", reflect.TypeOf((*MockICache)(nil).SetMovies), arg0)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRefreshedAt", reflect.TypeOf((*MockICache)(nil).SetRefreshedAt), ctx, fence, at)
 }
@@ -0,0 +1,170 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: lock.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	model "github.com/iamnator/movie-api/model"
+	ports "github.com/iamnator/movie-api/service/ports"
+)
+
+// MockILocker is a mock of ILocker interface.
+type MockILocker struct {
+	ctrl     *gomock.Controller
+	recorder *MockILockerMockRecorder
+}
+
+// MockILockerMockRecorder is the mock recorder for MockILocker.
+type MockILockerMockRecorder struct {
+	mock *MockILocker
+}
+
+// NewMockILocker creates a new mock instance.
+func NewMockILocker(ctrl *gomock.Controller) *MockILocker {
+	mock := &MockILocker{ctrl: ctrl}
+	mock.recorder = &MockILockerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILocker) EXPECT() *MockILockerMockRecorder {
+	return m.recorder
+}
+
+// Acquire mocks base method.
+func (m *MockILocker) Acquire(ctx context.Context, name string, ttl time.Duration) (ports.ILease, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Acquire", ctx, name, ttl)
+	ret0, _ := ret[0].(ports.ILease)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Acquire indicates an expected call of Acquire.
+func (mr *MockILockerMockRecorder) Acquire(ctx, name, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Acquire", reflect.TypeOf((*MockILocker)(nil).Acquire), ctx, name, ttl)
+}
+
+// MockILease is a mock of ILease interface.
+type MockILease struct {
+	ctrl     *gomock.Controller
+	recorder *MockILeaseMockRecorder
+}
+
+// MockILeaseMockRecorder is the mock recorder for MockILease.
+type MockILeaseMockRecorder struct {
+	mock *MockILease
+}
+
+// NewMockILease creates a new mock instance.
+func NewMockILease(ctrl *gomock.Controller) *MockILease {
+	mock := &MockILease{ctrl: ctrl}
+	mock.recorder = &MockILeaseMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockILease) EXPECT() *MockILeaseMockRecorder {
+	return m.recorder
+}
+
+// Lost mocks base method.
+func (m *MockILease) Lost() <-chan struct{} {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Lost")
+	ret0, _ := ret[0].(<-chan struct{})
+	return ret0
+}
+
+// Lost indicates an expected call of Lost.
+func (mr *MockILeaseMockRecorder) Lost() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Lost", reflect.TypeOf((*MockILease)(nil).Lost))
+}
+
+// Release mocks base method.
+func (m *MockILease) Release(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Release", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Release indicates an expected call of Release.
+func (mr *MockILeaseMockRecorder) Release(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Release", reflect.TypeOf((*MockILease)(nil).Release), ctx)
+}
+
+// Token mocks base method.
+func (m *MockILease) Token() int64 {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Token")
+	ret0, _ := ret[0].(int64)
+	return ret0
+}
+
+// Token indicates an expected call of Token.
+func (mr *MockILeaseMockRecorder) Token() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Token", reflect.TypeOf((*MockILease)(nil).Token))
+}
+
+// MockIJobStore is a mock of IJobStore interface.
+type MockIJobStore struct {
+	ctrl     *gomock.Controller
+	recorder *MockIJobStoreMockRecorder
+}
+
+// MockIJobStoreMockRecorder is the mock recorder for MockIJobStore.
+type MockIJobStoreMockRecorder struct {
+	mock *MockIJobStore
+}
+
+// NewMockIJobStore creates a new mock instance.
+func NewMockIJobStore(ctrl *gomock.Controller) *MockIJobStore {
+	mock := &MockIJobStore{ctrl: ctrl}
+	mock.recorder = &MockIJobStoreMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIJobStore) EXPECT() *MockIJobStoreMockRecorder {
+	return m.recorder
+}
+
+// GetJob mocks base method.
+func (m *MockIJobStore) GetJob(ctx context.Context, id string) (model.RefreshJob, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetJob", ctx, id)
+	ret0, _ := ret[0].(model.RefreshJob)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetJob indicates an expected call of GetJob.
+func (mr *MockIJobStoreMockRecorder) GetJob(ctx, id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetJob", reflect.TypeOf((*MockIJobStore)(nil).GetJob), ctx, id)
+}
+
+// SaveJob mocks base method.
+func (m *MockIJobStore) SaveJob(ctx context.Context, job model.RefreshJob) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SaveJob", ctx, job)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SaveJob indicates an expected call of SaveJob.
+func (mr *MockIJobStoreMockRecorder) SaveJob(ctx, job interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SaveJob", reflect.TypeOf((*MockIJobStore)(nil).SaveJob), ctx, job)
+}
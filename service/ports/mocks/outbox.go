@@ -0,0 +1,133 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: outbox.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+	uuid "github.com/google/uuid"
+	model "github.com/iamnator/movie-api/model"
+)
+
+// MockIOutboxRepository is a mock of IOutboxRepository interface.
+type MockIOutboxRepository struct {
+	ctrl     *gomock.Controller
+	recorder *MockIOutboxRepositoryMockRecorder
+}
+
+// MockIOutboxRepositoryMockRecorder is the mock recorder for MockIOutboxRepository.
+type MockIOutboxRepositoryMockRecorder struct {
+	mock *MockIOutboxRepository
+}
+
+// NewMockIOutboxRepository creates a new mock instance.
+func NewMockIOutboxRepository(ctrl *gomock.Controller) *MockIOutboxRepository {
+	mock := &MockIOutboxRepository{ctrl: ctrl}
+	mock.recorder = &MockIOutboxRepositoryMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIOutboxRepository) EXPECT() *MockIOutboxRepositoryMockRecorder {
+	return m.recorder
+}
+
+// ClaimOutbox mocks base method.
+func (m *MockIOutboxRepository) ClaimOutbox(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.OutboxMessage, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ClaimOutbox", ctx, now, limit, lease)
+	ret0, _ := ret[0].([]model.OutboxMessage)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ClaimOutbox indicates an expected call of ClaimOutbox.
+func (mr *MockIOutboxRepositoryMockRecorder) ClaimOutbox(ctx, now, limit, lease interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ClaimOutbox", reflect.TypeOf((*MockIOutboxRepository)(nil).ClaimOutbox), ctx, now, limit, lease)
+}
+
+// DeletePublishedOutbox mocks base method.
+func (m *MockIOutboxRepository) DeletePublishedOutbox(ctx context.Context, before time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "DeletePublishedOutbox", ctx, before)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// DeletePublishedOutbox indicates an expected call of DeletePublishedOutbox.
+func (mr *MockIOutboxRepositoryMockRecorder) DeletePublishedOutbox(ctx, before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeletePublishedOutbox", reflect.TypeOf((*MockIOutboxRepository)(nil).DeletePublishedOutbox), ctx, before)
+}
+
+// MarkOutboxFailed mocks base method.
+func (m *MockIOutboxRepository) MarkOutboxFailed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxFailed", ctx, id, reason, retryAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxFailed indicates an expected call of MarkOutboxFailed.
+func (mr *MockIOutboxRepositoryMockRecorder) MarkOutboxFailed(ctx, id, reason, retryAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxFailed", reflect.TypeOf((*MockIOutboxRepository)(nil).MarkOutboxFailed), ctx, id, reason, retryAt)
+}
+
+// MarkOutboxPublished mocks base method.
+func (m *MockIOutboxRepository) MarkOutboxPublished(ctx context.Context, id uuid.UUID, publishedAt time.Time) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MarkOutboxPublished", ctx, id, publishedAt)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// MarkOutboxPublished indicates an expected call of MarkOutboxPublished.
+func (mr *MockIOutboxRepositoryMockRecorder) MarkOutboxPublished(ctx, id, publishedAt interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MarkOutboxPublished", reflect.TypeOf((*MockIOutboxRepository)(nil).MarkOutboxPublished), ctx, id, publishedAt)
+}
+
+// MockIOutboxSink is a mock of IOutboxSink interface.
+type MockIOutboxSink struct {
+	ctrl     *gomock.Controller
+	recorder *MockIOutboxSinkMockRecorder
+}
+
+// MockIOutboxSinkMockRecorder is the mock recorder for MockIOutboxSink.
+type MockIOutboxSinkMockRecorder struct {
+	mock *MockIOutboxSink
+}
+
+// NewMockIOutboxSink creates a new mock instance.
+func NewMockIOutboxSink(ctrl *gomock.Controller) *MockIOutboxSink {
+	mock := &MockIOutboxSink{ctrl: ctrl}
+	mock.recorder = &MockIOutboxSinkMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIOutboxSink) EXPECT() *MockIOutboxSinkMockRecorder {
+	return m.recorder
+}
+
+// Publish mocks base method.
+func (m *MockIOutboxSink) Publish(ctx context.Context, message model.OutboxMessage) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Publish", ctx, message)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Publish indicates an expected call of Publish.
+func (mr *MockIOutboxSinkMockRecorder) Publish(ctx, message interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Publish", reflect.TypeOf((*MockIOutboxSink)(nil).Publish), ctx, message)
+}
@@ -0,0 +1,79 @@
+// Code generated by MockGen. DO NOT EDIT.
+// Source: presence.go
+
+// Package mocks is a generated GoMock package.
+package mocks
+
+import (
+	context "context"
+	reflect "reflect"
+	time "time"
+
+	gomock "github.com/golang/mock/gomock"
+)
+
+// MockIPresence is a mock of IPresence interface.
+type MockIPresence struct {
+	ctrl     *gomock.Controller
+	recorder *MockIPresenceMockRecorder
+}
+
+// MockIPresenceMockRecorder is the mock recorder for MockIPresence.
+type MockIPresenceMockRecorder struct {
+	mock *MockIPresence
+}
+
+// NewMockIPresence creates a new mock instance.
+func NewMockIPresence(ctrl *gomock.Controller) *MockIPresence {
+	mock := &MockIPresence{ctrl: ctrl}
+	mock.recorder = &MockIPresenceMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockIPresence) EXPECT() *MockIPresenceMockRecorder {
+	return m.recorder
+}
+
+// Count mocks base method.
+func (m *MockIPresence) Count(ctx context.Context, movieID int) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Count", ctx, movieID)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Count indicates an expected call of Count.
+func (mr *MockIPresenceMockRecorder) Count(ctx, movieID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Count", reflect.TypeOf((*MockIPresence)(nil).Count), ctx, movieID)
+}
+
+// Join mocks base method.
+func (m *MockIPresence) Join(ctx context.Context, movieID int, session string, ttl time.Duration) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Join", ctx, movieID, session, ttl)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Join indicates an expected call of Join.
+func (mr *MockIPresenceMockRecorder) Join(ctx, movieID, session, ttl interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Join", reflect.TypeOf((*MockIPresence)(nil).Join), ctx, movieID, session, ttl)
+}
+
+// Leave mocks base method.
+func (m *MockIPresence) Leave(ctx context.Context, movieID int, session string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Leave", ctx, movieID, session)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Leave indicates an expected call of Leave.
+func (mr *MockIPresenceMockRecorder) Leave(ctx, movieID, session interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Leave", reflect.TypeOf((*MockIPresence)(nil).Leave), ctx, movieID, session)
+}
@@ -5,7 +5,9 @@
 package mocks
 
 import (
+	context "context"
 	reflect "reflect"
+	time "time"
 
 	gomock "github.com/golang/mock/gomock"
 	uuid "github.com/google/uuid"
@@ -36,53 +38,98 @@ func (m *MockICommentRepository) EXPECT() *MockICommentRepositoryMockRecorder {
 }
 
 // AddComment mocks base method.
-func (m *MockICommentRepository) AddComment(comment model.Comment) error {
+func (m *MockICommentRepository) AddComment(ctx context.Context, comment *model.Comment) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "AddComment", comment)
+	ret := m.ctrl.Call(m, "AddComment", ctx, comment)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // AddComment indicates an expected call of AddComment.
-func (mr *MockICommentRepositoryMockRecorder) AddComment(comment interface{}) *gomock.Call {
+func (mr *MockICommentRepositoryMockRecorder) AddComment(ctx, comment interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockICommentRepository)(nil).AddComment), comment)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AddComment", reflect.TypeOf((*MockICommentRepository)(nil).AddComment), ctx, comment)
+}
+
+// AnonymiseIPAddrs mocks base method.
+func (m *MockICommentRepository) AnonymiseIPAddrs(ctx context.Context, createdBefore time.Time) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "AnonymiseIPAddrs", ctx, createdBefore)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// AnonymiseIPAddrs indicates an expected call of AnonymiseIPAddrs.
+func (mr *MockICommentRepositoryMockRecorder) AnonymiseIPAddrs(ctx, createdBefore interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "AnonymiseIPAddrs", reflect.TypeOf((*MockICommentRepository)(nil).AnonymiseIPAddrs), ctx, createdBefore)
 }
 
 // GetComment mocks base method.
-func (m *MockICommentRepository) GetComment(commentID uuid.UUID) (*model.Comment, error) {
+func (m *MockICommentRepository) GetComment(ctx context.Context, commentID uuid.UUID) (*model.Comment, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetComment", commentID)
+	ret := m.ctrl.Call(m, "GetComment", ctx, commentID)
 	ret0, _ := ret[0].(*model.Comment)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetComment indicates an expected call of GetComment.
-func (mr *MockICommentRepositoryMockRecorder) GetComment(commentID interface{}) *gomock.Call {
+func (mr *MockICommentRepositoryMockRecorder) GetComment(ctx, commentID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComment", reflect.TypeOf((*MockICommentRepository)(nil).GetComment), commentID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetComment", reflect.TypeOf((*MockICommentRepository)(nil).GetComment), ctx, commentID)
 }
 
 // GetCommentCountByMovieID mocks base method.
-func (m *MockICommentRepository) GetCommentCountByMovieID(movieID int) (int64, error) {
+func (m *MockICommentRepository) GetCommentCountByMovieID(ctx context.Context, movieID int) (int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommentCountByMovieID", movieID)
+	ret := m.ctrl.Call(m, "GetCommentCountByMovieID", ctx, movieID)
 	ret0, _ := ret[0].(int64)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
 // GetCommentCountByMovieID indicates an expected call of GetCommentCountByMovieID.
-func (mr *MockICommentRepositoryMockRecorder) GetCommentCountByMovieID(movieID interface{}) *gomock.Call {
+func (mr *MockICommentRepositoryMockRecorder) GetCommentCountByMovieID(ctx, movieID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentCountByMovieID", reflect.TypeOf((*MockICommentRepository)(nil).GetCommentCountByMovieID), ctx, movieID)
+}
+
+// GetCommentCounts mocks base method.
+func (m *MockICommentRepository) GetCommentCounts(ctx context.Context) (map[int]int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentCounts", ctx)
+	ret0, _ := ret[0].(map[int]int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentCounts indicates an expected call of GetCommentCounts.
+func (mr *MockICommentRepositoryMockRecorder) GetCommentCounts(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentCounts", reflect.TypeOf((*MockICommentRepository)(nil).GetCommentCounts), ctx)
+}
+
+// GetCommentsAfter mocks base method.
+func (m *MockICommentRepository) GetCommentsAfter(ctx context.Context, movieID int, afterID uuid.UUID, limit int) ([]model.Comment, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCommentsAfter", ctx, movieID, afterID, limit)
+	ret0, _ := ret[0].([]model.Comment)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCommentsAfter indicates an expected call of GetCommentsAfter.
+func (mr *MockICommentRepositoryMockRecorder) GetCommentsAfter(ctx, movieID, afterID, limit interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentCountByMovieID", reflect.TypeOf((*MockICommentRepository)(nil).GetCommentCountByMovieID), movieID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsAfter", reflect.TypeOf((*MockICommentRepository)(nil).GetCommentsAfter), ctx, movieID, afterID, limit)
 }
 
 // GetCommentsByID mocks base method.
-func (m *MockICommentRepository) GetCommentsByID(commentID ...uuid.UUID) ([]model.Comment, error) {
+func (m *MockICommentRepository) GetCommentsByID(ctx context.Context, commentID ...uuid.UUID) ([]model.Comment, error) {
 	m.ctrl.T.Helper()
-	varargs := []interface{}{}
+	varargs := []interface{}{ctx}
 	for _, a := range commentID {
 		varargs = append(varargs, a)
 	}
@@ -93,15 +140,16 @@ func (m *MockICommentRepository) GetCommentsByID(commentID ...uuid.UUID) ([]mode
 }
 
 // GetCommentsByID indicates an expected call of GetCommentsByID.
-func (mr *MockICommentRepositoryMockRecorder) GetCommentsByID(commentID ...interface{}) *gomock.Call {
+func (mr *MockICommentRepositoryMockRecorder) GetCommentsByID(ctx interface{}, commentID ...interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByID", reflect.TypeOf((*MockICommentRepository)(nil).GetCommentsByID), commentID...)
+	varargs := append([]interface{}{ctx}, commentID...)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByID", reflect.TypeOf((*MockICommentRepository)(nil).GetCommentsByID), varargs...)
 }
 
 // GetCommentsByIPAddr mocks base method.
-func (m *MockICommentRepository) GetCommentsByIPAddr(ipAddr string, page, pageSize int) ([]model.Comment, int64, error) {
+func (m *MockICommentRepository) GetCommentsByIPAddr(ctx context.Context, ipAddr string, page, pageSize int) ([]model.Comment, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommentsByIPAddr", ipAddr, page, pageSize)
+	ret := m.ctrl.Call(m, "GetCommentsByIPAddr", ctx, ipAddr, page, pageSize)
 	ret0, _ := ret[0].([]model.Comment)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -109,15 +157,15 @@ func (m *MockICommentRepository) GetCommentsByIPAddr(ipAddr string, page, pageSi
 }
 
 // GetCommentsByIPAddr indicates an expected call of GetCommentsByIPAddr.
-func (mr *MockICommentRepositoryMockRecorder) GetCommentsByIPAddr(ipAddr, page, pageSize interface{}) *gomock.Call {
+func (mr *MockICommentRepositoryMockRecorder) GetCommentsByIPAddr(ctx, ipAddr, page, pageSize interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByIPAddr", reflect.TypeOf((*MockICommentRepository)(nil).GetCommentsByIPAddr), ipAddr, page, pageSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByIPAddr", reflect.TypeOf((*MockICommentRepository)(nil).GetCommentsByIPAddr), ctx, ipAddr, page, pageSize)
 }
 
 // GetCommentsByMovieID mocks base method.
-func (m *MockICommentRepository) GetCommentsByMovieID(movieID, page, pageSize int) ([]model.Comment, int64, error) {
+func (m *MockICommentRepository) GetCommentsByMovieID(ctx context.Context, movieID, page, pageSize int) ([]model.Comment, int64, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetCommentsByMovieID", movieID, page, pageSize)
+	ret := m.ctrl.Call(m, "GetCommentsByMovieID", ctx, movieID, page, pageSize)
 	ret0, _ := ret[0].([]model.Comment)
 	ret1, _ := ret[1].(int64)
 	ret2, _ := ret[2].(error)
@@ -125,7 +173,36 @@ func (m *MockICommentRepository) GetCommentsByMovieID(movieID, page, pageSize in
 }
 
 // GetCommentsByMovieID indicates an expected call of GetCommentsByMovieID.
-func (mr *MockICommentRepositoryMockRecorder) GetCommentsByMovieID(movieID, page, pageSize interface{}) *gomock.Call {
+func (mr *MockICommentRepositoryMockRecorder) GetCommentsByMovieID(ctx, movieID, page, pageSize interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByMovieID", reflect.TypeOf((*MockICommentRepository)(nil).GetCommentsByMovieID), ctx, movieID, page, pageSize)
+}
+
+// Ping mocks base method.
+func (m *MockICommentRepository) Ping(ctx context.Context) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Ping", ctx)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Ping indicates an expected call of Ping.
+func (mr *MockICommentRepositoryMockRecorder) Ping(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Ping", reflect.TypeOf((*MockICommentRepository)(nil).Ping), ctx)
+}
+
+// SchemaVersion mocks base method.
+func (m *MockICommentRepository) SchemaVersion(ctx context.Context) (model.SchemaVersion, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SchemaVersion", ctx)
+	ret0, _ := ret[0].(model.SchemaVersion)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SchemaVersion indicates an expected call of SchemaVersion.
+func (mr *MockICommentRepositoryMockRecorder) SchemaVersion(ctx interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCommentsByMovieID", reflect.TypeOf((*MockICommentRepository)(nil).GetCommentsByMovieID), movieID, page, pageSize)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SchemaVersion", reflect.TypeOf((*MockICommentRepository)(nil).SchemaVersion), ctx)
 }
@@ -74,3 +74,93 @@ func (mr *MockISwapiMockRecorder) GetFilms(ctx interface{}, id ...interface{}) *
 	varargs := append([]interface{}{ctx}, id...)
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetFilms", reflect.TypeOf((*MockISwapi)(nil).GetFilms), varargs...)
 }
+
+// GetPlanets mocks base method.
+func (m *MockISwapi) GetPlanets(ctx context.Context) ([]lib.Planet, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPlanets", ctx)
+	ret0, _ := ret[0].([]lib.Planet)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPlanets indicates an expected call of GetPlanets.
+func (mr *MockISwapiMockRecorder) GetPlanets(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPlanets", reflect.TypeOf((*MockISwapi)(nil).GetPlanets), ctx)
+}
+
+// MockICircuitBreaker is a mock of ICircuitBreaker interface.
+type MockICircuitBreaker struct {
+	ctrl     *gomock.Controller
+	recorder *MockICircuitBreakerMockRecorder
+}
+
+// MockICircuitBreakerMockRecorder is the mock recorder for MockICircuitBreaker.
+type MockICircuitBreakerMockRecorder struct {
+	mock *MockICircuitBreaker
+}
+
+// NewMockICircuitBreaker creates a new mock instance.
+func NewMockICircuitBreaker(ctrl *gomock.Controller) *MockICircuitBreaker {
+	mock := &MockICircuitBreaker{ctrl: ctrl}
+	mock.recorder = &MockICircuitBreakerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockICircuitBreaker) EXPECT() *MockICircuitBreakerMockRecorder {
+	return m.recorder
+}
+
+// State mocks base method.
+func (m *MockICircuitBreaker) State() string {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "State")
+	ret0, _ := ret[0].(string)
+	return ret0
+}
+
+// State indicates an expected call of State.
+func (mr *MockICircuitBreakerMockRecorder) State() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "State", reflect.TypeOf((*MockICircuitBreaker)(nil).State))
+}
+
+// MockISwapiTracker is a mock of ISwapiTracker interface.
+type MockISwapiTracker struct {
+	ctrl     *gomock.Controller
+	recorder *MockISwapiTrackerMockRecorder
+}
+
+// MockISwapiTrackerMockRecorder is the mock recorder for MockISwapiTracker.
+type MockISwapiTrackerMockRecorder struct {
+	mock *MockISwapiTracker
+}
+
+// NewMockISwapiTracker creates a new mock instance.
+func NewMockISwapiTracker(ctrl *gomock.Controller) *MockISwapiTracker {
+	mock := &MockISwapiTracker{ctrl: ctrl}
+	mock.recorder = &MockISwapiTrackerMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockISwapiTracker) EXPECT() *MockISwapiTrackerMockRecorder {
+	return m.recorder
+}
+
+// Track mocks base method.
+func (m *MockISwapiTracker) Track(ctx context.Context) (context.Context, func() []string) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Track", ctx)
+	ret0, _ := ret[0].(context.Context)
+	ret1, _ := ret[1].(func() []string)
+	return ret0, ret1
+}
+
+// Track indicates an expected call of Track.
+func (mr *MockISwapiTrackerMockRecorder) Track(ctx interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Track", reflect.TypeOf((*MockISwapiTracker)(nil).Track), ctx)
+}
@@ -0,0 +1,28 @@
+package ports
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/model"
+)
+
+//go:generate mockgen -source=outbox.go -destination=./mocks/outbox.go  -package=mocks github.com/iamnator/movie-api/service/ports IOutboxRepository,IOutboxSink
+type IOutboxRepository interface {
+	// ClaimOutbox returns up to limit unpublished messages, oldest first, that
+	// no other relay claimed, and claims them for lease
+	ClaimOutbox(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.OutboxMessage, error)
+	MarkOutboxPublished(ctx context.Context, id uuid.UUID, publishedAt time.Time) error
+	// MarkOutboxFailed records a failed attempt; the message is claimable again from retryAt
+	MarkOutboxFailed(ctx context.Context, id uuid.UUID, reason string, retryAt time.Time) error
+	// DeletePublishedOutbox deletes the messages published before, returning how many
+	DeletePublishedOutbox(ctx context.Context, before time.Time) (int64, error)
+}
+
+type IOutboxSink interface {
+	// Publish hands the message to consumers. It may be called more than once
+	// for the same message.
+	Publish(ctx context.Context, message model.OutboxMessage) error
+}
@@ -0,0 +1,15 @@
+package ports
+
+import (
+	"context"
+	"time"
+)
+
+//go:generate mockgen -source=presence.go -destination=./mocks/presence.go  -package=mocks github.com/iamnator/movie-api/service/ports IPresence
+type IPresence interface {
+	// Join marks session as viewing movieID for ttl; call it again to stay
+	Join(ctx context.Context, movieID int, session string, ttl time.Duration) error
+	Leave(ctx context.Context, movieID int, session string) error
+	// Count returns how many sessions view movieID across every instance
+	Count(ctx context.Context, movieID int) (int64, error)
+}
@@ -1,16 +1,30 @@
 package ports
 
 import (
+	"context"
+	"time"
+
 	"github.com/google/uuid"
 	"github.com/iamnator/movie-api/model"
 )
 
 //go:generate mockgen -source=repository.go -destination=./mocks/repository.go  -package=mocks github.com/iamnator/movie-api/service/ports ICommentRepository
 type ICommentRepository interface {
-	AddComment(comment model.Comment) error
-	GetComment(commentID uuid.UUID) (*model.Comment, error)
-	GetCommentsByID(commentID ...uuid.UUID) ([]model.Comment, error)
-	GetCommentsByIPAddr(ipAddr string, page, pageSize int) ([]model.Comment, int64, error)
-	GetCommentsByMovieID(movieID int, page, pageSize int) ([]model.Comment, int64, error)
-	GetCommentCountByMovieID(movieID int) (int64, error)
+	// AddComment saves a comment; a repeated comment leaves the original in place and comment is updated to it
+	AddComment(ctx context.Context, comment *model.Comment) error
+	GetComment(ctx context.Context, commentID uuid.UUID) (*model.Comment, error)
+	GetCommentsByID(ctx context.Context, commentID ...uuid.UUID) ([]model.Comment, error)
+	GetCommentsByIPAddr(ctx context.Context, ipAddr string, page, pageSize int) ([]model.Comment, int64, error)
+	GetCommentsByMovieID(ctx context.Context, movieID int, page, pageSize int) ([]model.Comment, int64, error)
+	GetCommentCountByMovieID(ctx context.Context, movieID int) (int64, error)
+	// GetCommentsAfter returns up to limit comments of a movie created after the given comment, oldest first
+	GetCommentsAfter(ctx context.Context, movieID int, afterID uuid.UUID, limit int) ([]model.Comment, error)
+	// GetCommentCounts returns the number of comments of every movie that has any
+	GetCommentCounts(ctx context.Context) (map[int]int64, error)
+	// AnonymiseIPAddrs irreversibly replaces the ip address of comments created before the given time
+	AnonymiseIPAddrs(ctx context.Context, createdBefore time.Time) (int64, error)
+
+	Ping(ctx context.Context) error
+	// SchemaVersion reports the applied migrations against those the repository needs
+	SchemaVersion(ctx context.Context) (model.SchemaVersion, error)
 }
@@ -9,4 +9,19 @@ import (
 type ISwapi interface {
 	GetFilms(ctx context.Context, id ...int) ([]swapi.Film, error)
 	GetCharacters(ctx context.Context, id ...int) ([]swapi.Person, error)
+	GetPlanets(ctx context.Context) ([]swapi.Planet, error)
+}
+
+// ICircuitBreaker is the circuit breaker guarding swapi
+type ICircuitBreaker interface {
+	// State is "closed", "open" or "half-open"
+	State() string
+}
+
+// ISwapiTracker tells which of the backends a swapi client fails over between
+// served a refresh
+type ISwapiTracker interface {
+	// Track returns a context recording the backends answering the requests
+	// made with it, and a func listing them in the order they first answered
+	Track(ctx context.Context) (context.Context, func() []string)
 }
@@ -0,0 +1,37 @@
+package ports
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+
+	"github.com/iamnator/movie-api/model"
+)
+
+// ErrNotFound is returned by repositories when the requested record does not exist
+var ErrNotFound = errors.New("record not found")
+
+//go:generate mockgen -source=webhook.go -destination=./mocks/webhook.go  -package=mocks github.com/iamnator/movie-api/service/ports IWebhookRepository,IWebhookSender
+type IWebhookRepository interface {
+	AddSubscription(ctx context.Context, subscription *model.WebhookSubscription) error
+	GetSubscription(ctx context.Context, id uuid.UUID) (*model.WebhookSubscription, error)
+	GetSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id uuid.UUID) error
+
+	AddDeliveries(ctx context.Context, deliveries []model.WebhookDelivery) error
+	GetDelivery(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error)
+	GetDeliveries(ctx context.Context, state model.WebhookDeliveryState, page, pageSize int) ([]model.WebhookDelivery, int64, error)
+	// ClaimDueDeliveries returns up to limit pending deliveries due by now and
+	// pushes their next attempt lease into the future, so other instances
+	// polling at the same time do not send them too
+	ClaimDueDeliveries(ctx context.Context, now time.Time, limit int, lease time.Duration) ([]model.WebhookDelivery, error)
+	UpdateDelivery(ctx context.Context, delivery model.WebhookDelivery) error
+}
+
+type IWebhookSender interface {
+	// Send posts the delivery's payload to url, signed with secret. Any non 2xx
+	// response is an error.
+	Send(ctx context.Context, url, secret string, delivery model.WebhookDelivery) error
+}
@@ -0,0 +1,89 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+)
+
+var ErrPresenceDisabled = errors.New("presence tracking is not configured")
+
+const (
+	// presenceTTL is how long a viewer is counted after its instance last
+	// refreshed it, presenceRefresh must be well within it
+	presenceTTL     = 30 * time.Second
+	presenceRefresh = 10 * time.Second
+
+	// presencePollInterval is how often the viewer count is read
+	presencePollInterval = 5 * time.Second
+)
+
+// WatchMovie counts the caller as a viewer of a movie until ctx is done and
+// returns the number of viewers across every instance whenever it changes. A
+// reader that falls behind only gets the latest count.
+func (s service) WatchMovie(ctx context.Context, movieID int) (<-chan int64, error) {
+	if s.presence == nil {
+		return nil, ErrPresenceDisabled
+	}
+
+	//check if movie exists
+	if _, err := s.cache.GetMovieByID(ctx, movieID); err != nil {
+		log.Ctx(ctx).Debug().Err(err).Msg("movie not found")
+		return nil, errors.New("movie not found")
+	}
+
+	session := uuid.NewString()
+	if err := s.presence.Join(ctx, movieID, session, presenceTTL); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error joining movie viewers")
+		return nil, errors.New("error joining movie viewers")
+	}
+
+	viewers := make(chan int64, 1)
+
+	go func() {
+		defer close(viewers)
+		defer func() {
+			if err := s.presence.Leave(context.Background(), movieID, session); err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("error leaving movie viewers")
+			}
+		}()
+
+		joinedAt := s.clock.Now()
+		last := int64(-1)
+
+		for {
+			count, err := s.presence.Count(ctx, movieID)
+			if err != nil {
+				log.Ctx(ctx).Error().Err(err).Msg("error counting movie viewers")
+			} else if count != last {
+				last = count
+
+				// replace a count the reader did not get to yet
+				select {
+				case <-viewers:
+				default:
+				}
+				viewers <- count
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-s.clock.After(presencePollInterval):
+			}
+
+			if s.clock.Now().Sub(joinedAt) >= presenceRefresh {
+				if err := s.presence.Join(ctx, movieID, session, presenceTTL); err != nil {
+					log.Ctx(ctx).Error().Err(err).Msg("error refreshing movie viewer")
+				} else {
+					joinedAt = s.clock.Now()
+				}
+			}
+		}
+	}()
+
+	return viewers, nil
+}
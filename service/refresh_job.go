@@ -0,0 +1,377 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/metrics"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+const (
+	// maxTrackedJobs bounds how many finished jobs are kept for status queries
+	maxTrackedJobs = 50
+
+	// refreshLockName is the distributed lock shared by every instance's refresh job
+	refreshLockName = "refresh"
+
+	// refreshJobName labels the metrics of warm-up and manual refreshes
+	refreshJobName = "refresh"
+
+	// defaultRefreshTimeout bounds a whole refresh, characters included
+	defaultRefreshTimeout = 10 * time.Minute
+
+	// jobRecordTimeout bounds recording a job in the job store
+	jobRecordTimeout = 5 * time.Second
+)
+
+var (
+	ErrRefreshJobNotFound = errors.New("refresh job not found")
+	// ErrRefreshRunning is returned when a refresh of another scope is running;
+	// try again once it is done
+	ErrRefreshRunning = errors.New("a refresh of another scope is already running")
+)
+
+// refreshJob is the mutable, concurrency safe view of a model.RefreshJob
+type refreshJob struct {
+	mu  sync.Mutex
+	job model.RefreshJob
+}
+
+func (j *refreshJob) snapshot() model.RefreshJob {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	job := j.job
+	job.Errors = append([]string(nil), j.job.Errors...)
+	job.SwapiBackends = append([]string(nil), j.job.SwapiBackends...)
+	job.Warnings = append([]model.DataWarning(nil), j.job.Warnings...)
+	return job
+}
+
+func (j *refreshJob) addFilms(n int) {
+	j.mu.Lock()
+	j.job.FilmsFetched += n
+	j.mu.Unlock()
+}
+
+func (j *refreshJob) addCharacters(n int) {
+	j.mu.Lock()
+	j.job.CharactersFetched += n
+	j.mu.Unlock()
+}
+
+func (j *refreshJob) addError(err error) {
+	j.mu.Lock()
+	j.job.Errors = append(j.job.Errors, err.Error())
+	j.mu.Unlock()
+}
+
+func (j *refreshJob) addWarnings(warnings ...model.DataWarning) {
+	j.mu.Lock()
+	j.job.Warnings = append(j.job.Warnings, warnings...)
+	j.mu.Unlock()
+}
+
+func (j *refreshJob) setResult(result model.RefreshResult) {
+	j.mu.Lock()
+	j.job.Result = &result
+	j.mu.Unlock()
+}
+
+func (j *refreshJob) hasFailures() bool {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	return j.job.Result != nil && j.job.Result.HasFailures()
+}
+
+func (j *refreshJob) setSwapiBackends(backends []string) {
+	j.mu.Lock()
+	j.job.SwapiBackends = backends
+	j.mu.Unlock()
+}
+
+func (j *refreshJob) setFencingToken(token int64) {
+	j.mu.Lock()
+	j.job.FencingToken = token
+	j.mu.Unlock()
+}
+
+func (j *refreshJob) finish(state model.RefreshJobState, err error) {
+	j.mu.Lock()
+	defer j.mu.Unlock()
+
+	now := time.Now().UTC()
+	j.job.EndedAt = &now
+	j.job.State = state
+	if err != nil {
+		j.job.Errors = append(j.job.Errors, err.Error())
+	}
+}
+
+// jobTracker keeps recent refresh jobs and makes sure only one runs at a time
+type jobTracker struct {
+	mu      sync.Mutex
+	jobs    map[string]*refreshJob
+	order   []string // job ids, oldest first
+	running *refreshJob
+}
+
+func newJobTracker() *jobTracker {
+	return &jobTracker{
+		jobs: make(map[string]*refreshJob),
+	}
+}
+
+// sameRefreshScope reports whether two refreshes do the same work, whatever
+// triggered them
+func sameRefreshScope(a, b model.RefreshJob) bool {
+	return a.FilmID == b.FilmID && a.AllCharacters == b.AllCharacters
+}
+
+// start registers a new running job described by spec (its trigger, film
+// and scope). While a job of the same scope runs it is returned with
+// started=false, so concurrent triggers are coalesced into it; while one of
+// another scope runs ErrRefreshRunning is returned, coalescing would skip the
+// work asked for.
+func (t *jobTracker) start(spec model.RefreshJob) (job *refreshJob, started bool, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.running != nil {
+		if !sameRefreshScope(t.running.snapshot(), spec) {
+			return t.running, false, ErrRefreshRunning
+		}
+		return t.running, false, nil
+	}
+
+	spec.ID = uuid.NewString()
+	spec.State = model.RefreshJobRunning
+	spec.StartedAt = time.Now().UTC()
+	job = &refreshJob{job: spec}
+
+	t.jobs[job.job.ID] = job
+	t.order = append(t.order, job.job.ID)
+	if len(t.order) > maxTrackedJobs {
+		delete(t.jobs, t.order[0])
+		t.order = t.order[1:]
+	}
+
+	t.running = job
+	return job, true, nil
+}
+
+func (t *jobTracker) done(job *refreshJob, err error) {
+	switch {
+	case err != nil:
+		t.end(job, model.RefreshJobFailed, err)
+	case job.hasFailures():
+		t.end(job, model.RefreshJobPartial, nil)
+	default:
+		t.end(job, model.RefreshJobSucceeded, nil)
+	}
+}
+
+func (t *jobTracker) skip(job *refreshJob) {
+	t.end(job, model.RefreshJobSkipped, nil)
+}
+
+func (t *jobTracker) end(job *refreshJob, state model.RefreshJobState, err error) {
+	job.finish(state, err)
+
+	t.mu.Lock()
+	if t.running == job {
+		t.running = nil
+	}
+	t.mu.Unlock()
+}
+
+func (t *jobTracker) get(id string) (*refreshJob, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	job, ok := t.jobs[id]
+	return job, ok
+}
+
+// recordJob saves the job in the job store, if any. It outlives ctx so the
+// end of a refresh cancelled or timed out is recorded too.
+func (s service) recordJob(ctx context.Context, job *refreshJob) {
+	if s.jobStore == nil {
+		return
+	}
+
+	storeCtx, cancel := context.WithTimeout(context.Background(), jobRecordTimeout)
+	defer cancel()
+
+	if err := s.jobStore.SaveJob(storeCtx, job.snapshot()); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error recording refresh job")
+	}
+}
+
+// runRefresh runs a tracked refresh described by spec synchronously. If a
+// refresh of the same scope is already running the call joins it and returns
+// nil without doing any work; one of another scope fails the call with
+// ErrRefreshRunning for the caller to retry. The refresh stops with ctx, e.g.
+// when the scheduler that ran it stops.
+func (s service) runRefresh(ctx context.Context, spec model.RefreshJob) error {
+	job, started, err := s.jobs.start(spec)
+	if err != nil {
+		log.Ctx(ctx).Info().Str("job_id", job.snapshot().ID).Msg("refresh of another scope running")
+		return err
+	}
+	if !started {
+		log.Ctx(ctx).Info().Str("job_id", job.snapshot().ID).Msg("refresh already running, coalescing")
+		return nil
+	}
+
+	return s.execute(ctx, job)
+}
+
+// execute runs a job registered with the tracker and marks it done. When the
+// service has a locker the job only runs while holding the refresh lock, so a
+// single instance refreshes the shared cache at a time; the others skip.
+func (s service) execute(ctx context.Context, job *refreshJob) error {
+	start := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, s.refreshTimeout)
+	defer cancel()
+
+	// everything the refresh logs is tied to the job
+	ctx = log.With().Str("job_id", job.snapshot().ID).Logger().WithContext(ctx)
+	s.recordJob(ctx, job)
+
+	var served func() []string
+	if s.swapiTracker != nil {
+		ctx, served = s.swapiTracker.Track(ctx)
+	}
+
+	var result model.RefreshResult
+	ran, err := s.runExclusive(ctx, refreshLockName, func(ctx context.Context, token int64) error {
+		job.setFencingToken(token)
+		s.recordJob(ctx, job)
+
+		var filmIDs []int
+		if filmID := job.snapshot().FilmID; filmID > 0 {
+			filmIDs = []int{filmID}
+		}
+
+		var err error
+		result, err = s.refreshMovieCache(ctx, job, filmIDs...)
+		if err != nil || len(filmIDs) > 0 {
+			return err
+		}
+
+		// every instance reads the cache, so they all get ready off this
+		if err := s.cache.SetRefreshedAt(ctx, token, s.clock.Now()); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error recording refresh time")
+		}
+		return nil
+	})
+	if !ran && err == nil {
+		log.Ctx(ctx).Info().Msg("refresh is running on another instance, skipping")
+		s.jobs.skip(job)
+		s.recordJob(ctx, job)
+		return nil
+	}
+
+	// scheduled refreshes are observed as the job that scheduled them
+	if job.snapshot().Trigger != model.RefreshTriggerScheduled {
+		metrics.ObserveJob(refreshJobName, err, time.Since(start))
+	}
+
+	if served != nil {
+		backends := served()
+		log.Ctx(ctx).Info().Strs("swapi_backends", backends).Msg("refresh served by swapi backends")
+		job.setSwapiBackends(backends)
+	}
+
+	if warnings := job.snapshot().Warnings; len(warnings) > 0 {
+		log.Ctx(ctx).Warn().Int("warnings", len(warnings)).Msg("swapi served malformed or unknown values, see the job's warnings")
+	}
+
+	job.setResult(result)
+	s.jobs.done(job, err)
+	s.recordJob(ctx, job)
+
+	return err
+}
+
+func (s service) TriggerRefresh(ctx context.Context, filmID int) (*model.RefreshJob, error) {
+	if filmID < 0 {
+		return nil, errors.New("invalid film id")
+	}
+
+	job, started, err := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerManual, FilmID: filmID})
+	if err != nil {
+		return nil, err
+	}
+	if !started {
+		snapshot := job.snapshot()
+		snapshot.Coalesced = true
+		return &snapshot, nil
+	}
+
+	go func() {
+		// the refresh outlives the request that triggered it
+		if err := s.execute(context.Background(), job); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error running manual refresh")
+		}
+	}()
+
+	snapshot := job.snapshot()
+	return &snapshot, nil
+}
+
+// RunRefresh refreshes the film, or every film when filmID is 0, and returns
+// the job once it is done. A refresh of the same scope already running on this
+// instance is returned as is, coalesced, without waiting for it; one of
+// another scope fails the call with ErrRefreshRunning.
+func (s service) RunRefresh(ctx context.Context, filmID int) (*model.RefreshJob, error) {
+	if filmID < 0 {
+		return nil, errors.New("invalid film id")
+	}
+
+	job, started, err := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerCommand, FilmID: filmID})
+	if err != nil {
+		return nil, err
+	}
+	if !started {
+		snapshot := job.snapshot()
+		snapshot.Coalesced = true
+		return &snapshot, nil
+	}
+
+	err = s.execute(ctx, job)
+
+	snapshot := job.snapshot()
+	return &snapshot, err
+}
+
+// GetRefreshJob reports a job of this instance, or one another instance ran
+// when the service has a job store
+func (s service) GetRefreshJob(ctx context.Context, jobID string) (*model.RefreshJob, error) {
+	if job, ok := s.jobs.get(jobID); ok {
+		snapshot := job.snapshot()
+		return &snapshot, nil
+	}
+
+	if s.jobStore == nil {
+		return nil, ErrRefreshJobNotFound
+	}
+
+	job, err := s.jobStore.GetJob(ctx, jobID)
+	if errors.Is(err, ports.ErrNotFound) {
+		return nil, ErrRefreshJobNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &job, nil
+}
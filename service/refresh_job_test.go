@@ -0,0 +1,68 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/iamnator/movie-api/model"
+)
+
+func Test_jobTracker_Coalesce(t *testing.T) {
+	tracker := newJobTracker()
+
+	first, started, _ := tracker.start(model.RefreshJob{Trigger: model.RefreshTriggerManual})
+	if !started {
+		t.Fatal("expected the first trigger to start a job")
+	}
+
+	second, started, err := tracker.start(model.RefreshJob{Trigger: model.RefreshTriggerScheduled})
+	if started || second != first || err != nil {
+		t.Fatalf("expected the second trigger to join the running job, err=%v", err)
+	}
+
+	// coalescing into a refresh of another scope would skip the work asked for
+	for _, spec := range []model.RefreshJob{
+		{Trigger: model.RefreshTriggerManual, FilmID: 1},
+		{Trigger: model.RefreshTriggerScheduled, AllCharacters: true},
+	} {
+		if _, started, err := tracker.start(spec); started || !errors.Is(err, ErrRefreshRunning) {
+			t.Errorf("spec=%+v | started=%v err=%v | expected=%v", spec, started, err, ErrRefreshRunning)
+		}
+	}
+
+	tracker.done(first, errors.New("swapi is down"))
+
+	job := first.snapshot()
+	if job.State != model.RefreshJobFailed || job.EndedAt == nil || len(job.Errors) != 1 {
+		t.Errorf("unexpected job after failure: %+v", job)
+	}
+
+	third, started, _ := tracker.start(model.RefreshJob{Trigger: model.RefreshTriggerManual, FilmID: 1})
+	if !started || third == first {
+		t.Fatal("expected a new job once the previous one is done")
+	}
+
+	if got, ok := tracker.get(first.snapshot().ID); !ok || got != first {
+		t.Errorf("expected finished job %s to still be tracked", job.ID)
+	}
+}
+
+func Test_jobTracker_Bounded(t *testing.T) {
+	tracker := newJobTracker()
+
+	var firstID string
+	for i := 0; i < maxTrackedJobs+1; i++ {
+		job, _, _ := tracker.start(model.RefreshJob{Trigger: model.RefreshTriggerManual})
+		if i == 0 {
+			firstID = job.snapshot().ID
+		}
+		tracker.done(job, nil)
+	}
+
+	if _, ok := tracker.get(firstID); ok {
+		t.Errorf("expected the oldest job to be evicted")
+	}
+	if len(tracker.jobs) != maxTrackedJobs {
+		t.Errorf("tracked=%d | expected=%d", len(tracker.jobs), maxTrackedJobs)
+	}
+}
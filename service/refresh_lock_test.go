@@ -0,0 +1,109 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/go-redis/redis/v8"
+	"github.com/golang/mock/gomock"
+
+	"github.com/iamnator/movie-api/adapter/lock"
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/clock"
+	"github.com/iamnator/movie-api/service/ports"
+	"github.com/iamnator/movie-api/service/ports/mocks"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+)
+
+// newInstance builds a service as a separate dyno would, with its own job
+// tracker but a locker and a job store on the shared redis.
+func newInstance(t *testing.T, m *miniredis.Miniredis, cache ports.ICache, swapiClient ports.ISwapi) service {
+	t.Helper()
+
+	client := redis.NewClient(&redis.Options{Addr: m.Addr()})
+	t.Cleanup(func() { _ = client.Close() })
+
+	locker, jobStore := lock.NewRedisLocker(client), lock.NewRedisJobStore(client)
+
+	return service{
+		cache:          cache,
+		swapiClient:    swapiClient,
+		jobs:           newJobTracker(),
+		locker:         locker,
+		jobStore:       jobStore,
+		clock:          clock.System,
+		refreshTimeout: defaultRefreshTimeout,
+	}
+}
+
+func lastJob(s service) model.RefreshJob {
+	job, _ := s.jobs.get(s.jobs.order[len(s.jobs.order)-1])
+	return job.snapshot()
+}
+
+func Test_RefreshLock_TwoInstances(t *testing.T) {
+	m := miniredis.RunT(t)
+	ctrl := gomock.NewController(t)
+
+	cache := mocks.NewMockICache(ctrl)
+	swapiClient := mocks.NewMockISwapi(ctrl)
+
+	entered := make(chan struct{})
+	release := make(chan struct{})
+
+	// only one of the two instances may reach swapi
+	swapiClient.EXPECT().GetFilms(gomock.Any()).DoAndReturn(func(ctx context.Context, id ...int) ([]lib.Film, error) {
+		close(entered)
+		<-release
+		return nil, nil
+	}).Times(1)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(nil, nil).Times(1)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(nil, nil).Times(1)
+	cache.EXPECT().SetRefreshedAt(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil).Times(1)
+
+	first := newInstance(t, m, cache, swapiClient)
+	second := newInstance(t, m, cache, swapiClient)
+
+	errc := make(chan error, 1)
+	go func() {
+		errc <- first.runRefresh(context.Background(), model.RefreshJob{Trigger: model.RefreshTriggerScheduled})
+	}()
+
+	<-entered
+
+	// the job running on the first instance is reported by the second
+	running := lastJob(first)
+	if job, err := second.GetRefreshJob(context.Background(), running.ID); err != nil || job.State != model.RefreshJobRunning || job.FencingToken == 0 {
+		t.Errorf("job=%+v err=%v | expected the running job with its fencing token", job, err)
+	}
+
+	if err := second.runRefresh(context.Background(), model.RefreshJob{Trigger: model.RefreshTriggerScheduled}); err != nil {
+		t.Fatalf("unexpected error from second instance: %s", err)
+	}
+	if job := lastJob(second); job.State != model.RefreshJobSkipped {
+		t.Errorf("second instance job state=%s | expected=%s", job.State, model.RefreshJobSkipped)
+	}
+
+	close(release)
+	if err := <-errc; err != nil {
+		t.Fatalf("unexpected error from first instance: %s", err)
+	}
+
+	job := lastJob(first)
+	if job.State != model.RefreshJobSucceeded || job.FencingToken == 0 {
+		t.Errorf("unexpected first instance job: %+v", job)
+	}
+	if shared, err := second.GetRefreshJob(context.Background(), job.ID); err != nil || shared.State != model.RefreshJobSucceeded {
+		t.Errorf("job=%+v err=%v | expected the first instance's job to be reported done", shared, err)
+	}
+
+	if _, err := second.GetRefreshJob(context.Background(), "unknown"); !errors.Is(err, ErrRefreshJobNotFound) {
+		t.Errorf("err=%v | expected=%v", err, ErrRefreshJobNotFound)
+	}
+
+	if m.Exists("lock:" + refreshLockName) {
+		t.Errorf("expected the refresh lock to be released")
+	}
+}
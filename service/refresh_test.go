@@ -0,0 +1,604 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/backoff"
+	"github.com/iamnator/movie-api/pkg/clock"
+	"github.com/iamnator/movie-api/pkg/scheduler"
+	"github.com/iamnator/movie-api/service/ports"
+	"github.com/iamnator/movie-api/service/ports/mocks"
+	"github.com/iamnator/movie-api/thirdparty/swapi"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+)
+
+func film(id int, characterIDs ...int) lib.Film {
+	f := lib.Film{
+		Title: fmt.Sprintf("film %d", id),
+		URL:   fmt.Sprintf("https://swapi.dev/api/films/%d/", id),
+	}
+	for _, characterID := range characterIDs {
+		f.CharacterURLs = append(f.CharacterURLs, fmt.Sprintf("https://swapi.dev/api/people/%d/", characterID))
+	}
+	return f
+}
+
+func editedFilm(id int, edited time.Time, characterIDs ...int) lib.Film {
+	f := film(id, characterIDs...)
+	f.Edited = edited.Format(time.RFC3339)
+	return f
+}
+
+func editedPerson(id int, edited time.Time) lib.Person {
+	p := person(id)
+	p.Edited = edited.Format(time.RFC3339)
+	return p
+}
+
+func person(id int) lib.Person {
+	return lib.Person{
+		Name:   fmt.Sprintf("person %d", id),
+		Height: "172",
+		Gender: "male",
+		URL:    fmt.Sprintf("https://swapi.dev/api/people/%d/", id),
+	}
+}
+
+func people(ids ...int) []lib.Person {
+	var p []lib.Person
+	for _, id := range ids {
+		p = append(p, person(id))
+	}
+	return p
+}
+
+func ids(from, to int) []int {
+	var i []int
+	for id := from; id <= to; id++ {
+		i = append(i, id)
+	}
+	return i
+}
+
+func newRefreshService(ctrl *gomock.Controller) (service, *mocks.MockICache, *mocks.MockISwapi) {
+	cache := mocks.NewMockICache(ctrl)
+	swapiClient := mocks.NewMockISwapi(ctrl)
+
+	return service{
+		cache:       cache,
+		swapiClient: swapiClient,
+		jobs:        newJobTracker(),
+		chunkPolicy: backoff.Policy{MaxAttempts: 1},
+		clock:       clock.System,
+	}, cache, swapiClient
+}
+
+func Test_refreshMovieCache_WaitsForCharacters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+
+	swapiClient.EXPECT().GetFilms(gomock.Any()).Return([]lib.Film{film(1, 1, 2), film(2, 2, 3)}, nil)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(nil, nil)
+	cache.EXPECT().SetMovies(gomock.Any(), gomock.Any(), gomock.Len(2)).Return(nil)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(nil, nil)
+	swapiClient.EXPECT().GetCharacters(gomock.Any(), 1, 2, 3).Return(people(1, 2, 3), nil)
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 1, gomock.Len(2)).Return(nil)
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 2, gomock.Len(2)).Return(nil)
+
+	job, _, _ := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerManual})
+	result, err := s.refreshMovieCache(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// every expectation above is met by the time refreshMovieCache returns
+	ctrl.Finish()
+
+	want := model.RefreshResult{
+		SucceededFilmIDs:      []int{1, 2},
+		SucceededCharacterIDs: []int{1, 2, 3},
+		Changes: model.RefreshChanges{
+			FilmsAdded:      []int{1, 2},
+			CharactersAdded: []int{1, 2, 3},
+		},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("result=%+v | expected=%+v", result, want)
+	}
+
+	if snapshot := job.snapshot(); snapshot.FilmsFetched != 2 || snapshot.CharactersFetched != 3 {
+		t.Errorf("unexpected progress: %+v", snapshot)
+	}
+}
+
+func Test_refreshMovieCache_ContinuesPastFailedChunks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+
+	// 25 characters make three chunks
+	swapiClient.EXPECT().GetFilms(gomock.Any()).Return([]lib.Film{film(1, ids(1, 25)...)}, nil)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(nil, nil)
+	cache.EXPECT().SetMovies(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(nil, nil)
+
+	gomock.InOrder(
+		// first chunk fails outright
+		swapiClient.EXPECT().GetCharacters(gomock.Any(), ids(1, 10)).Return(nil, errors.New("swapi is down")),
+		// second chunk comes back empty
+		swapiClient.EXPECT().GetCharacters(gomock.Any(), ids(11, 20)).Return(nil, nil),
+		// third chunk is partial
+		swapiClient.EXPECT().GetCharacters(gomock.Any(), ids(21, 25)).Return(people(21, 22, 23), errors.New("error fetching 24, 25")),
+	)
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 1, gomock.Len(3)).Return(nil)
+
+	job, _, _ := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerManual})
+	result, err := s.refreshMovieCache(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	job.setResult(result)
+	s.jobs.done(job, err)
+
+	want := model.RefreshResult{
+		SucceededFilmIDs:      []int{1},
+		SucceededCharacterIDs: []int{21, 22, 23},
+		FailedCharacterIDs:    append(ids(1, 20), 24, 25),
+		Changes: model.RefreshChanges{
+			FilmsAdded:      []int{1},
+			CharactersAdded: []int{21, 22, 23},
+		},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("result=%+v | expected=%+v", result, want)
+	}
+
+	snapshot := job.snapshot()
+	if snapshot.State != model.RefreshJobPartial || len(snapshot.Errors) != 2 {
+		t.Errorf("unexpected job: %+v", snapshot)
+	}
+}
+
+func Test_refreshMovieCache_RetriesFailedCharacters(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+	s.chunkPolicy = backoff.Policy{MaxAttempts: 3}
+
+	swapiClient.EXPECT().GetFilms(gomock.Any()).Return([]lib.Film{film(1, 1, 2, 3)}, nil)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(nil, nil)
+	cache.EXPECT().SetMovies(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(nil, nil)
+
+	// a retry only asks for the characters still missing, and gives up with
+	// those none of the attempts fetched
+	gomock.InOrder(
+		swapiClient.EXPECT().GetCharacters(gomock.Any(), 1, 2, 3).Return(people(1), &swapi.CharactersError{Errors: map[int]error{2: errors.New("timeout"), 3: errors.New("timeout")}}),
+		swapiClient.EXPECT().GetCharacters(gomock.Any(), 2, 3).Return(people(2), &swapi.CharactersError{Errors: map[int]error{3: errors.New("timeout")}}),
+		swapiClient.EXPECT().GetCharacters(gomock.Any(), 3).Return(nil, &swapi.CharactersError{Errors: map[int]error{3: errors.New("timeout")}}),
+	)
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 1, gomock.Len(2)).Return(nil)
+
+	job, _, _ := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerManual})
+	result, err := s.refreshMovieCache(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(result.SucceededCharacterIDs, []int{1, 2}) || !reflect.DeepEqual(result.FailedCharacterIDs, []int{3}) {
+		t.Errorf("succeeded=%v failed=%v | expected=[1 2] [3]", result.SucceededCharacterIDs, result.FailedCharacterIDs)
+	}
+	if errs := job.snapshot().Errors; len(errs) != 1 {
+		t.Errorf("errors=%v | expected the chunk's last error only", errs)
+	}
+}
+
+func Test_refreshMovieCache_SaveCharactersFails(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+
+	swapiClient.EXPECT().GetFilms(gomock.Any()).Return([]lib.Film{film(1, 1, 2), film(2, 3)}, nil)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(nil, nil)
+	cache.EXPECT().SetMovies(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(nil, nil)
+	swapiClient.EXPECT().GetCharacters(gomock.Any(), 1, 2, 3).Return(people(1, 2, 3), nil)
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 1, gomock.Any()).Return(errors.New("redis is down"))
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 2, gomock.Any()).Return(nil)
+
+	job, _, _ := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerManual})
+	result, err := s.refreshMovieCache(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := model.RefreshResult{
+		SucceededFilmIDs:      []int{1, 2},
+		SucceededCharacterIDs: []int{3},
+		FailedCharacterIDs:    []int{1, 2},
+		Changes: model.RefreshChanges{
+			FilmsAdded:      []int{1, 2},
+			CharactersAdded: []int{3},
+		},
+	}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("result=%+v | expected=%+v", result, want)
+	}
+}
+
+func Test_refreshMovieCache_FilmsFail(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+
+	job, _, _ := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerManual})
+
+	swapiClient.EXPECT().GetFilms(gomock.Any()).Return(nil, errors.New("swapi is down"))
+	if _, err := s.refreshMovieCache(context.Background(), job); err == nil {
+		t.Error("expected an error when films cannot be fetched")
+	}
+
+	swapiClient.EXPECT().GetFilms(gomock.Any(), 1).Return([]lib.Film{film(1, 1)}, nil)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(nil, nil)
+	cache.EXPECT().SetMovies(gomock.Any(), gomock.Any(), gomock.Any()).Return(errors.New("redis is down"))
+	result, err := s.refreshMovieCache(context.Background(), job, 1)
+	if err == nil {
+		t.Error("expected an error when films cannot be cached")
+	}
+	if !reflect.DeepEqual(result.FailedFilmIDs, []int{1}) {
+		t.Errorf("failed_film_ids=%v | expected=[1]", result.FailedFilmIDs)
+	}
+}
+
+func Test_refreshMovieCache_Incremental(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+
+	cachedAt := time.Date(2014, 12, 20, 0, 0, 0, 0, time.UTC)
+	editedAt := cachedAt.Add(time.Hour)
+
+	swapiClient.EXPECT().GetFilms(gomock.Any()).Return([]lib.Film{
+		editedFilm(1, cachedAt, 1, 2), // unchanged
+		editedFilm(2, editedAt, 2, 3), // edited, character 4 left its cast
+		editedFilm(3, editedAt, 5),    // new
+	}, nil)
+
+	// film 4 is gone upstream
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(map[int]time.Time{1: cachedAt, 2: cachedAt, 4: cachedAt}, nil)
+	cache.EXPECT().SetMovies(gomock.Any(), gomock.Any(), gomock.Len(2)).Return(nil)
+	cache.EXPECT().DeleteMovies(gomock.Any(), gomock.Any(), 4).Return(nil)
+
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(map[ports.CharacterKey]time.Time{
+		{MovieID: 1, CharacterID: 1}: cachedAt,
+		{MovieID: 1, CharacterID: 2}: cachedAt,
+		{MovieID: 2, CharacterID: 2}: cachedAt,
+		{MovieID: 2, CharacterID: 3}: cachedAt,
+		{MovieID: 2, CharacterID: 4}: cachedAt,
+		{MovieID: 4, CharacterID: 6}: cachedAt,
+	}, nil)
+
+	// characters of film 1 are cached and its cast did not change
+	swapiClient.EXPECT().GetCharacters(gomock.Any(), 2, 3, 5).
+		Return([]lib.Person{editedPerson(2, cachedAt), editedPerson(3, editedAt), editedPerson(5, editedAt)}, nil)
+
+	// character 2 did not change, so film 2 only gets character 3 rewritten
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 2, gomock.Len(1)).Return(nil)
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 3, gomock.Len(1)).Return(nil)
+	cache.EXPECT().DeleteCharacters(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, _ int64, keys ...ports.CharacterKey) error {
+		if len(keys) != 2 {
+			t.Errorf("deleted=%v | expected characters 4 and 6", keys)
+		}
+		return nil
+	})
+
+	job, _, _ := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerScheduled})
+	result, err := s.refreshMovieCache(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := model.RefreshChanges{
+		FilmsAdded:        []int{3},
+		FilmsUpdated:      []int{2},
+		FilmsRemoved:      []int{4},
+		CharactersAdded:   []int{5},
+		CharactersUpdated: []int{3},
+		CharactersRemoved: []int{4, 6},
+	}
+	if !reflect.DeepEqual(result.Changes, want) {
+		t.Errorf("changes=%+v | expected=%+v", result.Changes, want)
+	}
+}
+
+func Test_refreshMovieCache_UnreadableKeepsCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+
+	cachedAt := time.Date(2014, 12, 20, 0, 0, 0, 0, time.UTC)
+
+	unreadable := editedFilm(2, cachedAt)
+	unreadable.URL = "https://swapi.dev/api/films/two/"
+	partial := editedFilm(1, cachedAt, 1)
+	partial.CharacterURLs = append(partial.CharacterURLs, "https://swapi.dev/api/people/")
+
+	// film 2, and character 2 of film 1, may still be listed upstream
+	swapiClient.EXPECT().GetFilms(gomock.Any()).Return([]lib.Film{partial, unreadable}, nil)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(map[int]time.Time{1: cachedAt, 2: cachedAt}, nil)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(map[ports.CharacterKey]time.Time{
+		{MovieID: 1, CharacterID: 1}: cachedAt,
+		{MovieID: 1, CharacterID: 2}: cachedAt,
+		{MovieID: 2, CharacterID: 3}: cachedAt,
+	}, nil)
+	cache.EXPECT().DeleteMovies(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+	cache.EXPECT().DeleteCharacters(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	job, _, _ := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerScheduled})
+	result, err := s.refreshMovieCache(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if len(result.Changes.FilmsRemoved) > 0 || len(result.Changes.CharactersRemoved) > 0 {
+		t.Errorf("changes=%+v | expected nothing removed", result.Changes)
+	}
+	if errs := job.snapshot().Errors; len(errs) != 2 {
+		t.Errorf("errors=%v | expected the unreadable film and character", errs)
+	}
+}
+
+func Test_refreshMovieCache_ScopedKeepsOtherMovies(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+
+	cachedAt := time.Date(2014, 12, 20, 0, 0, 0, 0, time.UTC)
+
+	swapiClient.EXPECT().GetFilms(gomock.Any(), 1).Return([]lib.Film{editedFilm(1, cachedAt, 1)}, nil)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(map[int]time.Time{1: cachedAt, 2: cachedAt}, nil)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(map[ports.CharacterKey]time.Time{
+		{MovieID: 1, CharacterID: 1}: cachedAt,
+		{MovieID: 2, CharacterID: 2}: cachedAt,
+	}, nil)
+
+	// nothing changed and film 2 was not part of the refresh, so nothing is written or deleted
+	job, _, _ := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerManual, FilmID: 1})
+	result, err := s.refreshMovieCache(context.Background(), job, 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if !reflect.DeepEqual(result.Changes, model.RefreshChanges{}) {
+		t.Errorf("changes=%+v | expected none", result.Changes)
+	}
+}
+
+func Test_refreshMovieCache_AllCharactersRechecksCached(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+
+	cachedAt := time.Date(2014, 12, 20, 0, 0, 0, 0, time.UTC)
+	editedAt := cachedAt.Add(time.Hour)
+
+	swapiClient.EXPECT().GetFilms(gomock.Any()).Return([]lib.Film{editedFilm(1, cachedAt, 1, 2)}, nil)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(map[int]time.Time{1: cachedAt}, nil)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(map[ports.CharacterKey]time.Time{
+		{MovieID: 1, CharacterID: 1}: cachedAt,
+		{MovieID: 1, CharacterID: 2}: cachedAt,
+	}, nil)
+
+	// the film did not change but its characters are fetched anyway
+	swapiClient.EXPECT().GetCharacters(gomock.Any(), 1, 2).
+		Return([]lib.Person{editedPerson(1, cachedAt), editedPerson(2, editedAt)}, nil)
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 1, gomock.Len(1)).Return(nil)
+
+	job, _, _ := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerScheduled, AllCharacters: true})
+	result, err := s.refreshMovieCache(context.Background(), job)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	want := model.RefreshChanges{CharactersUpdated: []int{2}}
+	if !reflect.DeepEqual(result.Changes, want) {
+		t.Errorf("changes=%+v | expected=%+v", result.Changes, want)
+	}
+}
+
+func Test_RunRefresh_WaitsForTheJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+	s.refreshTimeout = defaultRefreshTimeout
+
+	swapiClient.EXPECT().GetFilms(gomock.Any(), 1).Return([]lib.Film{film(1, 1)}, nil)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(nil, nil)
+	cache.EXPECT().SetMovies(gomock.Any(), gomock.Any(), gomock.Len(1)).Return(nil)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(nil, nil)
+	swapiClient.EXPECT().GetCharacters(gomock.Any(), 1).Return(people(1), nil)
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 1, gomock.Len(1)).Return(nil)
+	// a scoped refresh does not count as a refresh of the whole cache
+	cache.EXPECT().SetRefreshedAt(gomock.Any(), gomock.Any(), gomock.Any()).Times(0)
+
+	job, err := s.RunRefresh(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if job.State != model.RefreshJobSucceeded || job.Trigger != model.RefreshTriggerCommand || job.EndedAt == nil {
+		t.Errorf("unexpected job: %+v", job)
+	}
+	if job.Result == nil || !reflect.DeepEqual(job.Result.SucceededCharacterIDs, []int{1}) {
+		t.Errorf("result=%+v | expected=character 1 cached", job.Result)
+	}
+}
+
+func Test_RunRefresh_Cancelled(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, _, swapiClient := newRefreshService(ctrl)
+	s.refreshTimeout = defaultRefreshTimeout
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	swapiClient.EXPECT().GetFilms(gomock.Any()).DoAndReturn(func(ctx context.Context, id ...int) ([]lib.Film, error) {
+		return nil, ctx.Err()
+	})
+
+	job, err := s.RunRefresh(ctx, 0)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	if job.State != model.RefreshJobFailed {
+		t.Errorf("state=%s | expected=%s", job.State, model.RefreshJobFailed)
+	}
+}
+
+func Test_RunRefresh_RecordsSwapiBackends(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+	s.refreshTimeout = defaultRefreshTimeout
+
+	tracker := mocks.NewMockISwapiTracker(ctrl)
+	s.swapiTracker = tracker
+
+	type trackedKey struct{}
+	tracker.EXPECT().Track(gomock.Any()).DoAndReturn(func(ctx context.Context) (context.Context, func() []string) {
+		return context.WithValue(ctx, trackedKey{}, true), func() []string { return []string{"swapi.dev", "www.swapi.tech"} }
+	})
+
+	// the refresh fetches with the tracked context
+	swapiClient.EXPECT().GetFilms(gomock.Any(), 1).DoAndReturn(func(ctx context.Context, id ...int) ([]lib.Film, error) {
+		if ctx.Value(trackedKey{}) == nil {
+			t.Error("films fetched with an untracked context")
+		}
+		return []lib.Film{film(1, 1)}, nil
+	})
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(nil, nil)
+	cache.EXPECT().SetMovies(gomock.Any(), gomock.Any(), gomock.Len(1)).Return(nil)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(nil, nil)
+	swapiClient.EXPECT().GetCharacters(gomock.Any(), 1).DoAndReturn(func(ctx context.Context, id ...int) ([]lib.Person, error) {
+		if ctx.Value(trackedKey{}) == nil {
+			t.Error("characters fetched with an untracked context")
+		}
+		return people(1), nil
+	})
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 1, gomock.Len(1)).Return(nil)
+
+	job, err := s.RunRefresh(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	if expected := []string{"swapi.dev", "www.swapi.tech"}; !reflect.DeepEqual(job.SwapiBackends, expected) {
+		t.Errorf("swapi_backends=%v | expected=%v", job.SwapiBackends, expected)
+	}
+}
+
+func Test_refreshMovieCache_FlagsUnknownHeights(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+
+	unknown := person(2)
+	unknown.Height = "unknown"
+	malformed := person(3)
+	malformed.Height = "1.7m"
+
+	swapiClient.EXPECT().GetFilms(gomock.Any()).Return([]lib.Film{film(1, 1, 2, 3)}, nil)
+	cache.EXPECT().GetMovieVersions(gomock.Any()).Return(nil, nil)
+	cache.EXPECT().SetMovies(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil)
+	cache.EXPECT().GetCharacterVersions(gomock.Any()).Return(nil, nil)
+	swapiClient.EXPECT().GetCharacters(gomock.Any(), 1, 2, 3).Return([]lib.Person{person(1), unknown, malformed}, nil)
+
+	var cached []model.Character
+	cache.EXPECT().SetCharactersByMovieID(gomock.Any(), gomock.Any(), 1, gomock.Len(3)).DoAndReturn(func(ctx context.Context, _ int64, movieID int, characters []model.Character) error {
+		cached = characters
+		return nil
+	})
+
+	job, _, _ := s.jobs.start(model.RefreshJob{Trigger: model.RefreshTriggerManual})
+	if _, err := s.refreshMovieCache(context.Background(), job); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	for _, c := range cached {
+		if known := c.ID == 1; c.HeightKnown != known || (known && c.HeightCm != 172) || (!known && c.HeightCm != 0) {
+			t.Errorf("character %d: height_cm=%d height_known=%v | expected known=%v", c.ID, c.HeightCm, c.HeightKnown, known)
+		}
+	}
+
+	// the test film has no dates, which are flagged too, only heights are checked
+	var heights []model.DataWarning
+	for _, w := range job.snapshot().Warnings {
+		if w.Field == "height" {
+			heights = append(heights, w)
+		}
+	}
+	want := []model.DataWarning{
+		{Resource: unknown.URL, Field: "height", Value: "unknown", Problem: lib.ProblemUnknown},
+		{Resource: malformed.URL, Field: "height", Value: "1.7m", Problem: lib.ProblemMalformed},
+	}
+	if !reflect.DeepEqual(heights, want) {
+		t.Errorf("warnings=%+v | expected=%+v", heights, want)
+	}
+}
+
+func Test_refreshFilmsJob_StopsWithTheScheduler(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, _, swapiClient := newRefreshService(ctrl)
+	s.refreshTimeout = defaultRefreshTimeout
+
+	// the scheduler stopped while the job was due
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	swapiClient.EXPECT().GetFilms(gomock.Any()).DoAndReturn(func(ctx context.Context, _ ...int) ([]lib.Film, error) {
+		if ctx.Err() == nil {
+			t.Error("expected the refresh to run with the scheduler's context")
+		}
+		return nil, ctx.Err()
+	})
+
+	if err := s.refreshFilmsJob(ctx); err == nil {
+		t.Error("expected the cancelled refresh to fail")
+	}
+}
+
+func Test_refreshPlanetsJob(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, cache, swapiClient := newRefreshService(ctrl)
+	s.refreshTimeout = defaultRefreshTimeout
+	s.scheduler = scheduler.New(clock.System)
+	s.registerJobs(nil)
+
+	var registered bool
+	for _, job := range s.GetSchedule(context.Background()) {
+		if job.Name == model.JobPlanets {
+			registered = job.Enabled && job.Schedule == DefaultSchedules[model.JobPlanets]
+		}
+	}
+	if !registered {
+		t.Errorf("jobs=%+v | expected %s on its default schedule", s.GetSchedule(context.Background()), model.JobPlanets)
+	}
+
+	swapiClient.EXPECT().GetPlanets(gomock.Any()).Return([]lib.Planet{
+		{Name: "Tatooine", Climate: "arid", Terrain: "desert", Population: "200000", Edited: "2014-12-20T20:58:18.411000Z", URL: "https://swapi.dev/api/planets/1/"},
+		{Name: "no id", URL: "https://swapi.dev/api/planets/"},
+	}, nil)
+	cache.EXPECT().SetPlanets(gomock.Any(), int64(0), gomock.Any()).DoAndReturn(func(_ context.Context, _ int64, planets []model.Planet) error {
+		want := []model.Planet{{ID: 1, Name: "Tatooine", Climate: "arid", Terrain: "desert", Population: "200000", UpdatedAt: time.Date(2014, 12, 20, 20, 58, 18, 411000000, time.UTC)}}
+		if !reflect.DeepEqual(planets, want) {
+			t.Errorf("planets=%+v | expected=%+v", planets, want)
+		}
+		return nil
+	})
+
+	if err := s.refreshPlanetsJob(context.Background()); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	swapiClient.EXPECT().GetPlanets(gomock.Any()).Return(nil, errors.New("swapi is down"))
+	if err := s.refreshPlanetsJob(context.Background()); err == nil {
+		t.Error("expected an error when swapi is down")
+	}
+}
@@ -0,0 +1,236 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/metrics"
+	"github.com/iamnator/movie-api/pkg/scheduler"
+)
+
+// DefaultSchedules are the cron expressions periodic jobs run on unless
+// overridden with WithSchedules.
+var DefaultSchedules = map[string]string{
+	model.JobFilms:           "0 */3 * * *",  // every 3 hours
+	model.JobCharacters:      "30 4 * * *",   // daily, recheck every character
+	model.JobPlanets:         "45 4 * * *",   // daily
+	model.JobCommentCounts:   "*/15 * * * *", // every 15 minutes
+	model.JobIPAnonymisation: "0 3 * * *",    // daily
+	model.JobOutboxPruning:   "30 3 * * *",   // daily
+}
+
+const (
+	commentCountsLockName   = "comment_counts"
+	ipAnonymisationLockName = "ip_anonymisation"
+	outboxPruningLockName   = "outbox_pruning"
+
+	// defaultIPRetention is how long a commenter's ip address is kept as is
+	defaultIPRetention = 30 * 24 * time.Hour
+	// defaultOutboxRetention is how long published outbox messages are kept
+	defaultOutboxRetention = 7 * 24 * time.Hour
+)
+
+// registerJobs registers every periodic job on the service's scheduler. A job
+// with an invalid schedule is logged and left disabled rather than stopping
+// the others.
+func (s service) registerJobs(schedules map[string]string) {
+	jobs := map[string]func(ctx context.Context) error{
+		model.JobFilms:           s.refreshFilmsJob,
+		model.JobCharacters:      s.refreshCharactersJob,
+		model.JobPlanets:         s.refreshPlanetsJob,
+		model.JobCommentCounts:   s.reconcileCommentCounts,
+		model.JobIPAnonymisation: s.anonymiseIPAddrs,
+	}
+	if s.outbox != nil {
+		jobs[model.JobOutboxPruning] = s.pruneOutbox
+	}
+
+	for name, run := range jobs {
+		spec, ok := schedules[name]
+		if !ok {
+			spec = DefaultSchedules[name]
+		}
+
+		run = observedJob(name, run)
+		if err := s.scheduler.Register(name, spec, run); err != nil {
+			log.Error().Err(err).Str("job", name).Msg("error registering scheduled job, disabling it")
+			_ = s.scheduler.Register(name, scheduler.Disabled, run)
+		}
+	}
+}
+
+// observedJob records the duration and outcome of every run of a job, and tags
+// what it logs with the job name
+func observedJob(name string, run func(ctx context.Context) error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		ctx = log.With().Str("job", name).Logger().WithContext(ctx)
+
+		start := time.Now()
+		err := run(ctx)
+		metrics.ObserveJob(name, err, time.Since(start))
+		return err
+	}
+}
+
+// refreshFilmsJob refreshes every film, and the characters of the films that
+// changed, then counts the comments of films it may have added.
+func (s service) refreshFilmsJob(ctx context.Context) error {
+	if err := s.backGroundJOB(ctx, model.RefreshTriggerScheduled); err != nil {
+		return err
+	}
+
+	return s.reconcileCommentCounts(ctx)
+}
+
+// refreshCharactersJob rechecks the edited time of every character, catching
+// characters edited on swapi while their films were not.
+func (s service) refreshCharactersJob(ctx context.Context) error {
+	return s.runRefresh(ctx, model.RefreshJob{Trigger: model.RefreshTriggerScheduled, AllCharacters: true})
+}
+
+// refreshPlanetsJob caches every planet. It holds the refresh lock, whose
+// fencing token the cache checks, so it never runs alongside a refresh.
+func (s service) refreshPlanetsJob(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, s.refreshTimeout)
+	defer cancel()
+
+	ran, err := s.runExclusive(ctx, refreshLockName, func(ctx context.Context, token int64) error {
+		fetched, err := s.swapiClient.GetPlanets(ctx)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error getting planets")
+			return errors.New("error getting planets")
+		}
+
+		planets := make([]model.Planet, 0, len(fetched))
+		for _, planet := range fetched {
+			id := planet.GetID()
+			if id == 0 {
+				log.Ctx(ctx).Warn().Str("url", planet.URL).Msg("error getting planet id, skipping")
+				continue
+			}
+
+			planets = append(planets, model.Planet{
+				ID:         id,
+				Name:       planet.Name,
+				Climate:    planet.Climate,
+				Terrain:    planet.Terrain,
+				Population: planet.Population,
+				UpdatedAt:  planet.GetEdited(),
+			})
+		}
+
+		if err := s.cache.SetPlanets(ctx, token, planets); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error caching planets")
+			return errors.New("error caching planets")
+		}
+
+		log.Ctx(ctx).Info().Int("planets", len(planets)).Msg("planets refreshed")
+		return nil
+	})
+	if err == nil && !ran {
+		log.Ctx(ctx).Info().Msg("refresh is running on another instance, skipping planets")
+	}
+
+	return err
+}
+
+// reconcileCommentCounts copies the comment count of every cached movie from
+// the database. The api reads the database, the copy is only the cache's.
+func (s service) reconcileCommentCounts(ctx context.Context) error {
+	ran, err := s.runExclusive(ctx, commentCountsLockName, func(ctx context.Context, _ int64) error {
+		movies, err := s.cache.GetMovieVersions(ctx)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error getting cached movies")
+			return errors.New("error getting cached movies")
+		}
+
+		counts, err := s.commentRepository.GetCommentCounts(ctx)
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error getting comment counts")
+			return errors.New("error getting comment counts")
+		}
+
+		// movies without comments are not counted by the database
+		cachedCounts := make(map[int]int64, len(movies))
+		for movieID := range movies {
+			cachedCounts[movieID] = counts[movieID]
+		}
+
+		if err := s.cache.SetMovieCommentCounts(ctx, cachedCounts); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error caching comment counts")
+			return errors.New("error caching comment counts")
+		}
+
+		log.Ctx(ctx).Info().Int("movies", len(cachedCounts)).Msg("comment counts reconciled")
+		return nil
+	})
+	if err == nil && !ran {
+		log.Ctx(ctx).Info().Msg("comment counts are being reconciled on another instance, skipping")
+	}
+
+	return err
+}
+
+// anonymiseIPAddrs replaces the ip address of comments older than the ip
+// retention with an irreversible hash.
+func (s service) anonymiseIPAddrs(ctx context.Context) error {
+	ran, err := s.runExclusive(ctx, ipAnonymisationLockName, func(ctx context.Context, _ int64) error {
+		n, err := s.commentRepository.AnonymiseIPAddrs(ctx, s.clock.Now().Add(-s.ipRetention))
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error anonymising ip addresses")
+			return errors.New("error anonymising ip addresses")
+		}
+
+		log.Ctx(ctx).Info().Int64("comments", n).Msg("ip addresses anonymised")
+		return nil
+	})
+	if err == nil && !ran {
+		log.Ctx(ctx).Info().Msg("ip addresses are being anonymised on another instance, skipping")
+	}
+
+	return err
+}
+
+// pruneOutbox deletes the outbox messages published longer than the outbox
+// retention ago, unpublished ones are kept however old.
+func (s service) pruneOutbox(ctx context.Context) error {
+	ran, err := s.runExclusive(ctx, outboxPruningLockName, func(ctx context.Context, _ int64) error {
+		n, err := s.outbox.DeletePublishedOutbox(ctx, s.clock.Now().Add(-s.outboxRetention))
+		if err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error pruning outbox")
+			return errors.New("error pruning outbox")
+		}
+
+		log.Ctx(ctx).Info().Int64("messages", n).Msg("outbox pruned")
+		return nil
+	})
+	if err == nil && !ran {
+		log.Ctx(ctx).Info().Msg("outbox is being pruned on another instance, skipping")
+	}
+
+	return err
+}
+
+func (s service) GetSchedule(ctx context.Context) []model.ScheduledJob {
+	statuses := s.scheduler.Status()
+
+	jobs := make([]model.ScheduledJob, 0, len(statuses))
+	for _, status := range statuses {
+		jobs = append(jobs, model.ScheduledJob{
+			Name:           status.Name,
+			Schedule:       status.Spec,
+			Enabled:        status.Enabled,
+			Running:        status.Running,
+			NextRunAt:      status.NextRunAt,
+			LastRunAt:      status.LastRunAt,
+			LastFinishedAt: status.LastFinishedAt,
+			LastError:      status.LastError,
+		})
+	}
+
+	return jobs
+}
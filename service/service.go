@@ -1,9 +1,13 @@
 package service
 
 import (
+	"context"
 	"errors"
 	"github.com/google/uuid"
 	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/backoff"
+	"github.com/iamnator/movie-api/pkg/clock"
+	"github.com/iamnator/movie-api/pkg/scheduler"
 	"github.com/iamnator/movie-api/service/ports"
 	"github.com/rs/zerolog/log"
 	"time"
@@ -11,69 +15,286 @@ import (
 
 //go:generate mockgen -destination=./mocks/service_mock.go -package=mocks github.com/iamnator/movie-api/service IServices
 type IServices interface {
-	GetMovies(page, pageSize int) ([]model.Movie, int64, error)
-	GetMovieByID(movieID int) (*model.Movie, error)
-	ValidateMovieID(movieID int) error
-	SaveComment(movieID int, comment model.Comment) error
-	GetComment(movieID int, page, pageSize int) ([]model.Comment, int64, error)
-	GetCharactersByMovieID(arg model.GetCharactersByMovieIDArgs) (*model.CharacterList, int64, error)
+	GetMovies(ctx context.Context, page, pageSize int) ([]model.Movie, int64, error)
+	GetMovieByID(ctx context.Context, movieID int) (*model.Movie, error)
+	ValidateMovieID(ctx context.Context, movieID int) error
+	SaveComment(ctx context.Context, movieID int, comment model.Comment) error
+	GetComment(ctx context.Context, movieID int, page, pageSize int) ([]model.Comment, int64, error)
+	GetCharactersByMovieID(ctx context.Context, arg model.GetCharactersByMovieIDArgs) (*model.CharacterList, int64, error)
+	TriggerRefresh(ctx context.Context, filmID int) (*model.RefreshJob, error)
+	RunRefresh(ctx context.Context, filmID int) (*model.RefreshJob, error)
+	GetRefreshJob(ctx context.Context, jobID string) (*model.RefreshJob, error)
+	GetSchedule(ctx context.Context) []model.ScheduledJob
+	CreateWebhook(ctx context.Context, req model.AddWebhookRequest) (*model.WebhookSubscription, error)
+	GetWebhooks(ctx context.Context) ([]model.WebhookSubscription, error)
+	DeleteWebhook(ctx context.Context, id uuid.UUID) error
+	GetWebhookDeliveries(ctx context.Context, state model.WebhookDeliveryState, page, pageSize int) ([]model.WebhookDelivery, int64, error)
+	ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error)
+	StreamComments(ctx context.Context, movieID int, lastEventID string) (<-chan model.CommentEventData, error)
+	WatchMovie(ctx context.Context, movieID int) (<-chan int64, error)
+	Readiness(ctx context.Context) model.HealthReport
 }
 
 type service struct {
 	cache             ports.ICache
 	commentRepository ports.ICommentRepository
 	swapiClient       ports.ISwapi
+	swapiBreaker      ports.ICircuitBreaker // optional, reported by readiness
+	swapiTracker      ports.ISwapiTracker   // optional, records the swapi backends serving each refresh
+
+	jobs     *jobTracker     // refresh jobs, shared by all copies of the service
+	locker   ports.ILocker   // optional, makes the refresh job exclusive across instances
+	jobStore ports.IJobStore // optional, reports the refresh jobs of every instance
+
+	warmUpPolicy   backoff.Policy // retries the initial cache warm-up
+	chunkPolicy    backoff.Policy // retries fetching a chunk of characters
+	refreshTimeout time.Duration  // bounds a whole refresh
+	ipRetention    time.Duration  // how long commenters' ip addresses are kept as is
+
+	clock     clock.Clock
+	schedules map[string]string    // job name -> cron expression, overriding DefaultSchedules
+	scheduler *scheduler.Scheduler // runs the periodic jobs
+
+	webhooks      ports.IWebhookRepository // optional, notifies subscribers of changes
+	webhookSender ports.IWebhookSender
+	webhookPolicy backoff.Policy // spaces delivery attempts, dead-letters after MaxAttempts
+
+	outbox          ports.IOutboxRepository // optional, relays events recorded with the changes they describe
+	outboxSink      ports.IOutboxSink
+	outboxPolicy    backoff.Policy // spaces publish attempts of a message
+	outboxRetention time.Duration  // how long published messages are kept
+
+	broker   ports.ICommentBroker // optional, streams new comments to live subscribers
+	presence ports.IPresence      // optional, counts the viewers of each movie
+
+	noBackgroundJobs bool // no warm-up, periodic jobs, webhook dispatch nor outbox relay
+}
+
+// Option configures optional behaviour of the service
+type Option func(*service)
+
+// WithLocker makes refresh jobs take a distributed lock so only one instance
+// sharing the cache runs them at a time
+func WithLocker(l ports.ILocker) Option {
+	return func(s *service) {
+		s.locker = l
+	}
+}
+
+// WithJobStore records the refresh jobs in a store shared by the instances, so
+// any of them reports a job whichever instance ran it
+func WithJobStore(store ports.IJobStore) Option {
+	return func(s *service) {
+		s.jobStore = store
+	}
+}
+
+// WithSwapiBreaker reports the state of the circuit breaker guarding the swapi
+// client in readiness
+func WithSwapiBreaker(b ports.ICircuitBreaker) Option {
+	return func(s *service) {
+		s.swapiBreaker = b
+	}
+}
+
+// WithSwapiTracker records on each refresh job which of the swapi backends
+// served it
+func WithSwapiTracker(t ports.ISwapiTracker) Option {
+	return func(s *service) {
+		s.swapiTracker = t
+	}
+}
+
+// WithWarmUpPolicy overrides the retry policy of the initial cache warm-up
+func WithWarmUpPolicy(p backoff.Policy) Option {
+	return func(s *service) {
+		s.warmUpPolicy = p
+	}
+}
+
+// WithChunkRetryPolicy overrides the retry policy used per chunk of characters
+func WithChunkRetryPolicy(p backoff.Policy) Option {
+	return func(s *service) {
+		s.chunkPolicy = p
+	}
+}
+
+// WithRefreshTimeout overrides how long a whole refresh may take
+func WithRefreshTimeout(d time.Duration) Option {
+	return func(s *service) {
+		s.refreshTimeout = d
+	}
+}
+
+// WithIPRetention overrides how long the ip address of a commenter is kept
+// before it is anonymised
+func WithIPRetention(d time.Duration) Option {
+	return func(s *service) {
+		s.ipRetention = d
+	}
+}
+
+// WithSchedules overrides the cron expression of periodic jobs by name, see
+// DefaultSchedules. scheduler.Disabled turns a job off on this instance.
+func WithSchedules(schedules map[string]string) Option {
+	return func(s *service) {
+		s.schedules = schedules
+	}
+}
+
+// WithWebhooks enables change notifications: events are queued in repo and
+// delivered to their subscriptions with sender. comment.created is queued by
+// the outbox relay, see WithOutboxRelay.
+func WithWebhooks(repo ports.IWebhookRepository, sender ports.IWebhookSender) Option {
+	return func(s *service) {
+		s.webhooks = repo
+		s.webhookSender = sender
+	}
+}
+
+// WithOutboxRetention overrides how long outbox messages are kept once
+// published
+func WithOutboxRetention(d time.Duration) Option {
+	return func(s *service) {
+		s.outboxRetention = d
+	}
+}
+
+// WithOutboxRelay publishes messages recorded in the outbox, such as
+// comment.created, to sink
+func WithOutboxRelay(repo ports.IOutboxRepository, sink ports.IOutboxSink) Option {
+	return func(s *service) {
+		s.outbox = repo
+		s.outboxSink = sink
+	}
+}
+
+// WithCommentBroker enables live comment streams, fanned out through broker
+// so a comment saved on one instance reaches subscribers on all of them
+func WithCommentBroker(broker ports.ICommentBroker) Option {
+	return func(s *service) {
+		s.broker = broker
+	}
+}
+
+// WithPresence enables counting the live viewers of each movie across instances
+func WithPresence(presence ports.IPresence) Option {
+	return func(s *service) {
+		s.presence = presence
+	}
 }
 
-func NewServices(cache ports.ICache, commentRepository ports.ICommentRepository, swapiClient ports.ISwapi) IServices {
+// WithoutBackgroundJobs leaves the cache warm-up, the periodic jobs, webhook
+// dispatch and the outbox relay to the instances serving the api, for one-off
+// commands that only call the service
+func WithoutBackgroundJobs() Option {
+	return func(s *service) {
+		s.noBackgroundJobs = true
+	}
+}
+
+// WithClock replaces the clock driving the periodic jobs
+func WithClock(c clock.Clock) Option {
+	return func(s *service) {
+		s.clock = c
+	}
+}
+
+func NewServices(cache ports.ICache, commentRepository ports.ICommentRepository, swapiClient ports.ISwapi, opts ...Option) IServices {
 	srv := service{
 		cache:             cache,
 		commentRepository: commentRepository,
 		swapiClient:       swapiClient,
+		jobs:              newJobTracker(),
+		warmUpPolicy:      defaultWarmUpPolicy(),
+		chunkPolicy:       defaultChunkPolicy(),
+		refreshTimeout:    defaultRefreshTimeout,
+		ipRetention:       defaultIPRetention,
+		clock:             clock.System,
+		webhookPolicy:     defaultWebhookPolicy(),
+		outboxPolicy:      defaultOutboxPolicy(),
+		outboxRetention:   defaultOutboxRetention,
+	}
+
+	for _, opt := range opts {
+		opt(&srv)
+	}
+
+	srv.scheduler = scheduler.New(srv.clock)
+	srv.registerJobs(srv.schedules)
+
+	if srv.noBackgroundJobs {
+		return srv
+	}
+
+	if srv.webhooks != nil {
+		go srv.dispatchWebhooks(context.Background())
+	}
+
+	if srv.outbox != nil {
+		go srv.relayOutbox(context.Background())
 	}
 
+	// periodic jobs do not wait for the warm-up, which may retry for as long as
+	// swapi is down; a scheduled refresh meanwhile joins it
+	srv.scheduler.Start(context.Background())
+
 	go func() {
 
-		for {
-			log.Info().Msg("running background job ...")
-			if err := srv.backGroundJOB(); err == nil {
-				log.Info().Msg("background job ran successfully")
-				break
-			} else {
-				log.Error().Err(err).Msg("error running background job, retrying ...")
-			}
+		log.Info().Msg("running background job ...")
+		if err := srv.warmUpPolicy.Retry(context.Background(), func(ctx context.Context) error {
+			return srv.backGroundJOB(ctx, model.RefreshTriggerWarmUp)
+		}); err != nil {
+			log.Error().Err(err).Msg("error warming up cache, giving up")
+		} else {
+			log.Info().Msg("background job ran successfully")
 		}
 
-		//runs every 3 hours
-		ticker := time.NewTicker(3 * time.Hour)
-		defer ticker.Stop()
-
-		for range ticker.C {
-			if err := srv.backGroundJOB(); err != nil {
-				log.Error().Err(err).Msg("error running background job")
-			} else {
-				log.Info().Msg("background job ran successfully")
-			}
+		if err := srv.reconcileCommentCounts(context.Background()); err != nil {
+			log.Error().Err(err).Msg("error reconciling comment counts")
 		}
 	}()
 
 	return srv
 }
 
-func (s service) GetMovies(page, pageSize int) ([]model.Movie, int64, error) {
+// defaultWarmUpPolicy retries the warm-up until it succeeds, backing off up to
+// 5 minutes between attempts so a SWAPI outage is not hammered.
+func defaultWarmUpPolicy() backoff.Policy {
+	p := backoff.DefaultPolicy()
+	p.MaxInterval = 5 * time.Minute
+	p.OnRetry = func(attempt int, delay time.Duration, err error) {
+		log.Error().Err(err).Int("attempt", attempt).Dur("retry_in", delay).Msg("error running background job, retrying ...")
+	}
+	return p
+}
+
+// defaultChunkPolicy fetches a chunk of characters up to 3 times, the swapi
+// client sends each request of a chunk once
+func defaultChunkPolicy() backoff.Policy {
+	p := backoff.DefaultPolicy()
+	p.InitialInterval = time.Second
+	p.MaxAttempts = 3
+	p.OnRetry = func(attempt int, delay time.Duration, err error) {
+		log.Warn().Err(err).Int("attempt", attempt).Dur("retry_in", delay).Msg("error getting character chunk, retrying ...")
+	}
+	return p
+}
+
+func (s service) GetMovies(ctx context.Context, page, pageSize int) ([]model.Movie, int64, error) {
 
-	movies, count, err := s.cache.GetMovies(page, pageSize)
+	movies, count, err := s.cache.GetMovies(ctx, page, pageSize)
 	if err != nil {
-		log.Debug().Err(err).Msg("error getting movies from cache")
+		log.Ctx(ctx).Debug().Err(err).Msg("error getting movies from cache")
 		return nil, 0, errors.New("error getting movies from cache")
 	}
 
 	var movieList []model.Movie
 	for _, movie := range movies {
 
-		commentCount, err := s.commentRepository.GetCommentCountByMovieID(movie.ID)
+		commentCount, err := s.commentRepository.GetCommentCountByMovieID(ctx, movie.ID)
 		if err != nil {
-			log.Error().Err(err).Msg("error getting comment count")
+			log.Ctx(ctx).Error().Err(err).Msg("error getting comment count")
 			return nil, 0, errors.New("error getting comment count")
 		}
 
@@ -89,18 +310,18 @@ func (s service) GetMovies(page, pageSize int) ([]model.Movie, int64, error) {
 	return movieList, count, nil
 }
 
-func (s service) GetMovieByID(movieID int) (*model.Movie, error) {
+func (s service) GetMovieByID(ctx context.Context, movieID int) (*model.Movie, error) {
 
 	//check if movie exists
-	movie, err := s.cache.GetMovieByID(movieID)
+	movie, err := s.cache.GetMovieByID(ctx, movieID)
 	if err != nil {
-		log.Debug().Err(err).Msg("movie not found")
+		log.Ctx(ctx).Debug().Err(err).Msg("movie not found")
 		return nil, errors.New("movie not found")
 	}
 
-	commentCount, err := s.commentRepository.GetCommentCountByMovieID(movie.ID)
+	commentCount, err := s.commentRepository.GetCommentCountByMovieID(ctx, movie.ID)
 	if err != nil {
-		log.Error().Err(err).Msg("error getting comment count")
+		log.Ctx(ctx).Error().Err(err).Msg("error getting comment count")
 		return nil, errors.New("error getting comment count")
 	}
 
@@ -113,22 +334,22 @@ func (s service) GetMovieByID(movieID int) (*model.Movie, error) {
 	}, nil
 }
 
-func (s service) GetCharactersByMovieID(arg model.GetCharactersByMovieIDArgs) (*model.CharacterList, int64, error) {
+func (s service) GetCharactersByMovieID(ctx context.Context, arg model.GetCharactersByMovieIDArgs) (*model.CharacterList, int64, error) {
 	//check if movie exists
-	movie, err := s.cache.GetMovieByID(arg.MovieID)
+	movie, err := s.cache.GetMovieByID(ctx, arg.MovieID)
 	if err != nil {
-		log.Debug().Err(err).Msg("movie not found")
+		log.Ctx(ctx).Debug().Err(err).Msg("movie not found")
 		return nil, 0, errors.New("movie not found")
 	}
 
-	characters, count, err := s.cache.GetCharactersByMovieID(movie.ID, arg.Page, arg.PageSize, ports.GetCharacterFiler{
+	characters, count, err := s.cache.GetCharactersByMovieID(ctx, movie.ID, arg.Page, arg.PageSize, ports.GetCharacterFiler{
 		SortKey:   arg.SortKey,
 		SortOrder: arg.SortOrder,
 		Gender:    arg.Gender,
 	})
 
 	if err != nil {
-		log.Error().Err(err).Msg("error getting characters")
+		log.Ctx(ctx).Error().Err(err).Msg("error getting characters")
 		return nil, 0, errors.New("error getting characters")
 	}
 
@@ -142,14 +363,18 @@ func (s service) GetCharactersByMovieID(arg model.GetCharactersByMovieIDArgs) (*
 	for _, character := range characters {
 		feets, inches = model.FeetsInches(character.HeightCm)
 		characterList.Characters = append(characterList.Characters, model.CharacterList_Character{
-			Name:     character.Name,
-			Gender:   character.Gender,
-			HeightCm: character.HeightCm,
-			HeightFt: feets,
-			HeightIn: inches,
+			Name:        character.Name,
+			Gender:      character.Gender,
+			HeightCm:    character.HeightCm,
+			HeightKnown: character.HeightKnown,
+			HeightFt:    feets,
+			HeightIn:    inches,
 		})
 
-		characterList.TotalCm += character.HeightCm
+		// an unknown height is not 0 cm
+		if character.HeightKnown {
+			characterList.TotalCm += character.HeightCm
+		}
 	}
 
 	characterList.TotalFt, characterList.TotalIn = model.FeetsInches(characterList.TotalCm)
@@ -158,53 +383,66 @@ func (s service) GetCharactersByMovieID(arg model.GetCharactersByMovieIDArgs) (*
 	return &characterList, count, nil
 }
 
-func (s service) ValidateMovieID(movieID int) error {
+func (s service) ValidateMovieID(ctx context.Context, movieID int) error {
 	//check if movie exists
-	_, err := s.cache.GetMovieByID(movieID)
+	_, err := s.cache.GetMovieByID(ctx, movieID)
 	if err != nil {
-		log.Debug().Err(err).Msg("movie not found")
+		log.Ctx(ctx).Debug().Err(err).Msg("movie not found")
 		return errors.New("movie not found")
 	}
 
 	return nil
 }
 
-func (s service) SaveComment(movieID int, comment model.Comment) error {
+func (s service) SaveComment(ctx context.Context, movieID int, comment model.Comment) error {
 
 	//check if movie exists
-	_, err := s.cache.GetMovieByID(movieID)
+	_, err := s.cache.GetMovieByID(ctx, movieID)
 	if err != nil {
-		log.Debug().Err(err).Msg("movie not found")
+		log.Ctx(ctx).Debug().Err(err).Msg("movie not found")
 		return errors.New("movie not found")
 	}
 
+	id := uuid.New()
 	comment = model.Comment{
-		ID:           uuid.New(),
+		ID:           id,
 		SwapiMovieID: comment.SwapiMovieID,
 		Message:      comment.Message,
 		IPv4Addr:     comment.IPv4Addr,
 		CreatedAt:    comment.CreatedAt,
 	}
 
-	if err := s.commentRepository.AddComment(comment); err != nil {
-		log.Error().Err(err).Msg("error saving comment")
+	if err := s.commentRepository.AddComment(ctx, &comment); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error saving comment")
 		return errors.New("error saving comment")
 	}
 
-	return err
+	// a repeated comment comes back as the original one, already announced
+	if comment.ID != id {
+		return nil
+	}
+
+	// live streams only; a subscriber that misses it catches up when resuming
+	if s.broker != nil {
+		if err := s.broker.Publish(context.Background(), comment.EventData()); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error publishing comment")
+		}
+	}
+
+	return nil
 }
 
-func (s service) GetComment(movieID int, page, pageSize int) ([]model.Comment, int64, error) {
+func (s service) GetComment(ctx context.Context, movieID int, page, pageSize int) ([]model.Comment, int64, error) {
 	//check if movie exists
-	movie, err := s.cache.GetMovieByID(movieID)
+	movie, err := s.cache.GetMovieByID(ctx, movieID)
 	if err != nil {
-		log.Debug().Err(err).Msg("movie not found")
+		log.Ctx(ctx).Debug().Err(err).Msg("movie not found")
 		return nil, 0, errors.New("movie not found")
 	}
 
-	comments, count, err := s.commentRepository.GetCommentsByMovieID(movie.ID, page, pageSize)
+	comments, count, err := s.commentRepository.GetCommentsByMovieID(ctx, movie.ID, page, pageSize)
 	if err != nil {
-		log.Error().Err(err).Msg("error getting comments")
+		log.Ctx(ctx).Error().Err(err).Msg("error getting comments")
 		return nil, 0, err
 	}
 
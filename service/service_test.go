@@ -1,7 +1,116 @@
 package service
 
-import "testing"
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/backoff"
+	"github.com/iamnator/movie-api/pkg/clock"
+	"github.com/iamnator/movie-api/service/ports/mocks"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+)
 
 func TestService_GetMovieByID(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cache := mocks.NewMockICache(ctrl)
+	repo := mocks.NewMockICommentRepository(ctrl)
+	s := service{cache: cache, commentRepository: repo, clock: clock.System}
+
+	// the count copied on the cached movie may lag, the database's is served
+	cache.EXPECT().GetMovieByID(gomock.Any(), 1).Return(&model.MovieDetails{ID: 1, Name: "A New Hope", CommentCount: 2}, nil)
+	repo.EXPECT().GetCommentCountByMovieID(gomock.Any(), 1).Return(int64(3), nil)
+
+	movie, err := s.GetMovieByID(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if movie.Name != "A New Hope" || movie.CommentCount != 3 {
+		t.Errorf("movie=%+v | expected A New Hope with 3 comments", movie)
+	}
+
+	cache.EXPECT().GetMovieByID(gomock.Any(), 1).Return(&model.MovieDetails{ID: 1}, nil)
+	repo.EXPECT().GetCommentCountByMovieID(gomock.Any(), 1).Return(int64(0), errors.New("postgres is down"))
+
+	if _, err := s.GetMovieByID(context.Background(), 1); err == nil {
+		t.Error("expected an error when the comments cannot be counted")
+	}
+}
+
+func TestService_GetCharactersByMovieID_UnknownHeights(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cache := mocks.NewMockICache(ctrl)
+	s := service{cache: cache, clock: clock.System}
+
+	cache.EXPECT().GetMovieByID(gomock.Any(), 1).Return(&model.MovieDetails{ID: 1}, nil)
+	cache.EXPECT().GetCharactersByMovieID(gomock.Any(), 1, 1, 10, gomock.Any()).Return([]model.Character{
+		{ID: 1, Name: "Luke Skywalker", HeightCm: 172, HeightKnown: true},
+		{ID: 2, Name: "Arvel Crynyd"},
+		{ID: 3, Name: "Yoda", HeightCm: 66, HeightKnown: true},
+	}, int64(3), nil)
+
+	list, _, err := s.GetCharactersByMovieID(context.Background(), model.GetCharactersByMovieIDArgs{MovieID: 1, Page: 1, PageSize: 10})
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+
+	// the unknown height is left out of the total rather than counted as 0
+	if list.TotalCm != 238 {
+		t.Errorf("total_cm=%d | expected=%d", list.TotalCm, 238)
+	}
+	if list.Characters[1].HeightKnown {
+		t.Errorf("height_known=%v | expected=%v", list.Characters[1].HeightKnown, false)
+	}
+}
+
+func TestNewServices_SchedulerStartsDuringWarmUp(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	cache := mocks.NewMockICache(ctrl)
+	repo := mocks.NewMockICommentRepository(ctrl)
+	swapiClient := mocks.NewMockISwapi(ctrl)
+
+	// swapi hangs through the whole warm-up
+	release := make(chan struct{})
+	warmedUp := make(chan struct{})
+	swapiClient.EXPECT().GetFilms(gomock.Any()).DoAndReturn(func(ctx context.Context, id ...int) ([]lib.Film, error) {
+		<-release
+		return nil, errors.New("swapi is down")
+	})
+	cache.EXPECT().GetMovieVersions(gomock.Any()).DoAndReturn(func(ctx context.Context) (map[int]time.Time, error) {
+		close(warmedUp)
+		return nil, errors.New("redis is down")
+	})
+
+	policy := backoff.DefaultPolicy()
+	policy.MaxAttempts = 1
+	// none of the jobs comes due during the test
+	schedules := make(map[string]string, len(DefaultSchedules))
+	for name := range DefaultSchedules {
+		schedules[name] = "0 0 1 1 *"
+	}
+	s := NewServices(cache, repo, swapiClient, WithWarmUpPolicy(policy), WithSchedules(schedules))
+
+	scheduled := func() bool {
+		for _, job := range s.GetSchedule(context.Background()) {
+			if job.NextRunAt == nil {
+				return false
+			}
+		}
+		return true
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for !scheduled() && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if !scheduled() {
+		t.Errorf("jobs=%+v | expected every job scheduled while the warm-up runs", s.GetSchedule(context.Background()))
+	}
 
-}
\ No newline at end of file
+	close(release)
+	<-warmedUp
+}
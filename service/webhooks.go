@@ -0,0 +1,293 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/backoff"
+	"github.com/iamnator/movie-api/pkg/netguard"
+	"github.com/iamnator/movie-api/service/ports"
+)
+
+var (
+	ErrWebhooksDisabled        = errors.New("webhooks are not configured")
+	ErrWebhookNotFound         = errors.New("webhook not found")
+	ErrWebhookDeliveryNotFound = errors.New("webhook delivery not found")
+	ErrWebhookTargetForbidden  = errors.New("webhook url must be a public http(s) url")
+)
+
+const (
+	// webhookPollInterval is how often due deliveries are looked for
+	webhookPollInterval = 5 * time.Second
+	webhookBatchSize    = 20
+
+	// webhookSendTimeout bounds a single delivery attempt, webhookClaimLease
+	// must outlast a whole batch so no other instance sends it meanwhile
+	webhookSendTimeout = 10 * time.Second
+	webhookClaimLease  = 5 * time.Minute
+)
+
+// defaultWebhookPolicy spaces delivery attempts from 30 seconds to an hour
+// apart and dead-letters a delivery after 8 failed attempts.
+func defaultWebhookPolicy() backoff.Policy {
+	p := backoff.DefaultPolicy()
+	p.InitialInterval = 30 * time.Second
+	p.MaxInterval = time.Hour
+	p.MaxAttempts = 8
+	return p
+}
+
+func newWebhookEvent(t model.WebhookEventType, createdAt time.Time, data interface{}) model.WebhookEvent {
+	return model.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      t,
+		CreatedAt: createdAt,
+		Data:      data,
+	}
+}
+
+// publish queues a delivery of every event to every subscription interested in
+// it. Failures are logged only, the change that caused the events is done.
+func (s service) publish(ctx context.Context, events ...model.WebhookEvent) {
+	if s.webhooks == nil || len(events) == 0 {
+		return
+	}
+
+	subscriptions, err := s.webhooks.GetSubscriptions(ctx)
+	if err != nil {
+		log.Error().Err(err).Msg("error getting webhook subscriptions")
+		return
+	}
+
+	var deliveries []model.WebhookDelivery
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			log.Error().Err(err).Str("event", string(event.Type)).Msg("error encoding webhook event")
+			continue
+		}
+
+		for _, subscription := range subscriptions {
+			if !subscription.Subscribed(event.Type) {
+				continue
+			}
+
+			deliveries = append(deliveries, model.WebhookDelivery{
+				ID:             uuid.New(),
+				SubscriptionID: subscription.ID,
+				EventID:        event.ID,
+				EventType:      event.Type,
+				Payload:        model.RawJSON(payload),
+				State:          model.WebhookDeliveryPending,
+				NextAttemptAt:  event.CreatedAt,
+				CreatedAt:      event.CreatedAt,
+			})
+		}
+	}
+
+	if err := s.webhooks.AddDeliveries(ctx, deliveries); err != nil {
+		log.Error().Err(err).Int("deliveries", len(deliveries)).Msg("error queueing webhook deliveries")
+	}
+}
+
+// dispatchWebhooks sends due deliveries until ctx is done
+func (s service) dispatchWebhooks(ctx context.Context) {
+	for {
+		s.sendDueWebhooks(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-s.clock.After(webhookPollInterval):
+		}
+	}
+}
+
+// sendDueWebhooks claims a batch of due deliveries and attempts each once
+func (s service) sendDueWebhooks(ctx context.Context) {
+	deliveries, err := s.webhooks.ClaimDueDeliveries(ctx, s.clock.Now(), webhookBatchSize, webhookClaimLease)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error claiming webhook deliveries")
+		return
+	}
+
+	for _, delivery := range deliveries {
+		s.deliverWebhook(ctx, delivery)
+	}
+}
+
+// deliverWebhook attempts a delivery and records the outcome: delivered, due
+// again after a backoff, or dead once the retry policy is exhausted.
+func (s service) deliverWebhook(ctx context.Context, delivery model.WebhookDelivery) {
+	logger := log.With().Str("delivery_id", delivery.ID.String()).Str("event", string(delivery.EventType)).Logger()
+
+	subscription, err := s.webhooks.GetSubscription(ctx, delivery.SubscriptionID)
+	switch {
+	case errors.Is(err, ports.ErrNotFound):
+		err = errors.New("subscription deleted")
+		delivery.Attempts = s.webhookPolicy.MaxAttempts // nothing left to retry
+	case err == nil:
+		sendCtx, cancel := context.WithTimeout(ctx, webhookSendTimeout)
+		err = s.webhookSender.Send(sendCtx, subscription.URL, subscription.Secret, delivery)
+		cancel()
+	default:
+		// the delivery stays claimed and is retried once the claim expires
+		logger.Error().Err(err).Msg("error getting webhook subscription")
+		return
+	}
+
+	now := s.clock.Now()
+	delivery.Attempts++
+
+	switch {
+	case err == nil:
+		delivery.State = model.WebhookDeliveryDelivered
+		delivery.DeliveredAt = &now
+		delivery.LastError = ""
+	case s.webhookPolicy.MaxAttempts > 0 && delivery.Attempts >= s.webhookPolicy.MaxAttempts:
+		logger.Error().Err(err).Int("attempts", delivery.Attempts).Msg("giving up on webhook delivery")
+		delivery.State = model.WebhookDeliveryDead
+		delivery.LastError = err.Error()
+	default:
+		delay := s.webhookPolicy.Delay(delivery.Attempts)
+		logger.Warn().Err(err).Int("attempt", delivery.Attempts).Dur("retry_in", delay).Msg("error delivering webhook, retrying ...")
+		delivery.NextAttemptAt = now.Add(delay)
+		delivery.LastError = err.Error()
+	}
+
+	if err := s.webhooks.UpdateDelivery(ctx, delivery); err != nil {
+		logger.Error().Err(err).Msg("error updating webhook delivery")
+	}
+}
+
+// newWebhookSecret returns a random signing secret for subscriptions created without one
+func newWebhookSecret() (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func (s service) CreateWebhook(ctx context.Context, req model.AddWebhookRequest) (*model.WebhookSubscription, error) {
+	if s.webhooks == nil {
+		return nil, ErrWebhooksDisabled
+	}
+
+	// the sender checks the address again when it dials, the name may be
+	// pointed elsewhere meanwhile
+	if err := netguard.CheckURL(ctx, req.URL); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrWebhookTargetForbidden, err)
+	}
+
+	secret := req.Secret
+	if secret == "" {
+		var err error
+		if secret, err = newWebhookSecret(); err != nil {
+			log.Ctx(ctx).Error().Err(err).Msg("error generating webhook secret")
+			return nil, errors.New("error generating webhook secret")
+		}
+	}
+
+	subscription := model.WebhookSubscription{
+		ID:        uuid.New(),
+		URL:       req.URL,
+		Events:    req.Events,
+		Secret:    secret,
+		CreatedAt: s.clock.Now().UTC(),
+	}
+
+	if err := s.webhooks.AddSubscription(ctx, &subscription); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error saving webhook")
+		return nil, errors.New("error saving webhook")
+	}
+
+	// the secret is only ever returned here
+	return &subscription, nil
+}
+
+func (s service) GetWebhooks(ctx context.Context) ([]model.WebhookSubscription, error) {
+	if s.webhooks == nil {
+		return nil, ErrWebhooksDisabled
+	}
+
+	subscriptions, err := s.webhooks.GetSubscriptions(ctx)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error getting webhooks")
+		return nil, errors.New("error getting webhooks")
+	}
+
+	for i := range subscriptions {
+		subscriptions[i].Secret = ""
+	}
+
+	return subscriptions, nil
+}
+
+func (s service) DeleteWebhook(ctx context.Context, id uuid.UUID) error {
+	if s.webhooks == nil {
+		return ErrWebhooksDisabled
+	}
+
+	if err := s.webhooks.DeleteSubscription(ctx, id); err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			return ErrWebhookNotFound
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("error deleting webhook")
+		return errors.New("error deleting webhook")
+	}
+
+	return nil
+}
+
+func (s service) GetWebhookDeliveries(ctx context.Context, state model.WebhookDeliveryState, page, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	if s.webhooks == nil {
+		return nil, 0, ErrWebhooksDisabled
+	}
+
+	deliveries, count, err := s.webhooks.GetDeliveries(ctx, state, page, pageSize)
+	if err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error getting webhook deliveries")
+		return nil, 0, errors.New("error getting webhook deliveries")
+	}
+
+	return deliveries, count, nil
+}
+
+// ReplayWebhookDelivery queues a delivery again with a fresh retry budget,
+// typically one from the dead-letter list.
+func (s service) ReplayWebhookDelivery(ctx context.Context, id uuid.UUID) (*model.WebhookDelivery, error) {
+	if s.webhooks == nil {
+		return nil, ErrWebhooksDisabled
+	}
+
+	delivery, err := s.webhooks.GetDelivery(ctx, id)
+	if err != nil {
+		if errors.Is(err, ports.ErrNotFound) {
+			return nil, ErrWebhookDeliveryNotFound
+		}
+		log.Ctx(ctx).Error().Err(err).Msg("error getting webhook delivery")
+		return nil, errors.New("error getting webhook delivery")
+	}
+
+	delivery.State = model.WebhookDeliveryPending
+	delivery.Attempts = 0
+	delivery.NextAttemptAt = s.clock.Now()
+	delivery.DeliveredAt = nil
+
+	if err := s.webhooks.UpdateDelivery(ctx, *delivery); err != nil {
+		log.Ctx(ctx).Error().Err(err).Msg("error replaying webhook delivery")
+		return nil, errors.New("error replaying webhook delivery")
+	}
+
+	return delivery, nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+
+	"github.com/iamnator/movie-api/model"
+	"github.com/iamnator/movie-api/pkg/backoff"
+	"github.com/iamnator/movie-api/service/ports"
+	"github.com/iamnator/movie-api/service/ports/mocks"
+)
+
+// fixedClock always returns the same time
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time { return time.Time(c) }
+
+func (c fixedClock) After(d time.Duration) <-chan time.Time {
+	ch := make(chan time.Time, 1)
+	ch <- time.Time(c).Add(d)
+	return ch
+}
+
+func newWebhookService(ctrl *gomock.Controller, now time.Time) (service, *mocks.MockIWebhookRepository, *mocks.MockIWebhookSender) {
+	repo := mocks.NewMockIWebhookRepository(ctrl)
+	sender := mocks.NewMockIWebhookSender(ctrl)
+
+	return service{
+		clock:         fixedClock(now),
+		webhooks:      repo,
+		webhookSender: sender,
+		webhookPolicy: backoff.Policy{InitialInterval: time.Minute, Multiplier: 2, MaxAttempts: 3},
+	}, repo, sender
+}
+
+func Test_publish_OnlySubscribedEvents(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, repo, _ := newWebhookService(ctrl, time.Now())
+
+	all := model.WebhookSubscription{ID: uuid.New()}
+	comments := model.WebhookSubscription{ID: uuid.New(), Events: pq.StringArray{string(model.EventCommentCreated)}}
+
+	repo.EXPECT().GetSubscriptions(gomock.Any()).Return([]model.WebhookSubscription{all, comments}, nil)
+	repo.EXPECT().AddDeliveries(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, deliveries []model.WebhookDelivery) error {
+		got := make(map[uuid.UUID][]model.WebhookEventType)
+		for _, delivery := range deliveries {
+			got[delivery.SubscriptionID] = append(got[delivery.SubscriptionID], delivery.EventType)
+			if delivery.State != model.WebhookDeliveryPending {
+				t.Errorf("state=%s | expected=%s", delivery.State, model.WebhookDeliveryPending)
+			}
+		}
+
+		if len(got[all.ID]) != 2 {
+			t.Errorf("events=%v | expected both events for the catch-all subscription", got[all.ID])
+		}
+		if len(got[comments.ID]) != 1 || got[comments.ID][0] != model.EventCommentCreated {
+			t.Errorf("events=%v | expected only %s", got[comments.ID], model.EventCommentCreated)
+		}
+		return nil
+	})
+
+	now := time.Now()
+	s.publish(context.Background(),
+		newWebhookEvent(model.EventMovieUpdated, now, model.MovieDetails{ID: 1}),
+		newWebhookEvent(model.EventCommentCreated, now, model.CommentEventData{MovieID: 1}),
+	)
+}
+
+func Test_deliverWebhook(t *testing.T) {
+	now := time.Date(2023, 2, 1, 12, 0, 0, 0, time.UTC)
+	subscription := &model.WebhookSubscription{ID: uuid.New(), URL: "https://example.com/hook", Secret: "secret"}
+
+	tests := []struct {
+		name            string
+		attempts        int
+		subscriptionErr error
+		sendErr         error
+		wantState       model.WebhookDeliveryState
+		wantAttempts    int
+		wantNextAttempt time.Time
+	}{
+		{
+			name:         "delivered",
+			wantState:    model.WebhookDeliveryDelivered,
+			wantAttempts: 1,
+		},
+		{
+			name:            "retried with backoff",
+			attempts:        1,
+			sendErr:         errors.New("webhook responded with 502"),
+			wantState:       model.WebhookDeliveryPending,
+			wantAttempts:    2,
+			wantNextAttempt: now.Add(2 * time.Minute),
+		},
+		{
+			name:         "dead once attempts are exhausted",
+			attempts:     2,
+			sendErr:      errors.New("webhook responded with 502"),
+			wantState:    model.WebhookDeliveryDead,
+			wantAttempts: 3,
+		},
+		{
+			name:            "dead when the subscription is deleted",
+			subscriptionErr: ports.ErrNotFound,
+			wantState:       model.WebhookDeliveryDead,
+			wantAttempts:    4,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			s, repo, sender := newWebhookService(ctrl, now)
+
+			delivery := model.WebhookDelivery{
+				ID:             uuid.New(),
+				SubscriptionID: subscription.ID,
+				EventType:      model.EventMovieUpdated,
+				State:          model.WebhookDeliveryPending,
+				Attempts:       tt.attempts,
+			}
+
+			if tt.subscriptionErr != nil {
+				repo.EXPECT().GetSubscription(gomock.Any(), subscription.ID).Return(nil, tt.subscriptionErr)
+			} else {
+				repo.EXPECT().GetSubscription(gomock.Any(), subscription.ID).Return(subscription, nil)
+				sender.EXPECT().Send(gomock.Any(), subscription.URL, subscription.Secret, delivery).Return(tt.sendErr)
+			}
+
+			repo.EXPECT().UpdateDelivery(gomock.Any(), gomock.Any()).DoAndReturn(func(_ context.Context, got model.WebhookDelivery) error {
+				if got.State != tt.wantState || got.Attempts != tt.wantAttempts {
+					t.Errorf("state=%s attempts=%d | expected state=%s attempts=%d", got.State, got.Attempts, tt.wantState, tt.wantAttempts)
+				}
+				if !tt.wantNextAttempt.IsZero() && !got.NextAttemptAt.Equal(tt.wantNextAttempt) {
+					t.Errorf("next_attempt_at=%s | expected=%s", got.NextAttemptAt, tt.wantNextAttempt)
+				}
+				if tt.wantState == model.WebhookDeliveryDelivered && got.DeliveredAt == nil {
+					t.Errorf("delivered_at is not set")
+				}
+				return nil
+			})
+
+			s.deliverWebhook(context.Background(), delivery)
+		})
+	}
+}
+
+func Test_CreateWebhook_RejectsInternalTargets(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	s, repo, _ := newWebhookService(ctrl, time.Now())
+
+	// nothing is saved
+	repo.EXPECT().AddSubscription(gomock.Any(), gomock.Any()).Times(0)
+
+	for _, url := range []string{
+		"ftp://93.184.216.34/hooks",
+		"http://localhost:8080/hooks",
+		"http://127.0.0.1/hooks",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://10.0.0.2/hooks",
+		"http://[::1]/hooks",
+	} {
+		_, err := s.CreateWebhook(context.Background(), model.AddWebhookRequest{URL: url})
+		if !errors.Is(err, ErrWebhookTargetForbidden) {
+			t.Errorf("url=%s | err=%v | expected=%v", url, err, ErrWebhookTargetForbidden)
+		}
+	}
+}
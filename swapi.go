@@ -0,0 +1,125 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"os"
+
+	"github.com/go-redis/redis/v8"
+	"github.com/go-resty/resty/v2"
+
+	"github.com/iamnator/movie-api/adapter/cache"
+	"github.com/iamnator/movie-api/config"
+	"github.com/iamnator/movie-api/pkg/backoff"
+	"github.com/iamnator/movie-api/pkg/metrics"
+	"github.com/iamnator/movie-api/thirdparty/swapi"
+	"github.com/iamnator/movie-api/thirdparty/swapi/fixture"
+	swapilib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitech"
+)
+
+// swapiSpareConns are kept alive on top of one connection per worker fetching
+// characters
+const swapiSpareConns = 5
+
+// newSwapi builds the swapi client of the commands refreshing the cache,
+// failing over from the base url to the fallbacks, or the snapshot standing
+// in for it offline. The breaker returned guards the base url, it is nil when
+// there is none. The redis cache of the responses uses redisClient.
+func newSwapi(cfg config.Config, redisClient *redis.Client) (*swapi.Failover, *swapi.Breaker, error) {
+	if cfg.Swapi.Source == "fixtures" {
+		snapshot := fixture.Bundled()
+		if cfg.Swapi.FixturesDir != "" {
+			var err error
+			if snapshot, err = fixture.Load(os.DirFS(cfg.Swapi.FixturesDir)); err != nil {
+				return nil, nil, fmt.Errorf("error loading swapi fixtures: %w", err)
+			}
+		}
+		return swapi.NewFailover(swapi.NamedBackend{Name: "fixtures", Swapi: fixture.NewSwapi(snapshot)}), nil, nil
+	}
+
+	// every backend shares the store, responses are cached by url
+	var cacheOpts []swapilib.Option
+	switch cfg.Swapi.Cache.Store {
+	case "disk":
+		store, err := swapilib.NewDiskStore(cfg.Swapi.Cache.Dir)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error opening swapi cache: %w", err)
+		}
+		cacheOpts = append(cacheOpts, swapilib.ResponseCache(store))
+	case "redis":
+		cacheOpts = append(cacheOpts, swapilib.ResponseCache(cache.NewResponseStore(redisClient, cfg.Swapi.Cache.TTL.Std())))
+	}
+
+	primary := config.SwapiBackend{Backend: cfg.Swapi.Backend, BaseURL: cfg.Swapi.BaseURL}
+
+	var backends []swapi.NamedBackend
+	var primaryBreaker *swapi.Breaker
+	for i, backend := range append([]config.SwapiBackend{primary}, cfg.Swapi.Fallbacks...) {
+		client, breaker := newSwapiBackend(cfg, backend, cacheOpts)
+
+		// readiness and the metrics report the breaker of the base url
+		if i == 0 && breaker != nil {
+			primaryBreaker = breaker
+			if err := metrics.RegisterSwapiBreaker(breaker.State); err != nil {
+				return nil, nil, err
+			}
+		}
+
+		name := backend.BaseURL
+		if u, err := url.Parse(backend.BaseURL); err == nil {
+			name = u.Host
+		}
+		backends = append(backends, swapi.NamedBackend{Name: name, Swapi: client})
+	}
+
+	failover := swapi.NewFailover(backends...)
+
+	if err := metrics.RegisterSwapiCache(func() (int64, int64, int64) {
+		stats := failover.CacheStats()
+		return stats.Hits, stats.Revalidated, stats.Misses
+	}); err != nil {
+		return nil, nil, err
+	}
+
+	return failover, primaryBreaker, nil
+}
+
+// newSwapiBackend builds the client of a backend, paced and guarded by its own
+// circuit breaker when they are enabled. The breaker is nil when there is none.
+func newSwapiBackend(cfg config.Config, backend config.SwapiBackend, cacheOpts []swapilib.Option) (*swapi.Swapi, *swapi.Breaker) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxIdleConnsPerHost = cfg.Swapi.Concurrency + swapiSpareConns
+
+	// every attempt is paced, and none is sent while the breaker is open
+	roundTripper := metrics.InstrumentSwapi(transport)
+	if cfg.Swapi.RateLimit.PerSecond > 0 {
+		roundTripper = swapi.NewLimiter(cfg.Swapi.RateLimit.PerSecond, cfg.Swapi.RateLimit.Burst, nil).Transport(roundTripper)
+	}
+	var breaker *swapi.Breaker
+	if cfg.Swapi.Breaker.Threshold > 0 {
+		breaker = swapi.NewBreaker(cfg.Swapi.Breaker.Threshold, cfg.Swapi.Breaker.Cooldown.Std(), nil)
+		roundTripper = breaker.Transport(roundTripper)
+	}
+
+	restyClient := resty.New()
+	restyClient.SetTransport(roundTripper)
+	restyClient.SetTimeout(cfg.Swapi.Timeout.Std())
+
+	retry := backoff.DefaultPolicy()
+	retry.MaxAttempts = cfg.Swapi.RetryAttempts
+
+	opts := append([]swapilib.Option{
+		swapilib.HTTPClient(restyClient.GetClient()),
+		swapilib.BaseURL(backend.BaseURL),
+		swapilib.Retry(retry),
+	}, cacheOpts...)
+
+	var client swapi.Backend = swapilib.NewClient(opts...)
+	if backend.Backend == "swapi.tech" {
+		client = swapitech.NewClient(opts...)
+	}
+
+	return swapi.NewBackendSwapi(client, cfg.Swapi.Concurrency), breaker
+}
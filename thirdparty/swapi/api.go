@@ -2,34 +2,68 @@ package swapi
 
 import (
 	"context"
-	"errors"
 	swapi "github.com/iamnator/movie-api/thirdparty/swapi/lib"
-	"github.com/rs/zerolog/log"
 	"net/http"
 	"sync"
-	"time"
 )
 
 type (
 	ISwapi interface {
 		GetFilms(ctx context.Context, id ...int) ([]swapi.Film, error)
 		GetCharacters(ctx context.Context, id ...int) ([]swapi.Person, error)
+		GetPlanets(ctx context.Context) ([]swapi.Planet, error)
+	}
+
+	// A Backend serves swapi's resources, decoding the json shape of its api:
+	// *lib.Client reads swapi.dev and its mirrors, swapitech.Client reads
+	// swapi.tech
+	Backend interface {
+		Film(ctx context.Context, id int) (swapi.Film, error)
+		AllFilms(ctx context.Context) ([]swapi.Film, error)
+		Person(ctx context.Context, id int) (swapi.Person, error)
+		AllPeople(ctx context.Context) ([]swapi.Person, error)
+		AllPlanets(ctx context.Context) ([]swapi.Planet, error)
 	}
 
 	Swapi struct {
-		client *swapi.Client
+		client      Backend
+		concurrency int // of the requests fetching characters
 	}
 )
 
-func NewSwapi(hc *http.Client) (ISwapi, error) {
+var _ Backend = (*swapi.Client)(nil)
+
+// DefaultConcurrency is how many characters are fetched at once when
+// NewSwapi is given no concurrency
+const DefaultConcurrency = 5
+
+// NewSwapi returns a client of swapi.dev fetching up to concurrency characters at once
+func NewSwapi(hc *http.Client, concurrency int, opts ...swapi.Option) (*Swapi, error) {
+	opts = append([]swapi.Option{swapi.HTTPClient(hc)}, opts...)
 
-	opts := swapi.HTTPClient(hc)
+	return NewBackendSwapi(swapi.NewClient(opts...), concurrency), nil
+}
 
-	c := swapi.NewClient(opts)
+// NewBackendSwapi returns a client of the backend fetching up to concurrency
+// characters at once
+func NewBackendSwapi(backend Backend, concurrency int) *Swapi {
+	if concurrency < 1 {
+		concurrency = DefaultConcurrency
+	}
 
 	return &Swapi{
-		client: c,
-	}, nil
+		client:      backend,
+		concurrency: concurrency,
+	}
+}
+
+// CacheStats returns how the response cache of the backend answered the
+// requests so far, nothing when it has none
+func (s *Swapi) CacheStats() swapi.CacheStats {
+	if c, ok := s.client.(interface{ CacheStats() swapi.CacheStats }); ok {
+		return c.CacheStats()
+	}
+	return swapi.CacheStats{}
 }
 
 func (s *Swapi) GetFilms(ctx context.Context, id ...int) ([]swapi.Film, error) {
@@ -55,88 +89,71 @@ func (s *Swapi) GetFilms(ctx context.Context, id ...int) ([]swapi.Film, error) {
 	return films, nil
 }
 
+// GetCharacters returns the people with the given ids, in the order of the
+// ids, or every person. Up to the Swapi's concurrency are fetched at once; the
+// people fetched are returned with a *CharactersError listing the others. Once
+// the context is done no more are requested.
 func (s *Swapi) GetCharacters(ctx context.Context, ids ...int) ([]swapi.Person, error) {
+	if len(ids) == 0 {
+		return s.client.AllPeople(ctx)
+	}
 
-	var characters []swapi.Person
-	var errs []error
-	if len(ids) > 0 {
-		var character swapi.Person
-		var err error
-
-		charChan := make(chan swapi.Person, len(ids))
-		erChan := make(chan error, len(ids))
-		wait := sync.WaitGroup{}
+	// each worker only writes the slots of the ids it is given
+	people := make([]swapi.Person, len(ids))
+	errs := make([]error, len(ids))
 
-		go func() {
-			for p := range charChan {
-				log.Info().Msgf("fetched character: %s", p.Name)
-				characters = append(characters, p)
-			}
-		}()
+	workers := s.concurrency
+	if workers > len(ids) {
+		workers = len(ids)
+	}
 
+	next := make(chan int) // index of the id to fetch
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
 		go func() {
-			for e := range erChan {
-				log.Error().Msgf("error fetching character: %s", e.Error())
-				errs = append(errs, e)
+			defer wg.Done()
+			for i := range next {
+				people[i], errs[i] = s.client.Person(ctx, ids[i])
 			}
 		}()
+	}
 
-		idChan := make(chan int)
-
-		workers := func() {
-
-			defer wait.Done()
-			for {
-				select {
-				case id, ok := <-idChan:
-					if !ok {
-						return
-					}
-					character, err = s.client.Person(ctx, id)
-					if err != nil {
-						erChan <- err
-					} else {
-						charChan <- character
-					}
-
-				}
-			}
-
-		}
-
-		for i := 0; i < 5; i++ {
-			wait.Add(1)
-			go workers()
-		}
-
-		// push to workers
-		for _, id := range ids {
-			idChan <- id
+	// ids not handed out before the context is done are not requested
+	dispatched := 0
+dispatch:
+	for dispatched < len(ids) {
+		select {
+		case next <- dispatched:
+			dispatched++
+		case <-ctx.Done():
+			break dispatch
 		}
+	}
+	close(next)
+	wg.Wait()
 
-		close(idChan)
-
-		wait.Wait()
-		time.Sleep(time.Millisecond * 50) //allow time for workers to finish
-		close(charChan)
-		close(erChan)
+	for i := dispatched; i < len(ids); i++ {
+		errs[i] = ctx.Err()
+	}
 
-	} else {
-		_characters, err := s.client.AllPeople(ctx)
+	fetched := make([]swapi.Person, 0, len(ids))
+	failed := make(map[int]error)
+	for i, err := range errs {
 		if err != nil {
-			return nil, err
+			failed[ids[i]] = err
+			continue
 		}
-		characters = append(characters, _characters...)
+		fetched = append(fetched, people[i])
 	}
 
-	if len(errs) > 0 {
-		var ss string
-		for _, err := range errs {
-			ss += err.Error() + ", "
-		}
-
-		return characters, errors.New(ss)
+	if len(failed) > 0 {
+		return fetched, &CharactersError{Errors: failed}
 	}
+	return fetched, nil
+}
 
-	return characters, nil
+// GetPlanets returns every planet
+func (s *Swapi) GetPlanets(ctx context.Context) ([]swapi.Planet, error) {
+	return s.client.AllPlanets(ctx)
 }
@@ -0,0 +1,124 @@
+package swapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/iamnator/movie-api/thirdparty/swapi"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitest"
+)
+
+func Test_GetCharacters(t *testing.T) {
+	srv := swapitest.NewServer(swapitest.WithLatency(5 * time.Millisecond))
+	defer srv.Close()
+
+	s, _ := swapi.NewSwapi(http.DefaultClient, 3, lib.BaseURL(srv.URL))
+
+	characters, err := s.GetCharacters(context.Background(), 14, 1, 10, 2, 5, 3, 4, 20, 13)
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+
+	// in the order of the ids
+	expected := []string{"Han Solo", "Luke Skywalker", "Obi-Wan Kenobi", "C-3PO", "Leia Organa", "R2-D2", "Darth Vader", "Yoda", "Chewbacca"}
+	if len(characters) != len(expected) {
+		t.Fatalf("characters=%d | expected=%d", len(characters), len(expected))
+	}
+	for i := range expected {
+		if characters[i].Name != expected[i] {
+			t.Errorf("characters[%d]=%v | expected=%v", i, characters[i].Name, expected[i])
+		}
+	}
+
+	if n := srv.MaxInFlight(); n > 3 {
+		t.Errorf("concurrent requests=%d | expected=at most %d", n, 3)
+	}
+}
+
+func Test_GetCharacters_Failures(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	srv.Inject("people/2", swapitest.Fault{Status: http.StatusNotFound})
+	srv.Inject("people/4", swapitest.Fault{Malformed: true})
+
+	s, _ := swapi.NewSwapi(http.DefaultClient, 0, lib.BaseURL(srv.URL))
+
+	characters, err := s.GetCharacters(context.Background(), 1, 2, 3, 4, 5)
+	if len(characters) != 3 || characters[0].Name != "Luke Skywalker" || characters[1].Name != "R2-D2" || characters[2].Name != "Leia Organa" {
+		t.Errorf("characters=%+v | expected=Luke Skywalker, R2-D2, Leia Organa", characters)
+	}
+
+	var charErr *swapi.CharactersError
+	if !errors.As(err, &charErr) {
+		t.Fatalf("error=%v | expected=%T", err, charErr)
+	}
+	if ids := charErr.IDs(); len(ids) != 2 || ids[0] != 2 || ids[1] != 4 {
+		t.Errorf("failed=%v | expected=%v", ids, []int{2, 4})
+	}
+
+	// every failure stays reachable
+	var respErr *lib.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("error=%v | expected=404 of people/2", err)
+	}
+}
+
+func Test_GetCharacters_Cancelled(t *testing.T) {
+	srv := swapitest.NewServer(swapitest.WithLatency(time.Second))
+	defer srv.Close()
+
+	s, _ := swapi.NewSwapi(http.DefaultClient, 2, lib.BaseURL(srv.URL))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	ids := []int{1, 2, 3, 4, 5, 10, 11, 13, 14, 20}
+
+	start := time.Now()
+	characters, err := s.GetCharacters(ctx, ids...)
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("elapsed=%s | expected=returned once cancelled", elapsed)
+	}
+
+	if len(characters) != 0 {
+		t.Errorf("characters=%d | expected=%d", len(characters), 0)
+	}
+
+	var charErr *swapi.CharactersError
+	if !errors.As(err, &charErr) || len(charErr.IDs()) != len(ids) {
+		t.Fatalf("error=%v | expected=every id failed", err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error=%v | expected=%v", err, context.DeadlineExceeded)
+	}
+
+	// only the ids handed to the 2 workers before the deadline reached swapi
+	var requested int
+	for _, id := range ids {
+		requested += srv.Requests("people/" + strconv.Itoa(id))
+	}
+	if requested > 2 {
+		t.Errorf("requests=%d | expected=at most %d", requested, 2)
+	}
+}
+
+func Test_GetCharacters_All(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	s, _ := swapi.NewSwapi(http.DefaultClient, 0, lib.BaseURL(srv.URL))
+
+	characters, err := s.GetCharacters(context.Background())
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+	if len(characters) != 22 {
+		t.Errorf("characters=%d | expected=%d", len(characters), 22)
+	}
+}
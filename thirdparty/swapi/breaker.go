@@ -0,0 +1,165 @@
+package swapi
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/pkg/backoff"
+	"github.com/iamnator/movie-api/pkg/clock"
+)
+
+// states of a Breaker
+const (
+	BreakerClosed   = "closed"    // requests are sent
+	BreakerOpen     = "open"      // requests fail fast until the cooldown is over
+	BreakerHalfOpen = "half-open" // a single probe is sent, its outcome closes or reopens the breaker
+)
+
+// ErrCircuitOpen is returned for requests not sent to swapi while the breaker
+// is open. It is not retried.
+var ErrCircuitOpen = errors.New("swapi circuit breaker is open")
+
+// Breaker stops sending requests to swapi after consecutive failures, so an
+// outage is not hammered by every worker and retry, and probes it again once
+// cooled down. Transport errors, 429 and 5xx responses are failures.
+type Breaker struct {
+	threshold int
+	cooldown  time.Duration
+	clock     clock.Clock
+
+	mu       sync.Mutex
+	state    string
+	failures int // consecutive
+	openedAt time.Time
+	probing  bool // a half-open probe is in flight
+}
+
+// NewBreaker opens after threshold consecutive failures, for cooldown
+func NewBreaker(threshold int, cooldown time.Duration, c clock.Clock) *Breaker {
+	if c == nil {
+		c = clock.System
+	}
+
+	return &Breaker{
+		threshold: threshold,
+		cooldown:  cooldown,
+		clock:     c,
+		state:     BreakerClosed,
+	}
+}
+
+// State returns the state of the breaker, an open breaker past its cooldown
+// is reported half-open
+func (b *Breaker) State() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.state == BreakerOpen && b.clock.Now().Sub(b.openedAt) >= b.cooldown {
+		return BreakerHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a request may be sent, turning an open breaker
+// half-open once cooled down
+func (b *Breaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case BreakerOpen:
+		if b.clock.Now().Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.setState(BreakerHalfOpen)
+		b.probing = true
+		return true
+	case BreakerHalfOpen:
+		if b.probing {
+			return false
+		}
+		b.probing = true
+		return true
+	default:
+		return true
+	}
+}
+
+// record counts the outcome of a request allowed through
+func (b *Breaker) record(failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.probing = false
+
+	if !failed {
+		b.failures = 0
+		b.setState(BreakerClosed)
+		return
+	}
+
+	b.failures++
+	if b.state == BreakerHalfOpen || b.failures >= b.threshold {
+		b.openedAt = b.clock.Now()
+		b.setState(BreakerOpen)
+	}
+}
+
+// abandon forgets a request allowed through without an outcome, letting
+// another probe through
+func (b *Breaker) abandon() {
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// setState changes the state, logging transitions; b.mu is held
+func (b *Breaker) setState(state string) {
+	if b.state == state {
+		return
+	}
+
+	event := log.Info()
+	if state == BreakerOpen {
+		event = log.Warn().Dur("cooldown", b.cooldown)
+	}
+	event.Str("from", b.state).Str("to", state).Int("failures", b.failures).Msg("swapi circuit breaker state changed")
+
+	b.state = state
+}
+
+// Transport guards the requests sent through next
+func (b *Breaker) Transport(next http.RoundTripper) http.RoundTripper {
+	return breakerTransport{breaker: b, next: next}
+}
+
+type breakerTransport struct {
+	breaker *Breaker
+	next    http.RoundTripper
+}
+
+func (t breakerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if !t.breaker.allow() {
+		// retrying can only fail again until the cooldown is over
+		return nil, backoff.Permanent(ErrCircuitOpen)
+	}
+
+	resp, err := t.next.RoundTrip(req)
+
+	switch {
+	case err != nil && (errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded)):
+		// given up by the caller, says nothing of swapi
+		t.breaker.abandon()
+	case err != nil:
+		t.breaker.record(true)
+	default:
+		t.breaker.record(resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= http.StatusInternalServerError)
+	}
+
+	return resp, err
+}
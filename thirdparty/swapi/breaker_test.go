@@ -0,0 +1,84 @@
+package swapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/iamnator/movie-api/thirdparty/swapi"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitest"
+)
+
+// manualClock only moves when the test says so
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+func (c *manualClock) After(d time.Duration) <-chan time.Time {
+	c.now = c.now.Add(d)
+	ch := make(chan time.Time, 1)
+	ch <- c.now
+	return ch
+}
+
+func Test_Breaker(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	clock := &manualClock{now: time.Unix(0, 0)}
+	breaker := swapi.NewBreaker(2, 30*time.Second, clock)
+	c := lib.NewClient(lib.BaseURL(srv.URL), lib.HTTPClient(&http.Client{Transport: breaker.Transport(http.DefaultTransport)}))
+	ctx := context.Background()
+
+	srv.Inject("people/1", swapitest.Fault{Status: http.StatusInternalServerError, Times: 3})
+
+	// a not found is an answer, it does not count
+	if _, err := c.Person(ctx, 1000); err == nil {
+		t.Fatalf("error=nil | expected=not found")
+	}
+
+	for i := 0; i < 2; i++ {
+		if _, err := c.Person(ctx, 1); err == nil {
+			t.Fatalf("error=nil | expected=internal server error")
+		}
+	}
+	if state := breaker.State(); state != swapi.BreakerOpen {
+		t.Fatalf("state=%v | expected=%v", state, swapi.BreakerOpen)
+	}
+
+	// open, requests fail without reaching swapi
+	if _, err := c.Person(ctx, 1); !errors.Is(err, swapi.ErrCircuitOpen) {
+		t.Errorf("error=%v | expected=%v", err, swapi.ErrCircuitOpen)
+	}
+	if n := srv.Requests("people/1"); n != 2 {
+		t.Errorf("requests=%d | expected=%d", n, 2)
+	}
+
+	// the probe fails, the breaker opens again
+	clock.now = clock.now.Add(30 * time.Second)
+	if state := breaker.State(); state != swapi.BreakerHalfOpen {
+		t.Errorf("state=%v | expected=%v", state, swapi.BreakerHalfOpen)
+	}
+	if _, err := c.Person(ctx, 1); err == nil || errors.Is(err, swapi.ErrCircuitOpen) {
+		t.Errorf("error=%v | expected=internal server error", err)
+	}
+	if state := breaker.State(); state != swapi.BreakerOpen {
+		t.Fatalf("state=%v | expected=%v", state, swapi.BreakerOpen)
+	}
+
+	// swapi recovered, the next probe closes it
+	clock.now = clock.now.Add(30 * time.Second)
+	if _, err := c.Person(ctx, 1); err != nil {
+		t.Errorf("error=%v | expected=nil", err)
+	}
+	if state := breaker.State(); state != swapi.BreakerClosed {
+		t.Errorf("state=%v | expected=%v", state, swapi.BreakerClosed)
+	}
+}
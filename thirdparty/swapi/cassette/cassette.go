@@ -0,0 +1,229 @@
+// Package cassette records the responses of an http api to a file and replays
+// them, so tests run on real responses without reaching the api. Inject a
+// Recorder in the swapi client with
+//
+//	lib.HTTPClient(&http.Client{Transport: recorder})
+//
+// record the cassette once against swapi.dev in ModeRecord, commit it, and
+// replay it in ModeReplay.
+package cassette
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// Mode is how a Recorder handles requests
+type Mode int
+
+const (
+	// ModeReplay answers requests from the cassette
+	ModeReplay Mode = iota
+	// ModeRecord sends requests on and records their responses
+	ModeRecord
+)
+
+// ErrUnmatched is returned in strict replay for a request the cassette holds
+// no response to
+var ErrUnmatched = errors.New("no recorded response matches the request")
+
+type (
+	// Interaction is a request and the response recorded for it
+	Interaction struct {
+		Request  Request  `json:"request"`
+		Response Response `json:"response"`
+	}
+
+	// Request is what a request is matched on, its method, path and query
+	Request struct {
+		Method string `json:"method"`
+		Path   string `json:"path"`
+		Query  string `json:"query,omitempty"` // encoded with sorted keys
+	}
+
+	Response struct {
+		StatusCode int         `json:"status_code"`
+		Header     http.Header `json:"header,omitempty"`
+		Body       string      `json:"body"`
+	}
+
+	// Recorder is an http.RoundTripper recording to or replaying from a
+	// cassette file
+	Recorder struct {
+		path      string
+		mode      Mode
+		strict    bool
+		transport http.RoundTripper
+
+		mu           sync.Mutex
+		interactions []Interaction
+		replayed     map[Request]int // responses served by request, in recorded order
+	}
+
+	Option func(*Recorder)
+)
+
+// Strict fails replayed requests the cassette holds no response to with
+// ErrUnmatched, rather than sending them on
+func Strict() Option {
+	return func(r *Recorder) {
+		r.strict = true
+	}
+}
+
+// Transport sends the requests recorded, or not matched in replay, instead of
+// http.DefaultTransport
+func Transport(rt http.RoundTripper) Option {
+	return func(r *Recorder) {
+		r.transport = rt
+	}
+}
+
+// New returns a recorder of the cassette at path. In ModeReplay the cassette
+// is read, in ModeRecord it is written by Save.
+func New(path string, mode Mode, opts ...Option) (*Recorder, error) {
+	r := &Recorder{
+		path:      path,
+		mode:      mode,
+		transport: http.DefaultTransport,
+		replayed:  make(map[Request]int),
+	}
+
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	if mode == ModeReplay {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("error reading cassette: %w", err)
+		}
+		if err := json.Unmarshal(data, &r.interactions); err != nil {
+			return nil, fmt.Errorf("error decoding cassette %s: %w", path, err)
+		}
+	}
+
+	return r, nil
+}
+
+// requestOf returns what req is matched on. The host is left out so a
+// cassette recorded against swapi.dev replays against any base url.
+func requestOf(req *http.Request) Request {
+	return Request{
+		Method: req.Method,
+		Path:   req.URL.Path,
+		Query:  req.URL.Query().Encode(),
+	}
+}
+
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	if r.mode == ModeRecord {
+		return r.record(req)
+	}
+
+	if resp, ok := r.replay(req); ok {
+		return resp, nil
+	}
+	if r.strict {
+		return nil, fmt.Errorf("%s %s: %w", req.Method, req.URL.RequestURI(), ErrUnmatched)
+	}
+	return r.transport.RoundTrip(req)
+}
+
+// replay answers req with the next response recorded for it, the last one
+// once they were all served
+func (r *Recorder) replay(req *http.Request) (*http.Response, bool) {
+	key := requestOf(req)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var matches []int
+	for i, interaction := range r.interactions {
+		if interaction.Request == key {
+			matches = append(matches, i)
+		}
+	}
+	if len(matches) == 0 {
+		return nil, false
+	}
+
+	n := r.replayed[key]
+	if n >= len(matches) {
+		n = len(matches) - 1
+	}
+	r.replayed[key]++
+
+	return r.interactions[matches[n]].Response.http(req), true
+}
+
+func (r *Recorder) record(req *http.Request) (*http.Response, error) {
+	resp, err := r.transport.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	recorded := Response{
+		StatusCode: resp.StatusCode,
+		Header:     resp.Header.Clone(),
+		Body:       string(body),
+	}
+
+	r.mu.Lock()
+	r.interactions = append(r.interactions, Interaction{Request: requestOf(req), Response: recorded})
+	r.mu.Unlock()
+
+	return recorded.http(req), nil
+}
+
+// Save writes the interactions recorded to the cassette file
+func (r *Recorder) Save() error {
+	if r.mode != ModeRecord {
+		return nil
+	}
+
+	r.mu.Lock()
+	data, err := json.MarshalIndent(r.interactions, "", "  ")
+	r.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(r.path), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(r.path, append(data, '\n'), 0o644)
+}
+
+// http returns the response as received for req
+func (resp Response) http(req *http.Request) *http.Response {
+	header := resp.Header.Clone()
+	if header == nil {
+		header = make(http.Header)
+	}
+
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", resp.StatusCode, http.StatusText(resp.StatusCode)),
+		StatusCode:    resp.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        header,
+		Body:          io.NopCloser(bytes.NewBufferString(resp.Body)),
+		ContentLength: int64(len(resp.Body)),
+		Request:       req,
+	}
+}
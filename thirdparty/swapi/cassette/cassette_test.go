@@ -0,0 +1,109 @@
+package cassette_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"path/filepath"
+	"testing"
+
+	"github.com/iamnator/movie-api/thirdparty/swapi/cassette"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitest"
+)
+
+func client(baseURL string, recorder *cassette.Recorder) *lib.Client {
+	return lib.NewClient(lib.BaseURL(baseURL), lib.HTTPClient(&http.Client{Transport: recorder}))
+}
+
+// record records the films and luke on a fake swapi, then stops it
+func record(t *testing.T) string {
+	t.Helper()
+
+	srv := swapitest.NewServer(swapitest.WithPageSize(4))
+	defer srv.Close()
+
+	path := filepath.Join(t.TempDir(), "swapi.json")
+	recorder, err := cassette.New(path, cassette.ModeRecord)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := client(srv.URL, recorder)
+	if _, err := c.AllFilms(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.Person(context.Background(), 1); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := recorder.Save(); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func Test_Replay(t *testing.T) {
+	path := record(t)
+
+	recorder, err := cassette.New(path, cassette.ModeReplay, cassette.Strict())
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+
+	// the pages link each other with the url of the stopped server, matching
+	// ignores the host
+	c := client("http://swapi.invalid", recorder)
+
+	films, err := c.AllFilms(context.Background())
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+	if len(films) != 6 {
+		t.Errorf("films=%d | expected=%d", len(films), 6)
+	}
+
+	for i := 0; i < 2; i++ {
+		person, err := c.Person(context.Background(), 1)
+		if err != nil {
+			t.Fatalf("error=%v | expected=nil", err)
+		}
+		if person.Name != "Luke Skywalker" {
+			t.Errorf("name=%v | expected=%v", person.Name, "Luke Skywalker")
+		}
+	}
+}
+
+func Test_Replay_Unmatched(t *testing.T) {
+	path := record(t)
+
+	strict, err := cassette.New(path, cassette.ModeReplay, cassette.Strict())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := client("http://swapi.invalid", strict).Person(context.Background(), 2); !errors.Is(err, cassette.ErrUnmatched) {
+		t.Errorf("error=%v | expected=%v", err, cassette.ErrUnmatched)
+	}
+
+	// without strict, unmatched requests are sent on
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	lenient, err := cassette.New(path, cassette.ModeReplay)
+	if err != nil {
+		t.Fatal(err)
+	}
+	person, err := client(srv.URL, lenient).Person(context.Background(), 2)
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+	if person.Name != "C-3PO" {
+		t.Errorf("name=%v | expected=%v", person.Name, "C-3PO")
+	}
+}
+
+func Test_New_MissingCassette(t *testing.T) {
+	if _, err := cassette.New(filepath.Join(t.TempDir(), "missing.json"), cassette.ModeReplay); err == nil {
+		t.Errorf("error=nil | expected=cassette not found")
+	}
+}
@@ -0,0 +1,45 @@
+package swapi
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// CharactersError lists the characters GetCharacters could not fetch, with
+// the error of each
+type CharactersError struct {
+	Errors map[int]error // by character id
+}
+
+// IDs returns the ids of the characters not fetched, sorted
+func (e *CharactersError) IDs() []int {
+	ids := make([]int, 0, len(e.Errors))
+	for id := range e.Errors {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func (e *CharactersError) Error() string {
+	ids := e.IDs()
+
+	msgs := make([]string, len(ids))
+	for i, id := range ids {
+		msgs[i] = fmt.Sprintf("%d: %s", id, e.Errors[id])
+	}
+	return fmt.Sprintf("error fetching characters %v: %s", ids, strings.Join(msgs, "; "))
+}
+
+// Unwrap returns the error of every character not fetched, so errors.Is and
+// errors.As see each of them
+func (e *CharactersError) Unwrap() []error {
+	ids := e.IDs()
+
+	errs := make([]error, len(ids))
+	for i, id := range ids {
+		errs[i] = e.Errors[id]
+	}
+	return errs
+}
@@ -0,0 +1,185 @@
+package swapi
+
+import (
+	"context"
+	"errors"
+	"sync"
+
+	"github.com/rs/zerolog/log"
+
+	swapi "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+)
+
+type (
+	// A NamedBackend is a client of one of the apis a Failover tries, named
+	// for the record of which served a request, e.g. after its host
+	NamedBackend struct {
+		Name  string
+		Swapi ISwapi
+	}
+
+	// Failover asks its backends in order, moving on to the next when one
+	// fails, so an outage of swapi.dev is served by a mirror or swapi.tech.
+	// Characters one backend could not fetch are asked of the next. It does
+	// not retry, that is left to the clients of the backends or their callers.
+	Failover struct {
+		backends []NamedBackend
+	}
+
+	// served records the backends answering the requests made with a context
+	// returned by Failover.Track
+	served struct {
+		mu    sync.Mutex
+		names []string // in the order they first answered
+	}
+
+	servedKey struct{}
+)
+
+var _ ISwapi = (*Failover)(nil)
+
+// NewFailover tries the backends in the order given
+func NewFailover(backends ...NamedBackend) *Failover {
+	return &Failover{
+		backends: backends,
+	}
+}
+
+// Track returns a context recording the backends answering the requests made
+// with it, and a func listing them in the order they first answered
+func (f *Failover) Track(ctx context.Context) (context.Context, func() []string) {
+	s := &served{}
+	return context.WithValue(ctx, servedKey{}, s), s.list
+}
+
+func (s *served) add(name string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, n := range s.names {
+		if n == name {
+			return
+		}
+	}
+	s.names = append(s.names, name)
+}
+
+func (s *served) list() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return append([]string(nil), s.names...)
+}
+
+func record(ctx context.Context, name string) {
+	if s, ok := ctx.Value(servedKey{}).(*served); ok {
+		s.add(name)
+	}
+}
+
+// CacheStats sums how the response caches of the backends answered the
+// requests so far
+func (f *Failover) CacheStats() swapi.CacheStats {
+	var total swapi.CacheStats
+	for _, b := range f.backends {
+		if c, ok := b.Swapi.(interface{ CacheStats() swapi.CacheStats }); ok {
+			stats := c.CacheStats()
+			total.Hits += stats.Hits
+			total.Revalidated += stats.Revalidated
+			total.Misses += stats.Misses
+		}
+	}
+	return total
+}
+
+// errNoBackend is returned by a Failover without backends
+var errNoBackend = errors.New("no swapi backend")
+
+// GetFilms returns the films of the first backend fetching them all
+func (f *Failover) GetFilms(ctx context.Context, ids ...int) ([]swapi.Film, error) {
+	return first(ctx, f.backends, "films", func(s ISwapi) ([]swapi.Film, error) {
+		return s.GetFilms(ctx, ids...)
+	})
+}
+
+// GetPlanets returns the planets of the first backend fetching them all
+func (f *Failover) GetPlanets(ctx context.Context) ([]swapi.Planet, error) {
+	return first(ctx, f.backends, "planets", func(s ISwapi) ([]swapi.Planet, error) {
+		return s.GetPlanets(ctx)
+	})
+}
+
+// GetCharacters returns the people with the given ids, in the order of the
+// ids, or every person. The ids a backend could not fetch are asked of the
+// next one; those none fetched are returned in a *CharactersError.
+func (f *Failover) GetCharacters(ctx context.Context, ids ...int) ([]swapi.Person, error) {
+	if len(ids) == 0 {
+		return first(ctx, f.backends, "characters", func(s ISwapi) ([]swapi.Person, error) {
+			return s.GetCharacters(ctx)
+		})
+	}
+	if len(f.backends) == 0 {
+		return nil, errNoBackend
+	}
+
+	found := make(map[int]swapi.Person, len(ids))
+	pending := ids
+	var failed map[int]error // of the pending ids, by the last backend asked
+	for _, b := range f.backends {
+		people, err := b.Swapi.GetCharacters(ctx, pending...)
+		if len(people) > 0 {
+			record(ctx, b.Name)
+		}
+		for _, person := range people {
+			found[person.GetID()] = person
+		}
+
+		var charErr *CharactersError
+		switch {
+		case err == nil:
+			pending, failed = nil, nil
+		case errors.As(err, &charErr):
+			pending, failed = charErr.IDs(), charErr.Errors
+		default:
+			failed = make(map[int]error, len(pending))
+			for _, id := range pending {
+				failed[id] = err
+			}
+		}
+
+		if len(pending) == 0 || ctx.Err() != nil {
+			break
+		}
+		log.Ctx(ctx).Warn().Err(err).Str("backend", b.Name).Ints("ids", pending).Msg("error getting characters from swapi backend")
+	}
+
+	people := make([]swapi.Person, 0, len(ids))
+	for _, id := range ids {
+		if person, ok := found[id]; ok {
+			people = append(people, person)
+		}
+	}
+
+	if len(pending) > 0 {
+		return people, &CharactersError{Errors: failed}
+	}
+	return people, nil
+}
+
+// first returns what the first backend get succeeds with
+func first[T any](ctx context.Context, backends []NamedBackend, what string, get func(ISwapi) (T, error)) (T, error) {
+	var zero T
+	err := errNoBackend
+	for _, b := range backends {
+		var v T
+		if v, err = get(b.Swapi); err == nil {
+			record(ctx, b.Name)
+			return v, nil
+		}
+		if ctx.Err() != nil {
+			return zero, err
+		}
+		log.Ctx(ctx).Warn().Err(err).Str("backend", b.Name).Msgf("error getting %s from swapi backend", what)
+	}
+	return zero, err
+}
@@ -0,0 +1,128 @@
+package swapi_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"reflect"
+	"testing"
+
+	"github.com/iamnator/movie-api/thirdparty/swapi"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitest"
+)
+
+// newFailover fails over from a primary to a secondary server
+func newFailover(t *testing.T) (*swapi.Failover, *swapitest.Server, *swapitest.Server) {
+	t.Helper()
+
+	primary, secondary := swapitest.NewServer(), swapitest.NewServer()
+	t.Cleanup(primary.Close)
+	t.Cleanup(secondary.Close)
+
+	p, _ := swapi.NewSwapi(http.DefaultClient, 0, lib.BaseURL(primary.URL))
+	s, _ := swapi.NewSwapi(http.DefaultClient, 0, lib.BaseURL(secondary.URL))
+
+	return swapi.NewFailover(
+		swapi.NamedBackend{Name: "primary", Swapi: p},
+		swapi.NamedBackend{Name: "secondary", Swapi: s},
+	), primary, secondary
+}
+
+func Test_Failover_GetFilms(t *testing.T) {
+	f, primary, secondary := newFailover(t)
+
+	ctx, served := f.Track(context.Background())
+
+	films, err := f.GetFilms(ctx)
+	if err != nil || len(films) != 6 {
+		t.Fatalf("films=%d error=%v | expected=%d", len(films), err, 6)
+	}
+	if names := served(); !reflect.DeepEqual(names, []string{"primary"}) {
+		t.Errorf("served=%v | expected=%v", names, []string{"primary"})
+	}
+
+	primary.Inject("films", swapitest.Fault{Status: http.StatusServiceUnavailable})
+
+	ctx, served = f.Track(context.Background())
+
+	films, err = f.GetFilms(ctx)
+	if err != nil || len(films) != 6 {
+		t.Fatalf("films=%d error=%v | expected=%d", len(films), err, 6)
+	}
+	if names := served(); !reflect.DeepEqual(names, []string{"secondary"}) {
+		t.Errorf("served=%v | expected=%v", names, []string{"secondary"})
+	}
+	if n := secondary.Requests("films"); n != 1 {
+		t.Errorf("secondary requests=%d | expected=%d", n, 1)
+	}
+}
+
+func Test_Failover_GetCharacters(t *testing.T) {
+	f, primary, secondary := newFailover(t)
+
+	primary.Inject("people/2", swapitest.Fault{Status: http.StatusInternalServerError})
+	primary.Inject("people/4", swapitest.Fault{Status: http.StatusInternalServerError})
+	secondary.Inject("people/4", swapitest.Fault{Status: http.StatusNotFound})
+
+	ctx, served := f.Track(context.Background())
+
+	characters, err := f.GetCharacters(ctx, 3, 2, 1, 4)
+
+	// those the primary missed come from the secondary, in the order of the ids
+	var names []string
+	for _, c := range characters {
+		names = append(names, c.Name)
+	}
+	if expected := []string{"R2-D2", "C-3PO", "Luke Skywalker"}; !reflect.DeepEqual(names, expected) {
+		t.Errorf("characters=%v | expected=%v", names, expected)
+	}
+
+	var charErr *swapi.CharactersError
+	if !errors.As(err, &charErr) || !reflect.DeepEqual(charErr.IDs(), []int{4}) {
+		t.Fatalf("error=%v | expected=character 4 failed", err)
+	}
+	var respErr *lib.ResponseError
+	if !errors.As(charErr.Errors[4], &respErr) || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("error=%v | expected=the secondary's 404", charErr.Errors[4])
+	}
+
+	if names := served(); !reflect.DeepEqual(names, []string{"primary", "secondary"}) {
+		t.Errorf("served=%v | expected=%v", names, []string{"primary", "secondary"})
+	}
+	if n := secondary.Requests("people/1"); n != 0 {
+		t.Errorf("secondary requests=%d | expected=%d for a character the primary fetched", n, 0)
+	}
+}
+
+func Test_Failover_Cancelled(t *testing.T) {
+	f, primary, secondary := newFailover(t)
+
+	primary.Inject("films", swapitest.Fault{Status: http.StatusServiceUnavailable})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := f.GetFilms(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("error=%v | expected=%v", err, context.Canceled)
+	}
+	if n := secondary.Requests("films"); n != 0 {
+		t.Errorf("secondary requests=%d | expected=%d once cancelled", n, 0)
+	}
+}
+
+func Test_Failover_GetPlanets(t *testing.T) {
+	f, primary, _ := newFailover(t)
+
+	primary.Inject("planets", swapitest.Fault{Status: http.StatusServiceUnavailable})
+
+	ctx, served := f.Track(context.Background())
+
+	planets, err := f.GetPlanets(ctx)
+	if err != nil || len(planets) == 0 {
+		t.Fatalf("planets=%d error=%v | expected every planet", len(planets), err)
+	}
+	if names := served(); !reflect.DeepEqual(names, []string{"secondary"}) {
+		t.Errorf("served=%v | expected=%v", names, []string{"secondary"})
+	}
+}
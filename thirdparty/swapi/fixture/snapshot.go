@@ -0,0 +1,134 @@
+// Package fixture serves swapi's data from a snapshot instead of swapi.dev, so
+// the api, its tests and the integration tests run offline on the same data.
+package fixture
+
+import (
+	"embed"
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+//go:embed snapshot/*.json
+var bundled embed.FS
+
+// A Snapshot holds swapi resources as swapi serves them, by kind ("films",
+// "people", "planets", ...) and id
+type Snapshot struct {
+	resources map[string]map[int]json.RawMessage
+}
+
+// Bundled returns the snapshot embedded in the binary: every film, the
+// characters they list and those characters' homeworlds
+func Bundled() *Snapshot {
+	sub, err := fs.Sub(bundled, "snapshot")
+	if err != nil {
+		panic(err)
+	}
+
+	s, err := Load(sub)
+	if err != nil {
+		// the bundled snapshot is checked by the tests
+		panic(err)
+	}
+	return s
+}
+
+// Load reads a snapshot from a directory holding a <kind>.json file per kind
+// of resource, each a json array of resources with their swapi url
+func Load(dir fs.FS) (*Snapshot, error) {
+	files, err := fs.Glob(dir, "*.json")
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		return nil, fmt.Errorf("no snapshot files found")
+	}
+
+	s := &Snapshot{resources: make(map[string]map[int]json.RawMessage, len(files))}
+	for _, file := range files {
+		data, err := fs.ReadFile(dir, file)
+		if err != nil {
+			return nil, err
+		}
+
+		var resources []json.RawMessage
+		if err := json.Unmarshal(data, &resources); err != nil {
+			return nil, fmt.Errorf("error decoding %s: %w", file, err)
+		}
+
+		kind := strings.TrimSuffix(file, path.Ext(file))
+		byID := make(map[int]json.RawMessage, len(resources))
+		for i, resource := range resources {
+			var ref struct {
+				URL string `json:"url"`
+			}
+			if err := json.Unmarshal(resource, &ref); err != nil {
+				return nil, fmt.Errorf("error decoding %s[%d]: %w", file, i, err)
+			}
+
+			id, err := resourceID(ref.URL)
+			if err != nil {
+				return nil, fmt.Errorf("%s[%d]: %w", file, i, err)
+			}
+			if _, ok := byID[id]; ok {
+				return nil, fmt.Errorf("%s: duplicate id %d", file, id)
+			}
+			byID[id] = resource
+		}
+
+		s.resources[kind] = byID
+	}
+
+	return s, nil
+}
+
+// resourceID reads the id ending a resource url, e.g.
+// https://swapi.dev/api/films/6/
+func resourceID(url string) (int, error) {
+	segments := strings.Split(strings.TrimSuffix(url, "/"), "/")
+	id, err := strconv.Atoi(segments[len(segments)-1])
+	if err != nil || id <= 0 {
+		return 0, fmt.Errorf("invalid resource url %q", url)
+	}
+	return id, nil
+}
+
+// Kinds returns the kinds of resources held, sorted
+func (s *Snapshot) Kinds() []string {
+	kinds := make([]string, 0, len(s.resources))
+	for kind := range s.resources {
+		kinds = append(kinds, kind)
+	}
+	sort.Strings(kinds)
+	return kinds
+}
+
+// IDs returns the ids of the resources of a kind, sorted
+func (s *Snapshot) IDs(kind string) []int {
+	ids := make([]int, 0, len(s.resources[kind]))
+	for id := range s.resources[kind] {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// Get returns a resource as swapi serves it
+func (s *Snapshot) Get(kind string, id int) (json.RawMessage, bool) {
+	resource, ok := s.resources[kind][id]
+	return resource, ok
+}
+
+// decode decodes the resource into v
+func (s *Snapshot) decode(kind string, id int, v interface{}) (bool, error) {
+	resource, ok := s.Get(kind, id)
+	if !ok {
+		return false, nil
+	}
+	return true, json.Unmarshal(resource, v)
+}
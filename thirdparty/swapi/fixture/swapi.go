@@ -0,0 +1,98 @@
+package fixture
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/iamnator/movie-api/thirdparty/swapi"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+)
+
+// Swapi answers like the swapi client, from a snapshot
+type Swapi struct {
+	snapshot *Snapshot
+}
+
+var _ swapi.ISwapi = (*Swapi)(nil)
+
+func NewSwapi(snapshot *Snapshot) *Swapi {
+	return &Swapi{
+		snapshot: snapshot,
+	}
+}
+
+// GetFilms returns the films with the given ids, or every film
+func (s *Swapi) GetFilms(ctx context.Context, ids ...int) ([]lib.Film, error) {
+	films, missing, err := collect[lib.Film](ctx, s.snapshot, "films", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	// like the swapi client, films are all or nothing
+	if len(missing) > 0 {
+		return nil, notFound("films", missing[0])
+	}
+	return films, nil
+}
+
+// GetCharacters returns the people with the given ids, or every person. Like
+// the swapi client, the people found are returned with an error listing the
+// others.
+func (s *Swapi) GetCharacters(ctx context.Context, ids ...int) ([]lib.Person, error) {
+	people, missing, err := collect[lib.Person](ctx, s.snapshot, "people", ids)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(missing) > 0 {
+		failed := make(map[int]error, len(missing))
+		for _, id := range missing {
+			failed[id] = notFound("people", id)
+		}
+		return people, &swapi.CharactersError{Errors: failed}
+	}
+	return people, nil
+}
+
+// GetPlanets returns every planet of the snapshot
+func (s *Swapi) GetPlanets(ctx context.Context) ([]lib.Planet, error) {
+	planets, _, err := collect[lib.Planet](ctx, s.snapshot, "planets", nil)
+	return planets, err
+}
+
+// collect decodes the resources of a kind with the given ids, or all of them,
+// and returns the ids it does not hold
+func collect[T any](ctx context.Context, snapshot *Snapshot, kind string, ids []int) (found []T, missing []int, err error) {
+	if err := ctx.Err(); err != nil {
+		return nil, nil, err
+	}
+
+	if len(ids) == 0 {
+		ids = snapshot.IDs(kind)
+	}
+
+	for _, id := range ids {
+		var resource T
+		ok, err := snapshot.decode(kind, id, &resource)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error decoding %s %d: %w", kind, id, err)
+		}
+		if !ok {
+			missing = append(missing, id)
+			continue
+		}
+		found = append(found, resource)
+	}
+
+	return found, missing, nil
+}
+
+// notFound returns the error swapi answers with for a resource it does not hold
+func notFound(kind string, id int) error {
+	return &lib.ResponseError{
+		Method:     http.MethodGet,
+		URL:        fmt.Sprintf("/api/%s/%d/", kind, id),
+		StatusCode: http.StatusNotFound,
+	}
+}
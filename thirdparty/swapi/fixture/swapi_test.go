@@ -0,0 +1,123 @@
+package fixture
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"testing/fstest"
+
+	"github.com/iamnator/movie-api/thirdparty/swapi"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+)
+
+func Test_Bundled_IsConsistent(t *testing.T) {
+	s := Bundled()
+
+	if kinds := s.Kinds(); len(kinds) != 3 {
+		t.Errorf("kinds=%v | expected=films, people and planets", kinds)
+	}
+
+	// every resource a film or person links to is in the snapshot
+	check := func(kind string, urls ...string) {
+		t.Helper()
+		for _, url := range urls {
+			id, err := resourceID(url)
+			if err != nil {
+				t.Error(err)
+				continue
+			}
+			if _, ok := s.Get(kind, id); !ok {
+				t.Errorf("%s %d is linked to but missing", kind, id)
+			}
+		}
+	}
+
+	films, err := NewSwapi(s).GetFilms(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, film := range films {
+		check("people", film.CharacterURLs...)
+		check("planets", film.PlanetURLs...)
+		if film.GetEdited().IsZero() || film.GetReleaseDate().IsZero() {
+			t.Errorf("film %d has invalid dates", film.GetID())
+		}
+	}
+
+	people, err := NewSwapi(s).GetCharacters(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	for _, person := range people {
+		check("films", person.FilmURLs...)
+		check("planets", person.Homeworld)
+	}
+}
+
+func Test_Swapi_GetFilms(t *testing.T) {
+	s := NewSwapi(Bundled())
+
+	films, err := s.GetFilms(context.Background())
+	if err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	if len(films) != 6 {
+		t.Fatalf("films=%d | expected=%d", len(films), 6)
+	}
+	for i, film := range films {
+		if film.GetID() != i+1 {
+			t.Errorf("film %d id=%d | expected=%d", i, film.GetID(), i+1)
+		}
+	}
+
+	films, err = s.GetFilms(context.Background(), 2)
+	if err != nil || len(films) != 1 || films[0].Title != "The Empire Strikes Back" {
+		t.Errorf("films=%+v error=%v | expected=The Empire Strikes Back", films, err)
+	}
+
+	var respErr *lib.ResponseError
+	if _, err := s.GetFilms(context.Background(), 1, 7); !errors.As(err, &respErr) || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("error=%v | expected=404 for film 7", err)
+	}
+}
+
+func Test_Swapi_GetCharacters_Missing(t *testing.T) {
+	people, err := NewSwapi(Bundled()).GetCharacters(context.Background(), 1, 999)
+
+	// like the swapi client, the error lists the people not found
+	var charErr *swapi.CharactersError
+	if !errors.As(err, &charErr) || len(charErr.IDs()) != 1 || charErr.IDs()[0] != 999 {
+		t.Errorf("error=%v | expected=person 999 not found", err)
+	}
+	if len(people) != 1 || people[0].Name != "Luke Skywalker" {
+		t.Errorf("people=%+v | expected=Luke Skywalker", people)
+	}
+}
+
+func Test_Swapi_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	if _, err := NewSwapi(Bundled()).GetFilms(ctx); !errors.Is(err, context.Canceled) {
+		t.Errorf("error=%v | expected=%v", err, context.Canceled)
+	}
+}
+
+func Test_Load_Invalid(t *testing.T) {
+	tests := map[string]fstest.MapFS{
+		"empty":       {},
+		"not a list":  {"films.json": {Data: []byte(`{"count": 1}`)}},
+		"no url":      {"films.json": {Data: []byte(`[{"title": "A New Hope"}]`)}},
+		"duplicate":   {"films.json": {Data: []byte(`[{"url": "https://swapi.dev/api/films/1/"}, {"url": "https://swapi.dev/api/films/1/"}]`)}},
+		"invalid url": {"films.json": {Data: []byte(`[{"url": "https://swapi.dev/api/films/"}]`)}},
+	}
+
+	for name, dir := range tests {
+		t.Run(name, func(t *testing.T) {
+			if _, err := Load(dir); err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
@@ -0,0 +1,179 @@
+package lib
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+type (
+	// A ResponseStore keeps the responses cached by the client, by url
+	ResponseStore interface {
+		// Get returns the value stored at key, false when there is none
+		Get(ctx context.Context, key string) ([]byte, bool, error)
+		Set(ctx context.Context, key string, value []byte) error
+	}
+
+	// cachedResponse is a response body kept with what revalidates it
+	cachedResponse struct {
+		Body         json.RawMessage `json:"body"`
+		ETag         string          `json:"etag,omitempty"`
+		LastModified string          `json:"last_modified,omitempty"`
+		Expires      time.Time       `json:"expires"`    // fresh until then, per Cache-Control max-age
+		Revalidate   bool            `json:"revalidate"` // Cache-Control no-cache
+	}
+
+	// CacheStats counts the requests of a client by how its response cache
+	// answered them
+	CacheStats struct {
+		Hits        int64 `json:"hits"`        // fresh, answered without a request
+		Revalidated int64 `json:"revalidated"` // stale, swapi replied 304 not modified
+		Misses      int64 `json:"misses"`      // downloaded
+	}
+
+	// cacheCounters are the atomic counters behind CacheStats
+	cacheCounters struct {
+		hits, revalidated, misses int64
+	}
+)
+
+// cacheDirective returns the value of the Cache-Control directive, ok false
+// when it is not set
+func cacheDirective(header http.Header, name string) (value string, ok bool) {
+	for _, directive := range strings.Split(header.Get("Cache-Control"), ",") {
+		key, value, _ := strings.Cut(strings.TrimSpace(directive), "=")
+		if strings.EqualFold(key, name) {
+			return strings.Trim(value, `"`), true
+		}
+	}
+	return "", false
+}
+
+// newCachedResponse returns the entry caching body, false when Cache-Control
+// forbids storing it or nothing would ever revalidate it
+func newCachedResponse(header http.Header, body []byte, now time.Time) (cachedResponse, bool) {
+	if _, ok := cacheDirective(header, "no-store"); ok {
+		return cachedResponse{}, false
+	}
+
+	entry := cachedResponse{
+		Body:         body,
+		ETag:         header.Get("ETag"),
+		LastModified: header.Get("Last-Modified"),
+		Expires:      now,
+	}
+	entry.refresh(header, now)
+
+	return entry, entry.ETag != "" || entry.LastModified != "" || entry.Expires.After(now)
+}
+
+// refresh sets how long the entry is fresh from the headers of the response
+// storing or revalidating it
+func (e *cachedResponse) refresh(header http.Header, now time.Time) {
+	_, e.Revalidate = cacheDirective(header, "no-cache")
+
+	e.Expires = now
+	if maxAge, ok := cacheDirective(header, "max-age"); ok {
+		if seconds, err := strconv.Atoi(maxAge); err == nil && seconds > 0 {
+			e.Expires = now.Add(time.Duration(seconds) * time.Second)
+		}
+	}
+}
+
+func (e cachedResponse) fresh(now time.Time) bool {
+	return !e.Revalidate && now.Before(e.Expires)
+}
+
+// cached returns the entry cached for the request url, if any. A store
+// failing is treated as a miss, swapi still answers.
+func (c *Client) cached(req *http.Request) (cachedResponse, bool) {
+	data, ok, err := c.cache.Get(req.Context(), req.URL.String())
+	if err != nil || !ok {
+		return cachedResponse{}, false
+	}
+
+	var entry cachedResponse
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return cachedResponse{}, false
+	}
+	return entry, true
+}
+
+func (c *Client) store(req *http.Request, entry cachedResponse) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return
+	}
+	_ = c.cache.Set(req.Context(), req.URL.String(), data)
+}
+
+// CacheStats returns how the response cache answered the client's requests
+// so far, all misses when the client caches nothing
+func (c *Client) CacheStats() CacheStats {
+	return CacheStats{
+		Hits:        atomic.LoadInt64(&c.cacheCounters.hits),
+		Revalidated: atomic.LoadInt64(&c.cacheCounters.revalidated),
+		Misses:      atomic.LoadInt64(&c.cacheCounters.misses),
+	}
+}
+
+// DiskStore is a ResponseStore keeping each response in a file of a directory
+type DiskStore struct {
+	dir string
+}
+
+var _ ResponseStore = DiskStore{}
+
+// NewDiskStore returns a store of the directory, created if needed
+func NewDiskStore(dir string) (DiskStore, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return DiskStore{}, err
+	}
+	return DiskStore{dir: dir}, nil
+}
+
+func (s DiskStore) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(s.dir, hex.EncodeToString(sum[:])+".json")
+}
+
+func (s DiskStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	data, err := os.ReadFile(s.path(key))
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, false, nil
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return data, true, nil
+}
+
+// Set writes the value to a temporary file renamed over the key's, so
+// concurrent readers never see a partial response
+func (s DiskStore) Set(_ context.Context, key string, value []byte) error {
+	f, err := os.CreateTemp(s.dir, "*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(f.Name())
+
+	if _, err := f.Write(value); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+
+	return os.Rename(f.Name(), s.path(key))
+}
@@ -0,0 +1,115 @@
+package lib_test
+
+import (
+	"context"
+	"testing"
+
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitest"
+)
+
+func newCachingClient(t *testing.T, srv *swapitest.Server, dir string) *lib.Client {
+	t.Helper()
+
+	store, err := lib.NewDiskStore(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return lib.NewClient(lib.BaseURL(srv.URL), lib.ResponseCache(store))
+}
+
+func Test_ResponseCache(t *testing.T) {
+	tests := []struct {
+		name         string
+		cacheControl string
+		expected     lib.CacheStats // of the second run
+		requests     int            // to swapi over both runs
+	}{
+		{name: "fresh", cacheControl: "public, max-age=600", expected: lib.CacheStats{Hits: 4}, requests: 4},
+		{name: "stale", cacheControl: "", expected: lib.CacheStats{Revalidated: 4}, requests: 8},
+		{name: "no-cache", cacheControl: "max-age=600, no-cache", expected: lib.CacheStats{Revalidated: 4}, requests: 8},
+		{name: "no-store", cacheControl: "no-store", expected: lib.CacheStats{Misses: 4}, requests: 8},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := swapitest.NewServer(swapitest.WithPageSize(2), swapitest.WithCacheControl(tt.cacheControl))
+			defer srv.Close()
+
+			dir := t.TempDir()
+			ctx := context.Background()
+
+			// 3 pages of films and luke
+			first := newCachingClient(t, srv, dir)
+			if _, err := first.AllFilms(ctx); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := first.Person(ctx, 1); err != nil {
+				t.Fatal(err)
+			}
+			if stats := first.CacheStats(); stats != (lib.CacheStats{Misses: 4}) {
+				t.Errorf("first=%+v | expected=%+v", stats, lib.CacheStats{Misses: 4})
+			}
+
+			// a later run reads the responses the first stored on disk
+			second := newCachingClient(t, srv, dir)
+			films, err := second.AllFilms(ctx)
+			if err != nil {
+				t.Fatal(err)
+			}
+			person, err := second.Person(ctx, 1)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if len(films) != 6 || person.Name != "Luke Skywalker" {
+				t.Errorf("films=%d, person=%v | expected=6, Luke Skywalker", len(films), person.Name)
+			}
+			if stats := second.CacheStats(); stats != tt.expected {
+				t.Errorf("second=%+v | expected=%+v", stats, tt.expected)
+			}
+			if n := srv.Requests("films") + srv.Requests("people/1"); n != tt.requests {
+				t.Errorf("requests=%d | expected=%d", n, tt.requests)
+			}
+		})
+	}
+}
+
+func Test_ResponseCache_Disabled(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	c := lib.NewClient(lib.BaseURL(srv.URL))
+	for i := 0; i < 2; i++ {
+		if _, err := c.Person(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if stats := c.CacheStats(); stats != (lib.CacheStats{Misses: 2}) {
+		t.Errorf("stats=%+v | expected=%+v", stats, lib.CacheStats{Misses: 2})
+	}
+	if n := srv.NotModified(); n != 0 {
+		t.Errorf("not modified=%d | expected=%d", n, 0)
+	}
+}
+
+func Test_DiskStore(t *testing.T) {
+	store, err := lib.NewDiskStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+
+	if _, ok, err := store.Get(ctx, "https://swapi.dev/api/films/1/"); ok || err != nil {
+		t.Errorf("ok=%v, error=%v | expected=false, nil", ok, err)
+	}
+
+	if err := store.Set(ctx, "https://swapi.dev/api/films/1/", []byte(`{"title": "A New Hope"}`)); err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+	value, ok, err := store.Get(ctx, "https://swapi.dev/api/films/1/")
+	if !ok || err != nil || string(value) != `{"title": "A New Hope"}` {
+		t.Errorf("value=%s, ok=%v, error=%v | expected=the film", value, ok, err)
+	}
+}
@@ -4,8 +4,19 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"io"
 	"net/http"
 	"net/url"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.17.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"github.com/iamnator/movie-api/pkg/backoff"
 )
 
 const (
@@ -15,6 +26,8 @@ const (
 	defaultUserAgent     = "swapi.go"
 )
 
+var tracer = otel.Tracer("github.com/iamnator/movie-api/thirdparty/swapi/lib")
+
 // DefaultClient is the default SWAPI client
 var DefaultClient = NewClient()
 
@@ -31,6 +44,13 @@ type Client struct {
 
 	// HTTP client used to communicate with the SWAPI
 	httpClient *http.Client
+
+	// retry policy for transient failures, nil disables retries
+	retry *backoff.Policy
+
+	// cache of the responses, nil disables caching
+	cache         ResponseStore
+	cacheCounters cacheCounters
 }
 
 // NewClient returns a new SWAPI client.
@@ -83,10 +103,70 @@ func (c *Client) newRequest(ctx context.Context, s string) (*http.Request, error
 
 // do sends an API request and returns the API response. The API response is
 // decoded and stored in the value pointed to by v, or returned as an error if
-// an API error has occurred.
-func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
-	// Make sure to close the connection after replying to this request
-	req.Close = true
+// an API error has occurred. Transient failures are retried according to the
+// client's retry policy, unless the request's context was made WithoutRetries.
+func (c *Client) do(req *http.Request, v interface{}) (resp *http.Response, err error) {
+	// one span covers every attempt, swapi sees it as the parent of its own
+	ctx, span := tracer.Start(req.Context(), "swapi "+req.Method+" "+req.URL.Path,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPMethodKey.String(req.Method),
+			semconv.HTTPURLKey.String(req.URL.String()),
+		),
+	)
+	defer func() {
+		if resp != nil {
+			span.SetAttributes(semconv.HTTPStatusCodeKey.Int(resp.StatusCode))
+		}
+		if err != nil {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
+	req = req.WithContext(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	if c.retry == nil || retriesDisabled(ctx) {
+		return c.send(req, v)
+	}
+
+	err = c.retry.Retry(ctx, func(ctx context.Context) error {
+		var err error
+		resp, err = c.send(req, v)
+		if err != nil && !retryable(err) {
+			return backoff.Permanent(err)
+		}
+		return err
+	})
+
+	return resp, err
+}
+
+// send performs a single round trip for do. With a response cache, a fresh
+// cached response is decoded without a round trip and a stale one is
+// revalidated with a conditional request.
+func (c *Client) send(req *http.Request, v interface{}) (*http.Response, error) {
+	var entry cachedResponse
+	var cached bool
+	if c.cache != nil {
+		if entry, cached = c.cached(req); cached {
+			if entry.fresh(time.Now()) {
+				atomic.AddInt64(&c.cacheCounters.hits, 1)
+				return &http.Response{StatusCode: http.StatusOK, Header: make(http.Header), Request: req}, c.decode(req, entry.Body, v)
+			}
+
+			// the request is shared by the attempts of do
+			req = req.Clone(req.Context())
+			if entry.ETag != "" {
+				req.Header.Set("If-None-Match", entry.ETag)
+			}
+			if entry.LastModified != "" {
+				req.Header.Set("If-Modified-Since", entry.LastModified)
+			}
+		}
+	}
 
 	resp, err := c.httpClient.Do(req)
 	if err != nil {
@@ -94,13 +174,53 @@ func (c *Client) do(req *http.Request, v interface{}) (*http.Response, error) {
 	}
 	defer resp.Body.Close()
 
-	if v != nil {
-		err = json.NewDecoder(resp.Body).Decode(v)
+	if cached && resp.StatusCode == http.StatusNotModified {
+		// drained so the connection is kept alive
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		atomic.AddInt64(&c.cacheCounters.revalidated, 1)
+		entry.refresh(resp.Header, time.Now())
+		c.store(req, entry)
+		return resp, c.decode(req, entry.Body, v)
 	}
 
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		_, _ = io.Copy(io.Discard, resp.Body)
+
+		return resp, &ResponseError{
+			Method:     req.Method,
+			URL:        req.URL.RequestURI(),
+			StatusCode: resp.StatusCode,
+			RetryAfter: ParseRetryAfter(resp.Header, time.Now()),
+		}
+	}
+
+	body, err := io.ReadAll(resp.Body)
 	if err != nil {
 		return nil, fmt.Errorf("error reading response from %s %s: %s", req.Method, req.URL.RequestURI(), err)
 	}
+	if err := c.decode(req, body, v); err != nil {
+		return nil, err
+	}
+
+	atomic.AddInt64(&c.cacheCounters.misses, 1)
+	if c.cache != nil {
+		if entry, ok := newCachedResponse(resp.Header, body, time.Now()); ok {
+			c.store(req, entry)
+		}
+	}
 
 	return resp, nil
 }
+
+// decode decodes the response body to req into v
+func (c *Client) decode(req *http.Request, body []byte, v interface{}) error {
+	if v == nil {
+		return nil
+	}
+
+	if err := json.Unmarshal(body, v); err != nil {
+		return fmt.Errorf("error reading response from %s %s: %s", req.Method, req.URL.RequestURI(), err)
+	}
+	return nil
+}
@@ -0,0 +1,48 @@
+package lib
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func Test_do_PropagatesTraceContext(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	var traceparent string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		traceparent = r.Header.Get("traceparent")
+		_, _ = w.Write([]byte(`{"title": "A New Hope", "episode_id": 4}`))
+	}))
+	defer srv.Close()
+
+	ctx, parent := provider.Tracer("test").Start(context.Background(), "parent")
+	if _, err := NewClient(BaseURL(srv.URL)).Film(ctx, 1); err != nil {
+		t.Fatalf("unexpected error: %s", err)
+	}
+	parent.End()
+
+	spans := recorder.Ended()
+	if len(spans) != 2 {
+		t.Fatalf("spans=%d | expected=%d", len(spans), 2)
+	}
+
+	swapiSpan := spans[0]
+	if swapiSpan.Parent().SpanID() != parent.SpanContext().SpanID() {
+		t.Errorf("parent=%s | expected=%s", swapiSpan.Parent().SpanID(), parent.SpanContext().SpanID())
+	}
+
+	expected := "00-" + swapiSpan.SpanContext().TraceID().String() + "-" + swapiSpan.SpanContext().SpanID().String() + "-01"
+	if traceparent != expected {
+		t.Errorf("traceparent=%q | expected=%q", traceparent, expected)
+	}
+}
@@ -0,0 +1,73 @@
+package lib
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+)
+
+// A ResponseError is returned when SWAPI replies with a non-2xx status code.
+type ResponseError struct {
+	Method     string
+	URL        string
+	StatusCode int
+
+	// RetryAfter is how long swapi asked to wait before retrying, with a 429
+	// or 503; 0 when it did not say
+	RetryAfter time.Duration
+}
+
+func (e *ResponseError) Error() string {
+	return fmt.Sprintf("%s %s: unexpected status %d", e.Method, e.URL, e.StatusCode)
+}
+
+// Temporary reports whether the request may succeed if retried.
+func (e *ResponseError) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= http.StatusInternalServerError
+}
+
+// RetryDelay tells the retry policy to wait at least RetryAfter
+func (e *ResponseError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// ParseRetryAfter returns the delay of a Retry-After header, given in seconds
+// or as an http date, 0 when it is missing or invalid
+func ParseRetryAfter(header http.Header, now time.Time) time.Duration {
+	value := header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+
+	if seconds, err := strconv.Atoi(value); err == nil {
+		if seconds < 0 {
+			return 0
+		}
+		return time.Duration(seconds) * time.Second
+	}
+
+	if at, err := http.ParseTime(value); err == nil && at.After(now) {
+		return at.Sub(now)
+	}
+	return 0
+}
+
+// retryable reports whether err is worth retrying: throttling, server errors
+// and transport failures are, cancellations and malformed payloads are not.
+func retryable(err error) bool {
+	if errors.Is(err, context.Canceled) || errors.Is(err, context.DeadlineExceeded) {
+		return false
+	}
+
+	var respErr *ResponseError
+	if errors.As(err, &respErr) {
+		return respErr.Temporary()
+	}
+
+	var urlErr *url.Error
+	return errors.As(err, &urlErr)
+}
@@ -2,7 +2,6 @@ package lib
 
 import (
 	"context"
-	"fmt"
 	"time"
 )
 
@@ -29,63 +28,54 @@ func (f Film) GetID() int {
 	return id
 }
 
+// GetReleaseDate returns the release date, zero when it is malformed
 func (f Film) GetReleaseDate() time.Time {
-	t, _ := time.Parse("2006-01-02", f.ReleaseDate)
+	t, _ := f.ParseReleaseDate()
 	return t
 }
 
+// GetCreated returns the created time, zero when it is malformed
 func (f Film) GetCreated() time.Time {
-	t, _ := time.Parse(time.RFC3339, f.Created)
+	t, _ := f.ParseCreated()
 	return t
 }
 
+// GetEdited returns the edited time, zero when it is malformed
 func (f Film) GetEdited() time.Time {
-	t, _ := time.Parse(time.RFC3339, f.Edited)
+	t, _ := f.ParseEdited()
 	return t
 }
 
-// Film retrieves the film with the given id
-func (c *Client) Film(ctx context.Context, id int) (Film, error) {
-	req, err := c.newRequest(ctx, fmt.Sprintf("films/%d", id))
-	if err != nil {
-		return Film{}, err
-	}
-
-	var film Film
-
-	if _, err = c.do(req, &film); err != nil {
-		return Film{}, err
-	}
-
-	return film, nil
+// ParseReleaseDate returns the release date, an error wrapping ErrMalformed
+// when it does not parse
+func (f Film) ParseReleaseDate() (time.Time, error) {
+	return parseTime("2006-01-02", f.ReleaseDate)
 }
 
-func (c *Client) AllFilms(ctx context.Context) ([]Film, error) {
-	var films []Film
-
-	req, err := c.newRequest(ctx, "films")
-	if err != nil {
-		return nil, err
-	}
-
-	for {
-		var list List[Film]
-
-		if _, err = c.do(req, &list); err != nil {
-			return nil, err
-		}
+func (f Film) ParseCreated() (time.Time, error) {
+	return parseTime(time.RFC3339, f.Created)
+}
 
-		films = append(films, list.Results...)
+func (f Film) ParseEdited() (time.Time, error) {
+	return parseTime(time.RFC3339, f.Edited)
+}
 
-		if list.Next == nil {
-			break
-		}
+// Validate flags the fields of the film that are malformed
+func (f Film) Validate() []Warning {
+	v := validator{url: f.URL}
+	v.id()
+	v.time("release_date", f.ReleaseDate, f.ParseReleaseDate)
+	v.time("created", f.Created, f.ParseCreated)
+	v.time("edited", f.Edited, f.ParseEdited)
+	return v.warnings
+}
 
-		req, err = c.getRequest(ctx, *list.Next)
-		if err != nil {
-			return nil, err
-		}
-	}
+// Film retrieves the film with the given id
+func (c *Client) Film(ctx context.Context, id int) (Film, error) {
+	return Get(ctx, c, FilmResource, id)
+}
 
-	return films, nil
+// AllFilms retrieves every film
+func (c *Client) AllFilms(ctx context.Context) ([]Film, error) {
+	return List(ctx, c, FilmResource)
 }
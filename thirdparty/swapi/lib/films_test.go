@@ -0,0 +1,46 @@
+package lib_test
+
+import (
+	"context"
+	"testing"
+
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitest"
+)
+
+func Test_AllFilms_FollowsPages(t *testing.T) {
+	srv := swapitest.NewServer(swapitest.WithPageSize(4))
+	defer srv.Close()
+
+	films, err := lib.NewClient(lib.BaseURL(srv.URL)).AllFilms(context.Background())
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+
+	if len(films) != 6 {
+		t.Fatalf("films=%d | expected=%d", len(films), 6)
+	}
+	for i, film := range films {
+		if film.GetID() != i+1 {
+			t.Errorf("films[%d].id=%d | expected=%d", i, film.GetID(), i+1)
+		}
+	}
+	if films[0].Title != "A New Hope" {
+		t.Errorf("title=%v | expected=%v", films[0].Title, "A New Hope")
+	}
+
+	if n := srv.Requests("films"); n != 2 {
+		t.Errorf("requests=%d | expected=%d", n, 2)
+	}
+}
+
+func Test_AllFilms_Malformed(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	srv.Inject("films", swapitest.Fault{Malformed: true})
+
+	if _, err := lib.NewClient(lib.BaseURL(srv.URL)).AllFilms(context.Background()); err == nil {
+		t.Errorf("error=nil | expected=malformed json")
+	}
+}
@@ -1,11 +1,156 @@
 package lib
 
-type Entity interface {
-	Film | Person
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+)
+
+// A Resource describes a kind of swapi resource, decoded as T and served
+// under Path, e.g. films/ and films/1/
+type Resource[T any] struct {
+	Path string
 }
 
-type List[E Entity] struct {
+// the resources served by swapi
+var (
+	FilmResource     = Resource[Film]{Path: "films"}
+	PersonResource   = Resource[Person]{Path: "people"}
+	PlanetResource   = Resource[Planet]{Path: "planets"}
+	StarshipResource = Resource[Starship]{Path: "starships"}
+	VehicleResource  = Resource[Vehicle]{Path: "vehicles"}
+	SpeciesResource  = Resource[Species]{Path: "species"}
+)
+
+// page is a page of a list of resources
+type page[T any] struct {
 	Count   int
 	Next    *string
-	Results []E
+	Results []T
+}
+
+// Get retrieves the resource with the given id
+func Get[T any](ctx context.Context, c *Client, r Resource[T], id int) (T, error) {
+	var resource, zero T
+
+	req, err := c.newRequest(ctx, fmt.Sprintf("%s/%d/", r.Path, id))
+	if err != nil {
+		return zero, err
+	}
+
+	if _, err = c.do(req, &resource); err != nil {
+		return zero, err
+	}
+
+	return resource, nil
+}
+
+// Fetch decodes the response to ref in v, ref being a path relative to the
+// base path or an absolute url, e.g. the next page of a list. It reaches apis
+// shaped unlike swapi.dev, whose resources Get and List decode.
+func (c *Client) Fetch(ctx context.Context, ref string, v interface{}) error {
+	var req *http.Request
+	var err error
+	if u, perr := url.Parse(ref); perr == nil && u.IsAbs() {
+		req, err = c.getRequest(ctx, ref)
+	} else {
+		req, err = c.newRequest(ctx, ref)
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = c.do(req, v)
+	return err
+}
+
+// List retrieves every resource, following the pages
+func List[T any](ctx context.Context, c *Client, r Resource[T]) ([]T, error) {
+	var resources []T
+
+	it := Iterate(ctx, c, r)
+	for it.Next() {
+		resources = append(resources, it.Page()...)
+	}
+	if err := it.Err(); err != nil {
+		return nil, err
+	}
+
+	return resources, nil
+}
+
+// Iterator reads a list of resources a page at a time, only requesting a page
+// when Next is called:
+//
+//	it := lib.Iterate(ctx, c, lib.PlanetResource)
+//	for it.Next() {
+//		planets := it.Page()
+//	}
+//	if err := it.Err(); err != nil {
+type Iterator[T any] struct {
+	ctx    context.Context
+	client *Client
+	path   string
+
+	next  *string // url of the next page, nil once the last was read
+	page  page[T]
+	err   error
+	begun bool
+}
+
+// Iterate returns an iterator over every resource
+func Iterate[T any](ctx context.Context, c *Client, r Resource[T]) *Iterator[T] {
+	return &Iterator[T]{
+		ctx:    ctx,
+		client: c,
+		path:   r.Path + "/",
+	}
+}
+
+// Next requests the next page, it returns false once every page was read,
+// on error or when the context is done
+func (it *Iterator[T]) Next() bool {
+	if it.err != nil || (it.begun && it.next == nil) {
+		return false
+	}
+	if it.err = it.ctx.Err(); it.err != nil {
+		return false
+	}
+
+	req, err := it.client.newRequest(it.ctx, it.path)
+	if it.begun {
+		req, err = it.client.getRequest(it.ctx, *it.next)
+	}
+	if err != nil {
+		it.err = err
+		return false
+	}
+	it.begun = true
+
+	var p page[T]
+	if _, err := it.client.do(req, &p); err != nil {
+		it.err = err
+		return false
+	}
+
+	it.page = p
+	it.next = p.Next
+	return true
+}
+
+// Page returns the resources of the page read by the last call to Next
+func (it *Iterator[T]) Page() []T {
+	return it.page.Results
+}
+
+// Count returns how many resources the list holds over every page, once
+// Next read one
+func (it *Iterator[T]) Count() int {
+	return it.page.Count
+}
+
+// Err returns the error that stopped the iteration, if any
+func (it *Iterator[T]) Err() error {
+	return it.err
 }
@@ -0,0 +1,144 @@
+package lib_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"testing/fstest"
+
+	"github.com/iamnator/movie-api/thirdparty/swapi/fixture"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitest"
+)
+
+// craftSnapshot holds a starship, a vehicle and a species, which the bundled
+// snapshot does not
+func craftSnapshot(t *testing.T) *fixture.Snapshot {
+	t.Helper()
+
+	snapshot, err := fixture.Load(fstest.MapFS{
+		"starships.json": {Data: []byte(`[{"name": "X-wing", "model": "T-65 X-wing", "MGLT": "100", "starship_class": "Starfighter", "url": "https://swapi.dev/api/starships/12/"}]`)},
+		"vehicles.json":  {Data: []byte(`[{"name": "Snowspeeder", "vehicle_class": "airspeeder", "url": "https://swapi.dev/api/vehicles/14/"}]`)},
+		"species.json":   {Data: []byte(`[{"name": "Droid", "classification": "artificial", "homeworld": null, "url": "https://swapi.dev/api/species/2/"}]`)},
+	})
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+	return snapshot
+}
+
+func Test_List_Planets(t *testing.T) {
+	srv := swapitest.NewServer(swapitest.WithPageSize(3))
+	defer srv.Close()
+
+	planets, err := lib.List(context.Background(), lib.NewClient(lib.BaseURL(srv.URL)), lib.PlanetResource)
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+
+	ids := fixture.Bundled().IDs("planets")
+	if len(planets) != len(ids) {
+		t.Fatalf("planets=%d | expected=%d", len(planets), len(ids))
+	}
+	for i, planet := range planets {
+		if planet.GetID() != ids[i] {
+			t.Errorf("planets[%d].id=%d | expected=%d", i, planet.GetID(), ids[i])
+		}
+	}
+
+	if n, expected := srv.Requests("planets"), (len(ids)+2)/3; n != expected {
+		t.Errorf("requests=%d | expected=%d", n, expected)
+	}
+}
+
+func Test_Get(t *testing.T) {
+	srv := swapitest.NewServer(swapitest.WithSnapshot(craftSnapshot(t)))
+	defer srv.Close()
+
+	c := lib.NewClient(lib.BaseURL(srv.URL))
+	ctx := context.Background()
+
+	starship, err := lib.Get(ctx, c, lib.StarshipResource, 12)
+	if err != nil || starship.Name != "X-wing" || starship.MGLT != "100" || starship.GetID() != 12 {
+		t.Errorf("starship=%+v error=%v | expected=X-wing", starship, err)
+	}
+
+	vehicle, err := lib.Get(ctx, c, lib.VehicleResource, 14)
+	if err != nil || vehicle.Name != "Snowspeeder" || vehicle.VehicleClass != "airspeeder" {
+		t.Errorf("vehicle=%+v error=%v | expected=Snowspeeder", vehicle, err)
+	}
+
+	species, err := lib.Get(ctx, c, lib.SpeciesResource, 2)
+	if err != nil || species.Name != "Droid" || species.Homeworld != "" {
+		t.Errorf("species=%+v error=%v | expected=Droid", species, err)
+	}
+
+	_, err = lib.Get(ctx, c, lib.StarshipResource, 13)
+	var respErr *lib.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != 404 {
+		t.Errorf("error=%v | expected=404", err)
+	}
+}
+
+func Test_Iterate_Lazy(t *testing.T) {
+	srv := swapitest.NewServer(swapitest.WithPageSize(5))
+	defer srv.Close()
+
+	it := lib.Iterate(context.Background(), lib.NewClient(lib.BaseURL(srv.URL)), lib.PersonResource)
+
+	if n := srv.Requests("people"); n != 0 {
+		t.Errorf("requests=%d | expected=%d before Next", n, 0)
+	}
+
+	if !it.Next() {
+		t.Fatalf("Next=false error=%v | expected=true", it.Err())
+	}
+	if len(it.Page()) != 5 {
+		t.Errorf("page=%d | expected=%d", len(it.Page()), 5)
+	}
+	if it.Count() != 22 {
+		t.Errorf("count=%d | expected=%d", it.Count(), 22)
+	}
+	if n := srv.Requests("people"); n != 1 {
+		t.Errorf("requests=%d | expected=%d after the first page", n, 1)
+	}
+
+	pages := 1
+	for it.Next() {
+		pages++
+	}
+	if it.Err() != nil {
+		t.Errorf("error=%v | expected=nil", it.Err())
+	}
+	if pages != 5 {
+		t.Errorf("pages=%d | expected=%d", pages, 5)
+	}
+	if it.Next() {
+		t.Errorf("Next=true | expected=false once exhausted")
+	}
+}
+
+func Test_Iterate_StopsOnCancel(t *testing.T) {
+	srv := swapitest.NewServer(swapitest.WithPageSize(5))
+	defer srv.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	it := lib.Iterate(ctx, lib.NewClient(lib.BaseURL(srv.URL)), lib.PersonResource)
+	if !it.Next() {
+		t.Fatalf("Next=false error=%v | expected=true", it.Err())
+	}
+
+	cancel()
+
+	if it.Next() {
+		t.Errorf("Next=true | expected=false once cancelled")
+	}
+	if !errors.Is(it.Err(), context.Canceled) {
+		t.Errorf("error=%v | expected=%v", it.Err(), context.Canceled)
+	}
+	if n := srv.Requests("people"); n != 1 {
+		t.Errorf("requests=%d | expected=%d", n, 1)
+	}
+}
@@ -1,8 +1,11 @@
 package lib
 
 import (
+	"context"
 	"net/http"
 	"net/url"
+
+	"github.com/iamnator/movie-api/pkg/backoff"
 )
 
 type Option func(*Client)
@@ -14,6 +17,35 @@ func HTTPClient(hc *http.Client) Option {
 	}
 }
 
+// Retry transient failures (network errors, 429 and 5xx) using the given policy
+func Retry(p backoff.Policy) Option {
+	return func(c *Client) {
+		c.retry = &p
+	}
+}
+
+type noRetriesKey struct{}
+
+// WithoutRetries returns a context whose requests are sent once whatever the
+// client's retry policy, for callers retrying on their own
+func WithoutRetries(ctx context.Context) context.Context {
+	return context.WithValue(ctx, noRetriesKey{}, true)
+}
+
+func retriesDisabled(ctx context.Context) bool {
+	disabled, _ := ctx.Value(noRetriesKey{}).(bool)
+	return disabled
+}
+
+// ResponseCache keeps the responses in store, answering from it while they
+// are fresh per their Cache-Control and revalidating them with If-None-Match
+// and If-Modified-Since once stale
+func ResponseCache(store ResponseStore) Option {
+	return func(c *Client) {
+		c.cache = store
+	}
+}
+
 // BaseURL for the client parsed from provided rawurl
 func BaseURL(rawurl string) Option {
 	return func(c *Client) {
@@ -2,7 +2,7 @@ package lib
 
 import (
 	"context"
-	"fmt"
+	"time"
 )
 
 // A Person is an individual person or character within the Star Wars universe.
@@ -25,48 +25,50 @@ type Person struct {
 	URL          string   `json:"url"`
 }
 
-// Person retrieves the person with the given id
-func (c *Client) Person(ctx context.Context, id int) (Person, error) {
-	req, err := c.newRequest(ctx, fmt.Sprintf("people/%d", id))
-	if err != nil {
-		return Person{}, err
-	}
-
-	var person Person
-
-	if _, err = c.do(req, &person); err != nil {
-		return Person{}, err
-	}
-
-	return person, nil
+func (p Person) GetID() int {
+	id, _ := getIDFromURL(p.URL)
+	return id
 }
 
-func (c *Client) AllPeople(ctx context.Context) ([]Person, error) {
-	var people []Person
-
-	req, err := c.newRequest(ctx, "people/")
-	if err != nil {
-		return nil, err
-	}
+// GetEdited returns the edited time, zero when it is malformed
+func (p Person) GetEdited() time.Time {
+	t, _ := p.ParseEdited()
+	return t
+}
 
-	for {
-		var list List[Person]
+func (p Person) ParseEdited() (time.Time, error) {
+	return parseTime(time.RFC3339, p.Edited)
+}
 
-		if _, err = c.do(req, &list); err != nil {
-			return nil, err
-		}
+// GetHeight returns the height in centimetres, an error wrapping ErrMalformed
+// when it does not parse
+func (p Person) GetHeight() (Measure, error) {
+	return ParseMeasure(p.Height)
+}
 
-		people = append(people, list.Results...)
+// GetMass returns the mass in kilograms, an error wrapping ErrMalformed when
+// it does not parse
+func (p Person) GetMass() (Measure, error) {
+	return ParseMeasure(p.Mass)
+}
 
-		if list.Next == nil {
-			break
-		}
+// Validate flags the fields of the person that are malformed, and the
+// measures swapi does not know
+func (p Person) Validate() []Warning {
+	v := validator{url: p.URL}
+	v.id()
+	v.measure("height", p.Height)
+	v.measure("mass", p.Mass)
+	v.time("edited", p.Edited, p.ParseEdited)
+	return v.warnings
+}
 
-		req, err = c.getRequest(ctx, *list.Next)
-		if err != nil {
-			return nil, err
-		}
-	}
+// Person retrieves the person with the given id
+func (c *Client) Person(ctx context.Context, id int) (Person, error) {
+	return Get(ctx, c, PersonResource, id)
+}
 
-	return people, nil
+// AllPeople retrieves every person
+func (c *Client) AllPeople(ctx context.Context) ([]Person, error) {
+	return List(ctx, c, PersonResource)
 }
@@ -0,0 +1,132 @@
+package lib_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/iamnator/movie-api/pkg/backoff"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitest"
+)
+
+func Test_AllPeople_FollowsPages(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	people, err := lib.NewClient(lib.BaseURL(srv.URL)).AllPeople(context.Background())
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+
+	if len(people) != 22 {
+		t.Errorf("people=%d | expected=%d", len(people), 22)
+	}
+	if n := srv.Requests("people"); n != 3 {
+		t.Errorf("requests=%d | expected=%d", n, 3)
+	}
+}
+
+func Test_Person(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	person, err := lib.NewClient(lib.BaseURL(srv.URL)).Person(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+
+	if person.Name != "Luke Skywalker" {
+		t.Errorf("name=%v | expected=%v", person.Name, "Luke Skywalker")
+	}
+	if person.URL != srv.URL+"/api/people/1/" {
+		t.Errorf("url=%v | expected=%v", person.URL, srv.URL+"/api/people/1/")
+	}
+}
+
+func Test_Person_Errors(t *testing.T) {
+	tests := []struct {
+		name   string
+		id     int
+		fault  *swapitest.Fault
+		status int // of the expected *lib.ResponseError, 0 for another error
+	}{
+		{name: "not found", id: 1000, status: http.StatusNotFound},
+		{name: "throttled", id: 1, fault: &swapitest.Fault{Status: http.StatusTooManyRequests}, status: http.StatusTooManyRequests},
+		{name: "malformed json", id: 1, fault: &swapitest.Fault{Malformed: true}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := swapitest.NewServer()
+			defer srv.Close()
+
+			if tt.fault != nil {
+				srv.Inject("people/1", *tt.fault)
+			}
+
+			_, err := lib.NewClient(lib.BaseURL(srv.URL)).Person(context.Background(), tt.id)
+			if err == nil {
+				t.Fatalf("error=nil | expected=error")
+			}
+
+			var respErr *lib.ResponseError
+			if errors.As(err, &respErr) != (tt.status != 0) || (respErr != nil && respErr.StatusCode != tt.status) {
+				t.Errorf("error=%v | expected=status %d", err, tt.status)
+			}
+		})
+	}
+}
+
+func Test_Person_RetriesThrottling(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	srv.Inject("people/1", swapitest.Fault{Status: http.StatusTooManyRequests, Times: 2})
+
+	policy := backoff.Policy{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1, MaxAttempts: 3}
+	person, err := lib.NewClient(lib.BaseURL(srv.URL), lib.Retry(policy)).Person(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+
+	if person.Name != "Luke Skywalker" {
+		t.Errorf("name=%v | expected=%v", person.Name, "Luke Skywalker")
+	}
+	if n := srv.Requests("people/1"); n != 3 {
+		t.Errorf("requests=%d | expected=%d", n, 3)
+	}
+}
+
+func Test_Person_WithoutRetries(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	srv.Inject("people/1", swapitest.Fault{Status: http.StatusTooManyRequests, Times: 2})
+
+	// the caller retries on its own, the client must not multiply the attempts
+	policy := backoff.Policy{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1, MaxAttempts: 3}
+	_, err := lib.NewClient(lib.BaseURL(srv.URL), lib.Retry(policy)).Person(lib.WithoutRetries(context.Background()), 1)
+	if err == nil {
+		t.Fatal("expected the throttled response as an error")
+	}
+
+	if n := srv.Requests("people/1"); n != 1 {
+		t.Errorf("requests=%d | expected=%d", n, 1)
+	}
+}
+
+func Test_Person_Latency(t *testing.T) {
+	srv := swapitest.NewServer(swapitest.WithLatency(time.Second))
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, err := lib.NewClient(lib.BaseURL(srv.URL)).Person(ctx, 1)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("error=%v | expected=%v", err, context.DeadlineExceeded)
+	}
+}
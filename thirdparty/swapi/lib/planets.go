@@ -0,0 +1,40 @@
+package lib
+
+import (
+	"context"
+	"time"
+)
+
+// A Planet is a large mass, planet or planetoid in the Star Wars universe.
+type Planet struct {
+	Name           string   `json:"name"`
+	RotationPeriod string   `json:"rotation_period"`
+	OrbitalPeriod  string   `json:"orbital_period"`
+	Diameter       string   `json:"diameter"`
+	Climate        string   `json:"climate"`
+	Gravity        string   `json:"gravity"`
+	Terrain        string   `json:"terrain"`
+	SurfaceWater   string   `json:"surface_water"`
+	Population     string   `json:"population"`
+	ResidentURLs   []string `json:"residents"`
+	FilmURLs       []string `json:"films"`
+	Created        string   `json:"created"`
+	Edited         string   `json:"edited"`
+	URL            string   `json:"url"`
+}
+
+func (p Planet) GetID() int {
+	id, _ := getIDFromURL(p.URL)
+	return id
+}
+
+// GetEdited returns the edited time, zero when it is malformed
+func (p Planet) GetEdited() time.Time {
+	t, _ := parseTime(time.RFC3339, p.Edited)
+	return t
+}
+
+// AllPlanets retrieves every planet
+func (c *Client) AllPlanets(ctx context.Context) ([]Planet, error) {
+	return List(ctx, c, PlanetResource)
+}
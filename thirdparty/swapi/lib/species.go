@@ -0,0 +1,25 @@
+package lib
+
+// A Species is a type of person or character within the Star Wars universe.
+type Species struct {
+	Name            string   `json:"name"`
+	Classification  string   `json:"classification"`
+	Designation     string   `json:"designation"`
+	AverageHeight   string   `json:"average_height"`
+	SkinColors      string   `json:"skin_colors"`
+	HairColors      string   `json:"hair_colors"`
+	EyeColors       string   `json:"eye_colors"`
+	AverageLifespan string   `json:"average_lifespan"`
+	Homeworld       string   `json:"homeworld"` // empty for species without one, e.g. droids
+	Language        string   `json:"language"`
+	PeopleURLs      []string `json:"people"`
+	FilmURLs        []string `json:"films"`
+	Created         string   `json:"created"`
+	Edited          string   `json:"edited"`
+	URL             string   `json:"url"`
+}
+
+func (s Species) GetID() int {
+	id, _ := getIDFromURL(s.URL)
+	return id
+}
@@ -0,0 +1,111 @@
+package lib
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// states of a Measure
+const (
+	MeasureKnown         = "known"
+	MeasureUnknown       = "unknown" // swapi does not know the value
+	MeasureNotApplicable = "n/a"     // the value does not apply, e.g. to a hologram
+)
+
+// problems flagged by a Warning
+const (
+	ProblemMalformed = "malformed" // the value does not parse
+	ProblemUnknown   = "unknown"   // swapi does not know the value
+)
+
+// ErrMalformed is returned for a value swapi serves that does not parse
+var ErrMalformed = errors.New("malformed value")
+
+type (
+	// A Measure is a number swapi serves as a string, e.g. a height or a mass,
+	// or "unknown" / "n/a" when it has none
+	Measure struct {
+		Value *float64 // nil unless State is MeasureKnown
+		State string
+	}
+
+	// A Warning flags a field of a resource swapi serves malformed or without
+	// a value
+	Warning struct {
+		URL     string // of the resource
+		Field   string // json name of the field, e.g. "height"
+		Value   string // as served
+		Problem string // ProblemMalformed or ProblemUnknown
+	}
+)
+
+// ParseMeasure parses a number as swapi serves it, e.g. "172", "1,358",
+// "unknown" or "n/a". Anything else wraps ErrMalformed.
+func ParseMeasure(s string) (Measure, error) {
+	switch strings.TrimSpace(s) {
+	case MeasureUnknown:
+		return Measure{State: MeasureUnknown}, nil
+	case MeasureNotApplicable:
+		return Measure{State: MeasureNotApplicable}, nil
+	}
+
+	// thousands are separated by commas, e.g. the mass of Jabba
+	value, err := strconv.ParseFloat(strings.ReplaceAll(strings.TrimSpace(s), ",", ""), 64)
+	if err != nil || value < 0 {
+		return Measure{}, fmt.Errorf("%w: %q is not a number", ErrMalformed, s)
+	}
+	return Measure{Value: &value, State: MeasureKnown}, nil
+}
+
+// Known reports whether the measure has a value
+func (m Measure) Known() bool {
+	return m.Value != nil
+}
+
+// parseTime parses a time as swapi serves it, wrapping ErrMalformed
+func parseTime(layout, s string) (time.Time, error) {
+	t, err := time.Parse(layout, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %q is not a %s time", ErrMalformed, s, layout)
+	}
+	return t, nil
+}
+
+// validator collects the warnings of a resource
+type validator struct {
+	url      string
+	warnings []Warning
+}
+
+func (v *validator) warn(field, value, problem string) {
+	v.warnings = append(v.warnings, Warning{URL: v.url, Field: field, Value: value, Problem: problem})
+}
+
+// time flags the field when it does not parse
+func (v *validator) time(field, value string, parse func() (time.Time, error)) {
+	if _, err := parse(); err != nil {
+		v.warn(field, value, ProblemMalformed)
+	}
+}
+
+// measure flags the field when it does not parse or is unknown, n/a is not
+// flagged as the value does not apply
+func (v *validator) measure(field, value string) {
+	m, err := ParseMeasure(value)
+	switch {
+	case err != nil:
+		v.warn(field, value, ProblemMalformed)
+	case m.State == MeasureUnknown:
+		v.warn(field, value, ProblemUnknown)
+	}
+}
+
+// id flags the url when it holds no id
+func (v *validator) id() {
+	if _, err := getIDFromURL(v.url); err != nil {
+		v.warn("url", v.url, ProblemMalformed)
+	}
+}
@@ -0,0 +1,89 @@
+package lib_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+)
+
+func Test_ParseMeasure(t *testing.T) {
+	tests := []struct {
+		value string
+		state string
+		known float64
+		err   error
+	}{
+		{value: "172", state: lib.MeasureKnown, known: 172},
+		{value: "1,358", state: lib.MeasureKnown, known: 1358},
+		{value: "78.2", state: lib.MeasureKnown, known: 78.2},
+		{value: "unknown", state: lib.MeasureUnknown},
+		{value: "n/a", state: lib.MeasureNotApplicable},
+		{value: "", err: lib.ErrMalformed},
+		{value: "tall", err: lib.ErrMalformed},
+		{value: "-1", err: lib.ErrMalformed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			m, err := lib.ParseMeasure(tt.value)
+			if !errors.Is(err, tt.err) {
+				t.Fatalf("error=%v | expected=%v", err, tt.err)
+			}
+			if m.State != tt.state {
+				t.Errorf("state=%v | expected=%v", m.State, tt.state)
+			}
+			if m.Known() != (tt.state == lib.MeasureKnown) || (m.Known() && *m.Value != tt.known) {
+				t.Errorf("value=%v | expected=%v", m.Value, tt.known)
+			}
+		})
+	}
+}
+
+func Test_Person_Validate(t *testing.T) {
+	person := lib.Person{
+		Height: "unknown",
+		Mass:   "n/a",
+		Edited: "yesterday",
+		URL:    "https://swapi.dev/api/people/42/",
+	}
+
+	expected := []lib.Warning{
+		{URL: person.URL, Field: "height", Value: "unknown", Problem: lib.ProblemUnknown},
+		{URL: person.URL, Field: "edited", Value: "yesterday", Problem: lib.ProblemMalformed},
+	}
+	if warnings := person.Validate(); !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("warnings=%+v | expected=%+v", warnings, expected)
+	}
+
+	if !person.GetEdited().IsZero() {
+		t.Errorf("edited=%v | expected=zero", person.GetEdited())
+	}
+	if _, err := person.ParseEdited(); !errors.Is(err, lib.ErrMalformed) {
+		t.Errorf("error=%v | expected=%v", err, lib.ErrMalformed)
+	}
+}
+
+func Test_Film_Validate(t *testing.T) {
+	film := lib.Film{
+		ReleaseDate: "1977-05-25",
+		Created:     "2014-12-10T14:23:31.880000Z",
+		Edited:      "2014-12-20T19:49:45.256000Z",
+		URL:         "https://swapi.dev/api/films/1/",
+	}
+	if warnings := film.Validate(); len(warnings) != 0 {
+		t.Errorf("warnings=%+v | expected=none", warnings)
+	}
+
+	film.ReleaseDate = "25/05/1977"
+	film.URL = "https://swapi.dev/api/films/"
+
+	expected := []lib.Warning{
+		{URL: film.URL, Field: "url", Value: film.URL, Problem: lib.ProblemMalformed},
+		{URL: film.URL, Field: "release_date", Value: "25/05/1977", Problem: lib.ProblemMalformed},
+	}
+	if warnings := film.Validate(); !reflect.DeepEqual(warnings, expected) {
+		t.Errorf("warnings=%+v | expected=%+v", warnings, expected)
+	}
+}
@@ -0,0 +1,119 @@
+package swapi
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rs/zerolog/log"
+
+	"github.com/iamnator/movie-api/pkg/clock"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+)
+
+// Limiter paces the requests sent to swapi with a token bucket shared by
+// every worker and retry, and holds them all back when swapi throttles.
+type Limiter struct {
+	rate  float64 // tokens added per second
+	burst float64
+	clock clock.Clock
+
+	mu          sync.Mutex
+	tokens      float64 // negative when requests are waiting on tokens reserved ahead
+	last        time.Time
+	pausedUntil time.Time // set by a 429's Retry-After
+}
+
+// NewLimiter allows perSecond requests a second on average, and bursts of up
+// to burst requests
+func NewLimiter(perSecond float64, burst int, c clock.Clock) *Limiter {
+	if burst < 1 {
+		burst = 1
+	}
+	if c == nil {
+		c = clock.System
+	}
+
+	return &Limiter{
+		rate:   perSecond,
+		burst:  float64(burst),
+		clock:  c,
+		tokens: float64(burst),
+		last:   c.Now(),
+	}
+}
+
+// reserve takes a token and returns how long to wait before using it
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.clock.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens--
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / l.rate * float64(time.Second))
+	}
+	if paused := l.pausedUntil.Sub(now); paused > wait {
+		wait = paused
+	}
+	return wait
+}
+
+// Wait blocks until the request may be sent, or the context is done
+func (l *Limiter) Wait(ctx context.Context) error {
+	wait := l.reserve()
+	if wait <= 0 {
+		return ctx.Err()
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-l.clock.After(wait):
+		return nil
+	}
+}
+
+// Pause holds every request back for d, as asked by a throttled response
+func (l *Limiter) Pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if until := l.clock.Now().Add(d); until.After(l.pausedUntil) {
+		l.pausedUntil = until
+	}
+}
+
+// Transport paces the requests sent through next
+func (l *Limiter) Transport(next http.RoundTripper) http.RoundTripper {
+	return limitedTransport{limiter: l, next: next}
+}
+
+type limitedTransport struct {
+	limiter *Limiter
+	next    http.RoundTripper
+}
+
+func (t limitedTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.limiter.Wait(req.Context()); err != nil {
+		return nil, err
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err == nil && resp.StatusCode == http.StatusTooManyRequests {
+		if d := lib.ParseRetryAfter(resp.Header, t.limiter.clock.Now()); d > 0 {
+			log.Ctx(req.Context()).Warn().Dur("retry_after", d).Msg("throttled by swapi, pausing requests")
+			t.limiter.Pause(d)
+		}
+	}
+
+	return resp, err
+}
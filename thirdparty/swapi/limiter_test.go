@@ -0,0 +1,76 @@
+package swapi_test
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/iamnator/movie-api/pkg/backoff"
+	"github.com/iamnator/movie-api/thirdparty/swapi"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitest"
+)
+
+func Test_Limiter_Paces(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	limiter := swapi.NewLimiter(100, 2, nil)
+	c := lib.NewClient(lib.BaseURL(srv.URL), lib.HTTPClient(&http.Client{Transport: limiter.Transport(http.DefaultTransport)}))
+
+	// the burst goes out at once, the 4 others a token every 10ms
+	start := time.Now()
+	for i := 0; i < 6; i++ {
+		if _, err := c.Person(context.Background(), 1); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if elapsed := time.Since(start); elapsed < 35*time.Millisecond {
+		t.Errorf("elapsed=%s | expected=at least %s", elapsed, 40*time.Millisecond)
+	}
+}
+
+func Test_Limiter_RetryAfter(t *testing.T) {
+	srv := swapitest.NewServer()
+	defer srv.Close()
+
+	srv.Inject("people/1", swapitest.Fault{Status: http.StatusTooManyRequests, RetryAfter: 1, Times: 1})
+
+	limiter := swapi.NewLimiter(100, 10, nil)
+	policy := backoff.Policy{InitialInterval: time.Millisecond, MaxInterval: time.Millisecond, Multiplier: 1, MaxAttempts: 3}
+	c := lib.NewClient(lib.BaseURL(srv.URL), lib.Retry(policy), lib.HTTPClient(&http.Client{Transport: limiter.Transport(http.DefaultTransport)}))
+
+	start := time.Now()
+	person, err := c.Person(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("error=%v | expected=nil", err)
+	}
+
+	if person.Name != "Luke Skywalker" {
+		t.Errorf("name=%v | expected=%v", person.Name, "Luke Skywalker")
+	}
+	if elapsed := time.Since(start); elapsed < 900*time.Millisecond {
+		t.Errorf("elapsed=%s | expected=the 1s asked by Retry-After", elapsed)
+	}
+
+	// other requests wait out the pause too
+	start = time.Now()
+	limiter.Pause(50 * time.Millisecond)
+	if err := limiter.Wait(context.Background()); err != nil || time.Since(start) < 45*time.Millisecond {
+		t.Errorf("waited=%s, error=%v | expected=the pause", time.Since(start), err)
+	}
+}
+
+func Test_Limiter_WaitCancelled(t *testing.T) {
+	limiter := swapi.NewLimiter(1, 1, nil)
+	limiter.Pause(time.Minute)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	if err := limiter.Wait(ctx); err != context.DeadlineExceeded {
+		t.Errorf("error=%v | expected=%v", err, context.DeadlineExceeded)
+	}
+}
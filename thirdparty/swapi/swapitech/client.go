@@ -0,0 +1,151 @@
+// Package swapitech reads swapi.tech, which serves swapi's data in another
+// json shape: the fields of a resource are nested in its "properties", lists
+// only name their resources unless expanded, and films are not paged.
+package swapitech
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+)
+
+// DefaultBaseURL is where swapi.tech serves its api, under /api/
+const DefaultBaseURL = "https://www.swapi.tech"
+
+type (
+	// resource is a resource as swapi.tech serves it, its swapi.dev fields
+	// under properties
+	resource[T any] struct {
+		UID        string `json:"uid"`
+		Properties T      `json:"properties"`
+	}
+
+	// single is the response to a resource, e.g. people/1
+	single[T any] struct {
+		Result resource[T] `json:"result"`
+	}
+
+	// unpaged is the response to a list served whole, e.g. films
+	unpaged[T any] struct {
+		Result []resource[T] `json:"result"`
+	}
+
+	// page is a page of a paged list, e.g. people
+	page[T any] struct {
+		TotalRecords int           `json:"total_records"`
+		Next         *string       `json:"next"`
+		Results      []resource[T] `json:"results"`
+	}
+
+	// Client reads films, people and planets from swapi.tech, decoded as swapi.dev
+	// serves them
+	Client struct {
+		client *lib.Client
+	}
+)
+
+// NewClient returns a client of swapi.tech, or of the api at lib.BaseURL
+// shaped like it. The options are those of the swapi.dev client.
+func NewClient(opts ...lib.Option) *Client {
+	opts = append([]lib.Option{lib.BaseURL(DefaultBaseURL)}, opts...)
+
+	return &Client{
+		client: lib.NewClient(opts...),
+	}
+}
+
+// CacheStats returns how the response cache answered the requests so far
+func (c *Client) CacheStats() lib.CacheStats {
+	return c.client.CacheStats()
+}
+
+// Film retrieves the film with the given id
+func (c *Client) Film(ctx context.Context, id int) (lib.Film, error) {
+	return get[lib.Film](ctx, c.client, "films", id)
+}
+
+// AllFilms retrieves every film
+func (c *Client) AllFilms(ctx context.Context) ([]lib.Film, error) {
+	var films unpaged[lib.Film]
+	if err := c.client.Fetch(ctx, "films", &films); err != nil {
+		return nil, err
+	}
+
+	return properties(films.Result), nil
+}
+
+// Person retrieves the person with the given id
+func (c *Client) Person(ctx context.Context, id int) (lib.Person, error) {
+	return get[lib.Person](ctx, c.client, "people", id)
+}
+
+// AllPeople retrieves every person, following the pages
+func (c *Client) AllPeople(ctx context.Context) ([]lib.Person, error) {
+	return list[lib.Person](ctx, c.client, "people")
+}
+
+// AllPlanets retrieves every planet, following the pages
+func (c *Client) AllPlanets(ctx context.Context) ([]lib.Planet, error) {
+	return list[lib.Planet](ctx, c.client, "planets")
+}
+
+func get[T any](ctx context.Context, c *lib.Client, kind string, id int) (T, error) {
+	var r single[T]
+	if err := c.Fetch(ctx, fmt.Sprintf("%s/%d", kind, id), &r); err != nil {
+		var zero T
+		return zero, err
+	}
+
+	return r.Result.Properties, nil
+}
+
+// list reads every page of a paged list, expanded so the pages hold the
+// properties of the resources rather than their names
+func list[T any](ctx context.Context, c *lib.Client, kind string) ([]T, error) {
+	var resources []T
+
+	ref := kind + "?expanded=true"
+	for {
+		var p page[T]
+		if err := c.Fetch(ctx, ref, &p); err != nil {
+			return nil, err
+		}
+
+		resources = append(resources, properties(p.Results)...)
+
+		if p.Next == nil || *p.Next == "" {
+			return resources, nil
+		}
+
+		next, err := expanded(*p.Next)
+		if err != nil {
+			return nil, err
+		}
+		ref = next
+	}
+}
+
+// expanded returns the url of the next page asking for it expanded too, the
+// next links of swapi.tech drop the parameter
+func expanded(next string) (string, error) {
+	u, err := url.Parse(next)
+	if err != nil {
+		return "", fmt.Errorf("error parsing next page %q: %w", next, err)
+	}
+
+	q := u.Query()
+	q.Set("expanded", "true")
+	u.RawQuery = q.Encode()
+
+	return u.String(), nil
+}
+
+func properties[T any](resources []resource[T]) []T {
+	out := make([]T, len(resources))
+	for i, r := range resources {
+		out[i] = r.Properties
+	}
+	return out
+}
@@ -0,0 +1,121 @@
+package swapitech_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/iamnator/movie-api/thirdparty/swapi/fixture"
+	lib "github.com/iamnator/movie-api/thirdparty/swapi/lib"
+	"github.com/iamnator/movie-api/thirdparty/swapi/swapitech"
+)
+
+// pageSize of the people and planets listed by the fake swapi.tech
+const pageSize = 10
+
+// newServer serves the bundled snapshot in the shape of swapi.tech
+func newServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	snapshot := fixture.Bundled()
+
+	var srv *httptest.Server
+	srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		resource := func(kind string, id int) map[string]interface{} {
+			data, _ := snapshot.Get(kind, id)
+			return map[string]interface{}{"uid": strconv.Itoa(id), "properties": json.RawMessage(data)}
+		}
+
+		var body interface{}
+		switch segments := strings.Split(strings.Trim(strings.TrimPrefix(r.URL.Path, "/api/"), "/"), "/"); {
+		case len(segments) == 2:
+			id, _ := strconv.Atoi(segments[1])
+			if _, ok := snapshot.Get(segments[0], id); !ok {
+				w.WriteHeader(http.StatusNotFound)
+				_, _ = w.Write([]byte(`{"message": "not found"}`))
+				return
+			}
+			body = map[string]interface{}{"message": "ok", "result": resource(segments[0], id)}
+		case segments[0] == "films":
+			var films []interface{}
+			for _, id := range snapshot.IDs("films") {
+				films = append(films, resource("films", id))
+			}
+			body = map[string]interface{}{"message": "ok", "result": films}
+		case (segments[0] == "people" || segments[0] == "planets") && r.URL.Query().Get("expanded") == "true":
+			kind := segments[0]
+			ids := snapshot.IDs(kind)
+			page, _ := strconv.Atoi(r.URL.Query().Get("page"))
+			if page < 1 {
+				page = 1
+			}
+
+			var results []interface{}
+			for i := (page - 1) * pageSize; i < len(ids) && i < page*pageSize; i++ {
+				results = append(results, resource(kind, ids[i]))
+			}
+
+			// like swapi.tech, the next link drops expanded
+			var next *string
+			if page*pageSize < len(ids) {
+				u := fmt.Sprintf("%s/api/%s?page=%d&limit=%d", srv.URL, kind, page+1, pageSize)
+				next = &u
+			}
+			body = map[string]interface{}{"message": "ok", "total_records": len(ids), "next": next, "results": results}
+		default:
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(srv.Close)
+
+	return srv
+}
+
+func Test_Client(t *testing.T) {
+	c := swapitech.NewClient(lib.BaseURL(newServer(t).URL))
+	ctx := context.Background()
+
+	film, err := c.Film(ctx, 1)
+	if err != nil || film.Title != "A New Hope" || film.GetID() != 1 || len(film.CharacterURLs) == 0 {
+		t.Errorf("film=%+v error=%v | expected=A New Hope", film, err)
+	}
+
+	films, err := c.AllFilms(ctx)
+	if err != nil || len(films) != 6 {
+		t.Errorf("films=%d error=%v | expected=%d", len(films), err, 6)
+	}
+
+	person, err := c.Person(ctx, 1)
+	if err != nil || person.Name != "Luke Skywalker" || person.Height != "172" {
+		t.Errorf("person=%+v error=%v | expected=Luke Skywalker", person, err)
+	}
+
+	people, err := c.AllPeople(ctx)
+	if ids := fixture.Bundled().IDs("people"); err != nil || len(people) != len(ids) {
+		t.Fatalf("people=%d error=%v | expected=%d", len(people), err, len(ids))
+	}
+	if people[0].Name != "Luke Skywalker" {
+		t.Errorf("people[0]=%v | expected=%v", people[0].Name, "Luke Skywalker")
+	}
+
+	planets, err := c.AllPlanets(ctx)
+	if ids := fixture.Bundled().IDs("planets"); err != nil || len(planets) != len(ids) || planets[0].GetID() != ids[0] {
+		t.Errorf("planets=%d error=%v | expected=%d", len(planets), err, len(ids))
+	}
+
+	_, err = c.Person(ctx, 1000)
+	var respErr *lib.ResponseError
+	if !errors.As(err, &respErr) || respErr.StatusCode != http.StatusNotFound {
+		t.Errorf("error=%v | expected=404", err)
+	}
+}
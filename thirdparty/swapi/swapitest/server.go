@@ -0,0 +1,339 @@
+// Package swapitest runs a local fake of swapi's http api for tests, serving
+// a fixture snapshot with the routes and pagination of swapi.dev and the
+// failures injected by the test.
+package swapitest
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/iamnator/movie-api/thirdparty/swapi/fixture"
+)
+
+const (
+	// apiPath is where the routes are served, as on swapi.dev
+	apiPath = "/api/"
+
+	// snapshotBaseURL is the api root of the urls held by the snapshots,
+	// rewritten to the server's own
+	snapshotBaseURL = "https://swapi.dev/api/"
+
+	// DefaultPageSize is the size of swapi's pages
+	DefaultPageSize = 10
+)
+
+type (
+	// A Fault is injected in the responses to a route in place of the
+	// resource
+	Fault struct {
+		// Status replies with this status code and an error body, 0 serves the
+		// resource
+		Status int
+		// RetryAfter is sent as the Retry-After header of the error, in seconds
+		RetryAfter int
+		// Malformed replies 200 with a body that is not valid json
+		Malformed bool
+		// Latency delays the response, on top of the server's latency
+		Latency time.Duration
+		// Times the fault is injected before the route recovers, 0 means always
+		Times int
+	}
+
+	// Server is a fake swapi, call Close once done
+	Server struct {
+		// URL of the server, the client's lib.BaseURL
+		URL string
+
+		server       *httptest.Server
+		snapshot     *fixture.Snapshot
+		pageSize     int
+		latency      time.Duration
+		cacheControl string
+
+		mu          sync.Mutex
+		faults      map[string]*Fault
+		requests    map[string]int
+		notModified int
+		inFlight    int
+		maxInFlight int
+	}
+
+	Option func(*Server)
+)
+
+// WithSnapshot serves the snapshot instead of the bundled one
+func WithSnapshot(snapshot *fixture.Snapshot) Option {
+	return func(s *Server) {
+		s.snapshot = snapshot
+	}
+}
+
+// WithPageSize splits the lists in pages of n resources
+func WithPageSize(n int) Option {
+	return func(s *Server) {
+		s.pageSize = n
+	}
+}
+
+// WithLatency delays every response by d
+func WithLatency(d time.Duration) Option {
+	return func(s *Server) {
+		s.latency = d
+	}
+}
+
+// WithCacheControl sets the Cache-Control header of the resources served
+func WithCacheControl(value string) Option {
+	return func(s *Server) {
+		s.cacheControl = value
+	}
+}
+
+// NewServer starts a fake swapi serving the bundled snapshot
+func NewServer(opts ...Option) *Server {
+	s := &Server{
+		pageSize: DefaultPageSize,
+		faults:   make(map[string]*Fault),
+		requests: make(map[string]int),
+	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	if s.snapshot == nil {
+		s.snapshot = fixture.Bundled()
+	}
+
+	s.server = httptest.NewServer(http.HandlerFunc(s.serveHTTP))
+	s.URL = s.server.URL
+	return s
+}
+
+func (s *Server) Close() {
+	s.server.Close()
+}
+
+// Inject makes the server answer requests to route with the fault. Routes are
+// relative to the api root, without slashes or query, e.g. "films" or
+// "people/1".
+func (s *Server) Inject(route string, fault Fault) {
+	s.mu.Lock()
+	s.faults[normaliseRoute(route)] = &fault
+	s.mu.Unlock()
+}
+
+// Requests returns how many requests were made to the route, faulted or not
+func (s *Server) Requests(route string) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.requests[normaliseRoute(route)]
+}
+
+// MaxInFlight returns the most requests the server was handling at once
+func (s *Server) MaxInFlight() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.maxInFlight
+}
+
+// NotModified returns how many requests were answered 304 not modified
+func (s *Server) NotModified() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return s.notModified
+}
+
+func normaliseRoute(route string) string {
+	return strings.Trim(route, "/")
+}
+
+// fault counts the request to route and returns the fault to inject in its
+// response, the zero Fault when there is none
+func (s *Server) fault(route string) Fault {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.requests[route]++
+
+	f, ok := s.faults[route]
+	if !ok {
+		return Fault{}
+	}
+	if f.Times > 0 {
+		if f.Times--; f.Times == 0 {
+			delete(s.faults, route)
+		}
+	}
+	return *f
+}
+
+func (s *Server) serveHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet || !strings.HasPrefix(r.URL.Path, apiPath) {
+		s.writeError(w, http.StatusNotFound)
+		return
+	}
+
+	route := normaliseRoute(strings.TrimPrefix(r.URL.Path, apiPath))
+	fault := s.fault(route)
+
+	s.mu.Lock()
+	if s.inFlight++; s.inFlight > s.maxInFlight {
+		s.maxInFlight = s.inFlight
+	}
+	s.mu.Unlock()
+	defer func() {
+		s.mu.Lock()
+		s.inFlight--
+		s.mu.Unlock()
+	}()
+
+	select {
+	case <-time.After(s.latency + fault.Latency):
+	case <-r.Context().Done():
+		return
+	}
+
+	switch {
+	case fault.Status != 0:
+		if fault.RetryAfter > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(fault.RetryAfter))
+		}
+		s.writeError(w, fault.Status)
+		return
+	case fault.Malformed:
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"name": "Luke Sky`))
+		return
+	}
+
+	segments := strings.Split(route, "/")
+	switch len(segments) {
+	case 1:
+		s.writeList(w, r, segments[0])
+	case 2:
+		id, err := strconv.Atoi(segments[1])
+		if err != nil {
+			s.writeError(w, http.StatusNotFound)
+			return
+		}
+		s.writeResource(w, r, segments[0], id)
+	default:
+		s.writeError(w, http.StatusNotFound)
+	}
+}
+
+// list is a page of a list, as swapi serves it
+type list struct {
+	Count    int               `json:"count"`
+	Next     *string           `json:"next"`
+	Previous *string           `json:"previous"`
+	Results  []json.RawMessage `json:"results"`
+}
+
+func (s *Server) writeList(w http.ResponseWriter, r *http.Request, kind string) {
+	ids := s.snapshot.IDs(kind)
+	if len(ids) == 0 {
+		s.writeError(w, http.StatusNotFound)
+		return
+	}
+
+	page := 1
+	if p := r.URL.Query().Get("page"); p != "" {
+		var err error
+		if page, err = strconv.Atoi(p); err != nil || page < 1 {
+			s.writeError(w, http.StatusNotFound)
+			return
+		}
+	}
+
+	start := (page - 1) * s.pageSize
+	if start >= len(ids) {
+		s.writeError(w, http.StatusNotFound)
+		return
+	}
+	end := start + s.pageSize
+	if end > len(ids) {
+		end = len(ids)
+	}
+
+	l := list{Count: len(ids), Results: make([]json.RawMessage, 0, end-start)}
+	for _, id := range ids[start:end] {
+		resource, _ := s.snapshot.Get(kind, id)
+		l.Results = append(l.Results, resource)
+	}
+	if end < len(ids) {
+		l.Next = s.pageURL(kind, page+1)
+	}
+	if page > 1 {
+		l.Previous = s.pageURL(kind, page-1)
+	}
+
+	s.writeJSON(w, r, l)
+}
+
+func (s *Server) pageURL(kind string, page int) *string {
+	url := fmt.Sprintf("%s%s%s/?page=%d", s.URL, apiPath, kind, page)
+	return &url
+}
+
+func (s *Server) writeResource(w http.ResponseWriter, r *http.Request, kind string, id int) {
+	resource, ok := s.snapshot.Get(kind, id)
+	if !ok {
+		s.writeError(w, http.StatusNotFound)
+		return
+	}
+
+	s.writeJSON(w, r, resource)
+}
+
+// writeJSON writes v tagged with the ETag of its body, replying 304 not
+// modified to a request already holding it, as swapi.dev does
+func (s *Server) writeJSON(w http.ResponseWriter, r *http.Request, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		s.writeError(w, http.StatusInternalServerError)
+		return
+	}
+
+	// the resources link each other with swapi.dev urls
+	body = bytes.ReplaceAll(body, []byte(snapshotBaseURL), []byte(s.URL+apiPath))
+
+	sum := sha256.Sum256(body)
+	etag := `"` + hex.EncodeToString(sum[:8]) + `"`
+
+	w.Header().Set("ETag", etag)
+	if s.cacheControl != "" {
+		w.Header().Set("Cache-Control", s.cacheControl)
+	}
+	if r.Header.Get("If-None-Match") == etag {
+		s.mu.Lock()
+		s.notModified++
+		s.mu.Unlock()
+
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_, _ = w.Write(body)
+}
+
+// writeError replies like swapi, with the status text as detail
+func (s *Server) writeError(w http.ResponseWriter, status int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(map[string]string{"detail": http.StatusText(status)})
+}